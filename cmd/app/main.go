@@ -2,8 +2,9 @@ package main
 
 import (
 	"StudentManager/internal/app"
+	"os"
 )
 
 func main() {
-	app.Run()
+	os.Exit(app.Run())
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"StudentManager/internal/config"
+	"StudentManager/internal/logger"
+	"StudentManager/internal/migrate"
+	"StudentManager/pkg/database/postgres"
+	"StudentManager/pkg/database/sqlite"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  status    show applied and pending migrations
+  up        apply all pending migrations
+  down N    revert the last N migrations (default 1)
+  redo      revert and re-apply the last migration`
+
+func main() {
+	os.Exit(run())
+}
+
+// run returns the exit code, so the database is closed before the process exits
+func run() int {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		return 2
+	}
+
+	cfg := config.Init()
+	logs, err := logger.New(cfg.Env, cfg.Log, os.Stderr)
+	if err != nil {
+		slog.Error("invalid log config", "err", err)
+		return 1
+	}
+	slog.SetDefault(logs)
+
+	migrator, closeDb, err := newMigrator(cfg.Database)
+	if err != nil {
+		slog.Error("failed to set up migrations", "err", err)
+		return 1
+	}
+	defer closeDb()
+
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			slog.Error("failed to get migrations status", "err", err)
+			return 1
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			slog.Error("failed to apply migrations", "err", err)
+			return 1
+		}
+		slog.Info("applied migrations", "count", applied)
+	case "down":
+		n := 1
+		if len(os.Args) > 2 {
+			parsed, err := strconv.Atoi(os.Args[2])
+			if err != nil || parsed < 1 {
+				slog.Error("invalid number of migrations", "n", os.Args[2])
+				return 2
+			}
+			n = parsed
+		}
+		reverted, err := migrator.Down(ctx, n)
+		if err != nil {
+			slog.Error("failed to revert migrations", "err", err)
+			return 1
+		}
+		slog.Info("reverted migrations", "count", reverted)
+	case "redo":
+		if err := migrator.Redo(ctx); err != nil {
+			slog.Error("failed to redo migration", "err", err)
+			return 1
+		}
+	default:
+		fmt.Println(usage)
+		return 2
+	}
+
+	return 0
+}
+
+func newMigrator(database config.Database) (*migrate.Migrator, func(), error) {
+	switch database.Driver {
+	case config.DriverPostgres:
+		db, err := postgres.New(database)
+		if err != nil {
+			return nil, nil, err
+		}
+		migrator, err := migrate.NewPostgres(db)
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		return migrator, db.Close, nil
+	case config.DriverSQLite:
+		db, err := sqlite.New(database)
+		if err != nil {
+			return nil, nil, err
+		}
+		migrator, err := migrate.NewSQLite(db)
+		if err != nil {
+			_ = db.Close()
+			return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		return migrator, func() { _ = db.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver: %s", database.Driver)
+	}
+}
@@ -1,35 +1,162 @@
 package app
 
 import (
+	"StudentManager/internal/auth"
 	"StudentManager/internal/config"
+	"StudentManager/internal/health"
 	"StudentManager/internal/http/handler"
+	mw "StudentManager/internal/http/middleware"
 	"StudentManager/internal/http/service"
+	"StudentManager/internal/logger"
+	"StudentManager/internal/metrics"
 	"StudentManager/internal/repository"
-	"StudentManager/pkg/database/postgres"
+	"StudentManager/internal/tracing"
+	"StudentManager/internal/validation"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-func Run() {
+// exit codes of Run
+const (
+	ExitOK = 0
+	// the app couldn't start or the server failed while serving
+	ExitFailure = 1
+	// in-flight requests didn't finish within http_server.shutdown_timeout
+	// and were cut off
+	ExitDrainTimeout = 2
+)
+
+// Run serves until SIGINT or SIGTERM, then stops accepting connections,
+// waits for in-flight requests up to http_server.shutdown_timeout and
+// releases the storage. A second signal cuts the wait short.
+func Run() int {
 	cfg := config.Init()
-	db, err := postgres.New(cfg.Database)
+
+	logs, err := logger.New(cfg.Env, cfg.Log, os.Stderr)
+	if err != nil {
+		slog.Error("invalid log config", "err", err)
+		return ExitFailure
+	}
+	slog.SetDefault(logs)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("invalid tracing config", "err", err)
+		return ExitFailure
+	}
+	// spans of the last requests are flushed once everything else is closed
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush spans", "err", err)
+		}
+	}()
+
+	repos, err := repository.NewRepositories(cfg)
+	if err != nil {
+		slog.Error("failed to set up storage", "err", err)
+		return ExitFailure
+	}
+	defer func() {
+		repos.Close()
+		slog.Info("storage closed")
+	}()
+
+	validator, err := validation.New(cfg.Validation)
 	if err != nil {
-		log.Fatal(err)
-		return
+		slog.Error("invalid validation config", "err", err)
+		return ExitFailure
+	}
+
+	credentials := auth.NewCredentials(cfg.HTTPServer.User, cfg.HTTPServer.Password)
+	tokens, err := auth.NewTokens(cfg.Auth)
+	if err != nil {
+		slog.Error("invalid auth config", "err", err)
+		return ExitFailure
+	}
+	if tokens == nil {
+		slog.Info("token authentication is disabled, set auth.algorithm to enable it")
+	}
+
+	timeout, err := mw.Timeout(cfg.HTTPServer.RequestTimeout, cfg.HTTPServer.RouteTimeouts)
+	if err != nil {
+		slog.Error("invalid http_server config", "err", err)
+		return ExitFailure
+	}
+
+	deletePolicy, err := service.NewDeletePolicy(cfg.Groups)
+	if err != nil {
+		slog.Error("invalid groups config", "err", err)
+		return ExitFailure
+	}
+
+	appServices := service.NewServices(repos, tokens, credentials, deletePolicy)
+	handlers := handler.NewHandlers(appServices, validator)
+
+	checker := health.NewChecker(
+		health.Check{Name: "storage", Run: repos.Ping},
+		health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+			pending, err := repos.PendingMigrations(ctx)
+			if err != nil {
+				return err
+			}
+			if pending > 0 {
+				return fmt.Errorf("%d migrations pending", pending)
+			}
+			return nil
+		}},
+	)
+
+	for _, collector := range repos.Collectors() {
+		if err := metrics.Registry.Register(collector); err != nil {
+			slog.Error("failed to register storage metrics", "err", err)
+			return ExitFailure
+		}
 	}
-	repos := repository.NewRepositories(db)
-	appServices := service.NewServices(repos)
-	handlers := handler.NewHandlers(appServices)
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
-	handlers.InitRoutes(r)
+	// probes skip the request log and everything that could fail for reasons they don't check
+	handler.NewHealthHandler(checker).InitRoutes(r)
+	var metricsSrv *http.Server
+	if cfg.MetricsAddress == cfg.Address {
+		r.Handle("/metrics", metrics.Handler())
+	} else {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:        cfg.MetricsAddress,
+			Handler:     metricsMux,
+			ReadTimeout: cfg.HTTPServer.Timeout,
+			IdleTimeout: cfg.HTTPServer.IdleTimeout,
+		}
+	}
+
+	stats := &mw.RequestStats{}
+	r.Group(func(r chi.Router) {
+		r.Use(mw.Trace)
+		r.Use(mw.LogRequests)
+		r.Use(mw.Metrics)
+		r.Use(timeout)
+		r.Use(mw.CountOutcomes(stats))
+		r.Use(mw.Authenticate(appServices.Users, tokens, cfg.PublicPaths))
+		r.Use(mw.ResolveTenant(cfg.Tenancy, appServices.Tenants, cfg.PublicPaths))
+
+		handlers.InitRoutes(r)
+	})
 
 	// Я закончил на добавлении групп надо потестить запросы к ним
 	/* 1) Доделать группы (проверить при создании студента есть ли группа в бд, также добавить проверку при апдейте студента
@@ -44,11 +171,89 @@ func Run() {
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
-	log.Println("Listening on server address " + cfg.Address)
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatalf("failed to start server")
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("listening", "address", cfg.Address)
+		serveErr <- srv.ListenAndServe()
+	}()
+	if metricsSrv != nil {
+		go func() {
+			slog.Info("serving metrics", "address", cfg.MetricsAddress)
+			serveErr <- metricsSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		slog.Error("server failed", "err", err)
+		return ExitFailure
+	case sig := <-signals:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+
+	code := shutdown(srv, cfg.HTTPServer, checker, signals)
+	// metrics stay up while requests drain, so the last of them can still be scraped
+	if metricsSrv != nil {
+		if err := metricsSrv.Close(); err != nil {
+			slog.Error("failed to close metrics server", "err", err)
+		}
+	}
+
+	counts := stats.Counts()
+	slog.Info("requests served", "completed", counts.Completed, "failed", counts.Failed,
+		"canceled", counts.Canceled, "timed_out", counts.TimedOut)
+
+	return code
+}
+
+// shutdown turns /readyz down, gives the load balancer http_server.drain_delay
+// to notice and then drains srv. Storage is closed by Run once it returns,
+// after the last request is done with it.
+func shutdown(srv *http.Server, cfg config.HTTPServer, checker *health.Checker, signals <-chan os.Signal) int {
+	checker.StartDraining()
+	if cfg.DrainDelay > 0 {
+		slog.Warn("not ready anymore, still serving", "drain_delay", cfg.DrainDelay)
+		select {
+		case <-time.After(cfg.DrainDelay):
+		case sig := <-signals:
+			slog.Warn("signal received again, cutting off in-flight requests", "signal", sig.String())
+			if err := srv.Close(); err != nil {
+				slog.Error("failed to close server", "err", err)
+			}
+			return ExitDrainTimeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Warn("signal received again, cutting off in-flight requests", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		slog.Warn("in-flight requests didn't finish in time, closing their connections")
+		if err := srv.Close(); err != nil {
+			slog.Error("failed to close server", "err", err)
+		}
+		return ExitDrainTimeout
+	}
+	if err != nil {
+		slog.Error("failed to shut down server", "err", err)
+		return ExitFailure
 	}
 
-	log.Fatalf("server stopped")
+	slog.Info("server stopped")
+	return ExitOK
 }
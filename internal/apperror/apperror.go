@@ -0,0 +1,97 @@
+// Package apperror defines the errors services return to the transport layer.
+// Every error belongs to one of the sentinel kinds, which decide the HTTP
+// status, and carries a stable machine-readable code for clients.
+package apperror
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrDependency = errors.New("dependency failed")
+	// ErrUnauthorized means the caller is unknown, ErrForbidden that the
+	// caller is known but not allowed to do what they asked
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	// ErrCanceled means the caller went away, ErrTimeout that the work
+	// took longer than it was allowed to
+	ErrCanceled = errors.New("canceled")
+	ErrTimeout  = errors.New("timeout")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Cause is kept for logs and is never shown to clients
+	Cause error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+
+	return e.Message
+}
+
+// Unwrap lets errors.Is match both the kind and the cause
+func (e *Error) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Kind, e.Cause}
+	}
+
+	return []error{e.Kind}
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Dependency(code, message string, cause error) *Error {
+	return &Error{Kind: ErrDependency, Code: code, Message: message, Cause: cause}
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+// Interrupted returns the error for work stopped by a done context, ctxErr is
+// what the context's Err returned
+func Interrupted(ctxErr error) *Error {
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		return &Error{Kind: ErrTimeout, Code: "request_timeout", Message: "request took too long", Cause: ctxErr}
+	}
+
+	return &Error{Kind: ErrCanceled, Code: "request_canceled", Message: "request was canceled", Cause: ctxErr}
+}
+
+// As returns the *Error in err's chain, if there is one
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	ok := errors.As(err, &appErr)
+
+	return appErr, ok
+}
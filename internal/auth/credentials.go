@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// PasswordChecker verifies the password of a user, wherever users are kept
+type PasswordChecker interface {
+	CheckPassword(ctx context.Context, user, password string) bool
+}
+
+// Credentials checks a user name and password against the configured ones.
+// The password may be stored as a bcrypt hash ("$2a$...", "$2b$...", "$2y$...").
+type Credentials struct {
+	user         string
+	userHash     [32]byte
+	passwordHash [32]byte
+	bcryptHash   []byte
+}
+
+func NewCredentials(user, password string) *Credentials {
+	credentials := &Credentials{
+		user:     user,
+		userHash: sha256.Sum256([]byte(user)),
+	}
+	if isBcryptHash(password) {
+		credentials.bcryptHash = []byte(password)
+	} else {
+		credentials.passwordHash = sha256.Sum256([]byte(password))
+	}
+
+	return credentials
+}
+
+// User is the configured user name
+func (c *Credentials) User() string {
+	return c.user
+}
+
+// CheckPassword makes Credentials a PasswordChecker on its own
+func (c *Credentials) CheckPassword(_ context.Context, user, password string) bool {
+	return c.Check(user, password)
+}
+
+// Check compares in constant time. Values are hashed first, so their length
+// doesn't leak either, and the user is always checked together with the password.
+func (c *Credentials) Check(user, password string) bool {
+	userHash := sha256.Sum256([]byte(user))
+	userOk := subtle.ConstantTimeCompare(userHash[:], c.userHash[:]) == 1
+
+	var passwordOk bool
+	if c.bcryptHash != nil {
+		passwordOk = bcrypt.CompareHashAndPassword(c.bcryptHash, []byte(password)) == nil
+	} else {
+		passwordHash := sha256.Sum256([]byte(password))
+		passwordOk = subtle.ConstantTimeCompare(passwordHash[:], c.passwordHash[:]) == 1
+	}
+
+	return userOk && passwordOk
+}
+
+func isBcryptHash(password string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(password, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
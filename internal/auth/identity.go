@@ -0,0 +1,33 @@
+// Package auth knows who the caller is: it checks credentials, signs and
+// parses access tokens and carries the caller identity in a context.
+package auth
+
+import (
+	"context"
+)
+
+const (
+	MethodBasic  = "basic"
+	MethodBearer = "bearer"
+)
+
+type Identity struct {
+	Subject string
+	// how the caller authenticated, MethodBasic or MethodBearer
+	Method string
+	// the tenant a bearer token was issued for, if it is bound to one
+	Tenant string
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the caller of the request ctx belongs to, if it authenticated
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+
+	return identity, ok
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"StudentManager/internal/config"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"time"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired, signed
+// with another key or issued by someone else
+var ErrInvalidToken = errors.New("invalid token")
+
+// Tokens signs and verifies access tokens. Refresh tokens are opaque random
+// strings, only their hashes are stored.
+type Tokens struct {
+	method     jwt.SigningMethod
+	signKey    any
+	verifyKey  any
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokens returns nil when no algorithm is configured, token authentication is off then
+func NewTokens(cfg config.Auth) (*Tokens, error) {
+	tokens := &Tokens{
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}
+
+	switch cfg.Algorithm {
+	case "":
+		return nil, nil
+	case AlgorithmHS256:
+		// RFC 7518 wants the key to be at least as long as the hash
+		if len(cfg.Secret) < 32 {
+			return nil, errors.New("auth secret must be at least 32 bytes long")
+		}
+		tokens.method = jwt.SigningMethodHS256
+		tokens.signKey = []byte(cfg.Secret)
+		tokens.verifyKey = []byte(cfg.Secret)
+	case AlgorithmRS256:
+		privateKey, err := readRSAPrivateKey(cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		tokens.method = jwt.SigningMethodRS256
+		tokens.signKey = privateKey
+		tokens.verifyKey = &privateKey.PublicKey
+	default:
+		return nil, fmt.Errorf("unknown token algorithm: %s", cfg.Algorithm)
+	}
+
+	if tokens.accessTTL <= 0 || tokens.refreshTTL <= 0 {
+		return nil, errors.New("token lifetimes must be positive")
+	}
+
+	return tokens, nil
+}
+
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("RS256 needs auth.private_key_path")
+	}
+
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	return key, nil
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Sign returns a signed access token for identity and when it expires
+func (t *Tokens) Sign(identity Identity, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(t.accessTTL)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := jwt.NewWithClaims(t.method, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    t.issuer,
+			Subject:   identity.Subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        hex.EncodeToString(id),
+		},
+		Tenant: identity.Tenant,
+	})
+
+	signed, err := token.SignedString(t.signKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Parse verifies an access token and returns the identity it was issued for
+func (t *Tokens) Parse(token string) (Identity, error) {
+	var claims claims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return t.verifyKey, nil
+	},
+		// only the configured algorithm is accepted, "none" and HS256 signed
+		// with the RSA public key are rejected
+		jwt.WithValidMethods([]string{t.method.Alg()}),
+		jwt.WithIssuer(t.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Subject == "" {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return Identity{Subject: claims.Subject, Method: MethodBearer, Tenant: claims.Tenant}, nil
+}
+
+func (t *Tokens) RefreshTTL() time.Duration {
+	return t.refreshTTL
+}
+
+// NewRefreshToken returns a random refresh token and the hash to store
+func NewRefreshToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken is what refresh tokens are looked up by, the tokens are
+// random enough for a plain sha256
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// NewFamilyId identifies a chain of rotated refresh tokens
+func NewFamilyId() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(raw), nil
+}
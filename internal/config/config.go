@@ -7,27 +7,143 @@ import (
 	"time"
 )
 
+const (
+	StorageDatabase = "database"
+	StorageMemory   = "memory"
+)
+
 type Config struct {
 	Env        string `yaml:"env" env-Default:"local"`
+	Storage    string `yaml:"storage" env:"STORAGE" env-default:"database"`
 	HTTPServer `yaml:"http_server"`
 	Database   `yaml:"database" env-required:"true"`
+	Validation `yaml:"validation"`
+	Auth       `yaml:"auth"`
+	Tenancy    `yaml:"tenancy"`
+	Log        `yaml:"log"`
+	Tracing    `yaml:"tracing"`
+	Groups     `yaml:"groups"`
 }
 
 type HTTPServer struct {
 	Address     string        `yaml:"address" env-default:"localhost:8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	User        string        `yaml:"user" env-required:"true"`
-	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// deadline of a request's work, keep it below Timeout so the error still
+	// gets written; 0 turns it off
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"HTTP_SERVER_REQUEST_TIMEOUT" env-default:"3s"`
+	// overrides RequestTimeout, keys are "/path", "/prefix/*" or with a
+	// method in front, "POST /students"; the most specific one wins
+	RouteTimeouts map[string]time.Duration `yaml:"route_timeouts"`
+	// how long the app keeps serving after /readyz went down on shutdown,
+	// so the load balancer stops sending requests before connections close
+	DrainDelay time.Duration `yaml:"drain_delay" env:"HTTP_SERVER_DRAIN_DELAY" env-default:"0s"`
+	// how long in-flight requests may take to finish once shutdown starts
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SERVER_SHUTDOWN_TIMEOUT" env-default:"20s"`
+	// /metrics is served on its own listener, so it isn't exposed with the
+	// API, unless it is the same as Address
+	MetricsAddress string `yaml:"metrics_address" env:"HTTP_SERVER_METRICS_ADDRESS" env-default:"localhost:9090"`
+	User           string `yaml:"user" env-required:"true"`
+	// plain text or a bcrypt hash
+	Password string `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// paths reachable without credentials, "/prefix/*" matches a whole subtree
+	PublicPaths []string `yaml:"public_paths" env:"HTTP_SERVER_PUBLIC_PATHS" env-default:"/healthz,/readyz,/health,/auth/*"`
 }
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 type Database struct {
-	Username string `yaml:"username" env-required:"true"`
-	Password string `yaml:"password" env-required:"true"`
-	Host     string `yaml:"host" env-required:"true"`
-	Port     string `yaml:"port" env-required:"true"`
-	Dbname   string `yaml:"dbname" env-required:"true"`
+	Driver string `yaml:"driver" env:"DATABASE_DRIVER" env-default:"postgres"`
+	// connection settings below are used by the postgres driver only
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Dbname   string `yaml:"dbname"`
 	Sslmode  string `yaml:"sslmode"`
+	// file used by the sqlite driver
+	Path string `yaml:"path" env:"DATABASE_PATH" env-default:"student_manager.db"`
+	// apply pending migrations when the app starts
+	AutoMigrate bool `yaml:"auto_migrate" env:"DATABASE_AUTO_MIGRATE"`
+}
+
+// Auth configures bearer tokens, leave Algorithm empty to turn them off
+type Auth struct {
+	// HS256 or RS256
+	Algorithm string `yaml:"algorithm" env:"AUTH_ALGORITHM"`
+	// HS256 key, at least 32 bytes
+	Secret string `yaml:"secret" env:"AUTH_SECRET"`
+	// PEM encoded RSA key for RS256
+	PrivateKeyPath  string        `yaml:"private_key_path" env:"AUTH_PRIVATE_KEY_PATH"`
+	Issuer          string        `yaml:"issuer" env-default:"student-manager"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
+}
+
+// Tenancy tells how requests name their tenant. A bearer token bound to a
+// tenant names it too, and then the request can't name another one.
+type Tenancy struct {
+	Header string `yaml:"header" env:"TENANCY_HEADER" env-default:"X-Tenant-ID"`
+	// when set, the tenant is also read from the subdomain of this domain,
+	// e.g. "math" from math.students.example.com for students.example.com
+	BaseDomain string `yaml:"base_domain" env:"TENANCY_BASE_DOMAIN"`
+	// reject requests that don't name a tenant instead of using the default one
+	Required bool `yaml:"required" env:"TENANCY_REQUIRED"`
+}
+
+// Log configures the logger, it writes text when Env is "local" and JSON
+// otherwise
+type Log struct {
+	// debug, info, warn or error
+	Level string `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+}
+
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+// Tracing tells where spans go, none turns tracing off
+type Tracing struct {
+	// none, stdout or otlp
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	// host:port of the OTLP/HTTP collector
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+	// send spans over plain HTTP, for a collector running next to the app
+	Insecure    bool   `yaml:"insecure" env:"TRACING_INSECURE"`
+	ServiceName string `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"student-manager"`
+	// share of new traces that are recorded, traces started by a caller
+	// follow the caller's decision
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+const (
+	DeleteRestrict = "restrict"
+	DeleteUnassign = "unassign"
+	DeleteReassign = "reassign"
+)
+
+// Groups tells what happens to the students of a deleted group
+type Groups struct {
+	// restrict refuses to delete a group that has students, unassign leaves
+	// them without a group and reassign moves them to ReassignTo
+	DeletePolicy string `yaml:"delete_policy" env:"GROUPS_DELETE_POLICY" env-default:"restrict"`
+	// number of the group that takes the students, in the tenant of the
+	// deleted group
+	ReassignTo string `yaml:"reassign_to" env:"GROUPS_REASSIGN_TO"`
+}
+
+// Validation holds the payload rules that differ between institutions
+type Validation struct {
+	GroupNumberPattern string `yaml:"group_number_pattern" env:"VALIDATION_GROUP_NUMBER_PATTERN" env-default:"^[A-Za-z0-9][A-Za-z0-9-]{0,19}$"`
+	AgeMin             int    `yaml:"age_min" env:"VALIDATION_AGE_MIN" env-default:"14"`
+	AgeMax             int    `yaml:"age_max" env:"VALIDATION_AGE_MAX" env-default:"100"`
+	NameMinLength      int    `yaml:"name_min_length" env-default:"2"`
+	NameMaxLength      int    `yaml:"name_max_length" env-default:"100"`
 }
 
 func Init() *Config {
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"log/slog"
+)
+
+const (
+	PermissionStudentsRead  = "students:read"
+	PermissionStudentsWrite = "students:write"
+	PermissionGroupsRead    = "groups:read"
+	PermissionGroupsManage  = "groups:manage"
+	PermissionUsersManage   = "users:manage"
+	// courses aren't scoped, any scope of courses:read sees the whole catalog
+	PermissionCoursesRead   = "courses:read"
+	PermissionCoursesManage = "courses:manage"
+	// enrolling groups and students, scoped like students:write
+	PermissionEnrollmentsManage = "enrollments:manage"
+	// granted to no role, only the configured user may manage tenants
+	PermissionTenantsManage = "tenants:manage"
+)
+
+// A scope limits which rows a permission applies to
+const (
+	ScopeAll = "all"
+	// students and groups of the groups the user curates
+	ScopeCurated = "curated"
+	// the student record linked to the user and its group
+	ScopeOwn = "own"
+)
+
+// User names are unique in the whole deployment, a user belongs to a single
+// tenant and can't act in the others
+type User struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	TenantId     string `json:"tenant_id"`
+	// set for users who are students themselves
+	StudentId *int64   `json:"student_id,omitempty"`
+	Roles     []string `json:"roles"`
+}
+
+type Grant struct {
+	Permission string
+	Scope      string
+}
+
+// LogValue leaves the password hash out of logs
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("username", u.Username),
+		slog.String("tenant_id", u.TenantId),
+		slog.Any("roles", u.Roles),
+	)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+)
+
+// enrollment statuses, a completed enrollment can't change anymore
+const (
+	EnrollmentActive    = "active"
+	EnrollmentDropped   = "dropped"
+	EnrollmentCompleted = "completed"
+)
+
+// Course.Code is unique in a tenant, like group numbers
+type Course struct {
+	Id    int64  `json:"id"`
+	Code  string `json:"code"`
+	Title string `json:"title"`
+}
+
+// Enrollment puts a whole group or a single student on a course, exactly one
+// of GroupId and StudentId is set. A student takes the courses of its group
+// and its own electives.
+type Enrollment struct {
+	Id         int64     `json:"id"`
+	CourseId   int64     `json:"course_id"`
+	GroupId    int64     `json:"group_id,omitempty"`
+	StudentId  int64     `json:"student_id,omitempty"`
+	Status     string    `json:"status"`
+	EnrolledAt time.Time `json:"enrolled_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -1,6 +1,30 @@
 package domain
 
+import (
+	"time"
+)
+
+// waitlist modes of a group, the empty mode turns its waitlist off
+const (
+	WaitlistFIFO = "fifo"
+	// higher priority first, then in the order students joined
+	WaitlistPriority = "priority"
+)
+
+// Group.Capacity is the number of seats, 0 leaves the group unlimited
 type Group struct {
 	Id          int64  `json:"id"`
-	GroupNumber string `json:"group_number" env-required:"true"`
+	GroupNumber string `json:"group_number"`
+	Capacity    int    `json:"capacity,omitempty"`
+	Waitlist    string `json:"waitlist,omitempty"`
+}
+
+// WaitlistEntry is a student waiting for a seat in a full group. Position
+// counts from 1 in the order students get promoted, it's set on reads.
+type WaitlistEntry struct {
+	GroupId   int64     `json:"group_id"`
+	StudentId int64     `json:"student_id"`
+	Priority  int       `json:"priority"`
+	Position  int       `json:"position"`
+	QueuedAt  time.Time `json:"queued_at"`
 }
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+)
+
+// RefreshToken is stored by hash, the token itself is only known to the client.
+// Every refresh revokes the used token and issues a new one of the same family.
+type RefreshToken struct {
+	TokenHash string
+	Subject   string
+	FamilyId  string
+	// tenant of the access tokens, empty when they are not bound to one
+	TenantId  string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -1,9 +1,30 @@
 package domain
 
+import (
+	"log/slog"
+)
+
+// Student.GroupId is 0 when the student has no group. GroupNumber is the
+// number of that group, storage fills it in on reads and ignores it on writes.
+// The API only shows the number, the same way it did before groups had ids.
 type Student struct {
 	Id          int64  `json:"id"`
-	FullName    string `json:"full_name" env-required:"true"`
-	Age         int    `json:"age" env-required:"true"`
+	FullName    string `json:"full_name"`
+	Age         int    `json:"age"`
+	GroupId     int64  `json:"-"`
 	GroupNumber string `json:"group_number"`
-	Email       string `json:"email" env-required:"true"`
+	Email       string `json:"email"`
+}
+
+// LogValue lists the fields one by one, so the logger can redact the
+// personal ones
+func (s Student) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("id", s.Id),
+		slog.String("full_name", s.FullName),
+		slog.Int("age", s.Age),
+		slog.Int64("group_id", s.GroupId),
+		slog.String("group_number", s.GroupNumber),
+		slog.String("email", s.Email),
+	)
 }
@@ -0,0 +1,11 @@
+package domain
+
+import (
+	"time"
+)
+
+type Tenant struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,71 @@
+package dto
+
+import "StudentManager/internal/domain"
+
+type CourseDto struct {
+	Id    int64
+	Code  string
+	Title string
+}
+
+// EnrollmentDto enrolls a whole group or a single student, the other id is 0
+type EnrollmentDto struct {
+	CourseId  int64
+	GroupId   int64
+	StudentId int64
+}
+
+// CourseFilter.StudentId and GroupId select the courses a student or a group
+// takes, those with an active enrollment. A student takes the courses of its
+// group too.
+type CourseFilter struct {
+	TitleContains string
+	StudentId     int64
+	GroupId       int64
+	// StudentGroupId is the group of StudentId, set by services
+	StudentGroupId int64
+}
+
+type CourseQuery struct {
+	CourseFilter
+	PageRequest
+}
+
+type CoursePage struct {
+	Courses    []domain.Course
+	NextCursor string
+	Total      int64
+}
+
+// EnrollmentFilter.GroupId and StudentId match group enrollments and
+// electives as they are, an elective isn't matched by the group of its student
+type EnrollmentFilter struct {
+	CourseId  int64
+	Status    string
+	GroupId   int64
+	StudentId int64
+	// GroupNumberIn and ElectivesOf are set by services to limit what the
+	// caller may see: enrollments of the groups and of the students in them,
+	// and only the own electives with ElectivesOf
+	GroupNumberIn []string
+	ElectivesOf   int64
+}
+
+type EnrollmentQuery struct {
+	EnrollmentFilter
+	PageRequest
+}
+
+type EnrollmentPage struct {
+	Enrollments []domain.Enrollment
+	NextCursor  string
+	Total       int64
+}
+
+// CourseEnrollments is a course with a page of its enrollments
+type CourseEnrollments struct {
+	Course      domain.Course
+	Enrollments []domain.Enrollment
+	NextCursor  string
+	Total       int64
+}
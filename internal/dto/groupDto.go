@@ -1,6 +1,25 @@
 package dto
 
+import "StudentManager/internal/domain"
+
 type GroupDto struct {
 	Id          int64
 	GroupNumber string
+	Capacity    int
+	Waitlist    string
+}
+
+// GroupRoster is a group with a page of its students
+type GroupRoster struct {
+	Group      domain.Group
+	Students   []domain.Student
+	NextCursor string
+	Total      int64
+}
+
+// GroupWaitlist is a group with the students waiting for a seat in it, in the
+// order they get promoted
+type GroupWaitlist struct {
+	Group   domain.Group
+	Entries []domain.WaitlistEntry
 }
@@ -0,0 +1,57 @@
+package dto
+
+import "StudentManager/internal/domain"
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// PageRequest selects one page of a list. Cursor continues keyset pagination
+// from a previous page and can't be combined with Offset. Limit 0 means no limit.
+type PageRequest struct {
+	Limit         int
+	Offset        int
+	Cursor        string
+	SortBy        string
+	SortDirection string
+}
+
+// StudentFilter fields are ignored when they hold zero values
+type StudentFilter struct {
+	GroupNumber  string
+	AgeMin       int
+	AgeMax       int
+	EmailDomain  string
+	NameContains string
+	// GroupNumberIn and Id are set by services to limit what the caller may see
+	GroupNumberIn []string
+	Id            int64
+}
+
+type StudentQuery struct {
+	StudentFilter
+	PageRequest
+}
+
+type GroupFilter struct {
+	NumberContains string
+	GroupNumberIn  []string
+}
+
+type GroupQuery struct {
+	GroupFilter
+	PageRequest
+}
+
+type StudentPage struct {
+	Students   []domain.Student
+	NextCursor string
+	Total      int64
+}
+
+type GroupPage struct {
+	Groups     []domain.Group
+	NextCursor string
+	Total      int64
+}
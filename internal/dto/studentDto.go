@@ -7,3 +7,16 @@ type StudentDto struct {
 	GroupNumber string
 	Email       string
 }
+
+// StudentChanges lists the columns of a partial update, nil fields stay as
+// they are. A GroupId of 0 leaves the student without a group.
+type StudentChanges struct {
+	FullName *string
+	Age      *int
+	GroupId  *int64
+	Email    *string
+}
+
+func (c StudentChanges) IsEmpty() bool {
+	return c.FullName == nil && c.Age == nil && c.GroupId == nil && c.Email == nil
+}
@@ -0,0 +1,6 @@
+package dto
+
+type TenantDto struct {
+	Id   string
+	Name string
+}
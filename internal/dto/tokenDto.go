@@ -0,0 +1,12 @@
+package dto
+
+import (
+	"time"
+)
+
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
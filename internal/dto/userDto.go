@@ -0,0 +1,8 @@
+package dto
+
+type UserDto struct {
+	Username  string
+	Password  string
+	StudentId *int64
+	Roles     []string
+}
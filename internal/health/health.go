@@ -0,0 +1,103 @@
+// Package health tells whether the app and the things it depends on work.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// CheckTimeout bounds every check, a dependency that doesn't answer in time is down
+const CheckTimeout = 2 * time.Second
+
+// Check is a dependency the app can't serve requests without
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Ready reports whether the app should get traffic
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+type Checker struct {
+	checks   []Check
+	draining atomic.Bool
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{
+		checks: checks,
+	}
+}
+
+// StartDraining makes the app not ready for good, it is called when shutdown begins
+func (c *Checker) StartDraining() {
+	c.draining.Store(true)
+}
+
+// Check runs all checks at once
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	if c.draining.Load() {
+		report.Status = StatusDraining
+	}
+
+	return report
+}
+
+func run(ctx context.Context, check Check) CheckResult {
+	start := time.Now()
+	err := check.Run(ctx)
+
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
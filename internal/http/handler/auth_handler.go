@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	GrantPassword     = "password"
+	GrantRefreshToken = "refresh_token"
+)
+
+// TokenRequest follows the OAuth 2 token request, but is sent as JSON
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" validate:"required"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RevokeRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type AuthHandler struct {
+	service   service.AuthService
+	validator *validation.Validator
+}
+
+func NewAuthHandler(service service.AuthService, validator *validation.Validator) *AuthHandler {
+	return &AuthHandler{
+		service:   service,
+		validator: validator,
+	}
+}
+
+func (h *AuthHandler) IssueToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authService := h.service
+
+		var req TokenRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+		if err := h.validator.Struct(req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		var pair dto.TokenPair
+		var err error
+		switch req.GrantType {
+		case GrantPassword:
+			if req.Username == "" || req.Password == "" {
+				h.responseError(w, r, apperror.Validation("invalid_request", "username and password are required",
+					apperror.FieldError{Field: "username", Message: "is required"},
+					apperror.FieldError{Field: "password", Message: "is required"}))
+				return
+			}
+			pair, err = authService.Login(r.Context(), req.Username, req.Password)
+		case GrantRefreshToken:
+			if req.RefreshToken == "" {
+				h.responseError(w, r, apperror.Validation("invalid_request", "refresh_token is required",
+					apperror.FieldError{Field: "refresh_token", Message: "is required"}))
+				return
+			}
+			pair, err = authService.Refresh(r.Context(), req.RefreshToken)
+		default:
+			slog.InfoContext(r.Context(), "unsupported grant type", "grant_type", req.GrantType)
+			h.responseError(w, r, apperror.Validation("unsupported_grant_type",
+				"grant_type must be password or refresh_token",
+				apperror.FieldError{Field: "grant_type", Message: "must be password or refresh_token"}))
+			return
+		}
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseToken(w, r, pair)
+	}
+}
+
+func (h *AuthHandler) RevokeToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authService := h.service
+
+		var req RevokeRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+		if err := h.validator.Struct(req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		if err := authService.Revoke(r.Context(), req.RefreshToken); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *AuthHandler) responseToken(w http.ResponseWriter, r *http.Request, pair dto.TokenPair) {
+	// RFC 6749 forbids caching token responses
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp.TokenResponse(pair, time.Now()))
+}
+
+func (h *AuthHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
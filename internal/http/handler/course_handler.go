@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type CreateCourseRequest struct {
+	Code  string `json:"code" validate:"required,course_code"`
+	Title string `json:"title" validate:"required,course_title"`
+}
+
+type UpdateCourseRequest struct {
+	Id    int64  `json:"id"`
+	Code  string `json:"code" validate:"required,course_code"`
+	Title string `json:"title" validate:"required,course_title"`
+}
+
+type CourseHandler struct {
+	service   service.CourseService
+	validator *validation.Validator
+}
+
+func NewCourseHandler(service service.CourseService, validator *validation.Validator) *CourseHandler {
+	return &CourseHandler{
+		service:   service,
+		validator: validator,
+	}
+}
+
+func (h *CourseHandler) CreateCourse() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseService := h.service
+
+		var req CreateCourseRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
+
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		course, err := courseService.Create(r.Context(), dto.CourseDto{Code: req.Code, Title: req.Title})
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, resp.CourseResponse(course))
+	}
+}
+
+// GetAllCourses lists the catalog, ?student_id= and ?group_id= narrow it to
+// the courses a student or a group takes
+func (h *CourseHandler) GetAllCourses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseService := h.service
+		values := r.URL.Query()
+
+		page, err := pageFromQuery(values)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid courses query", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		query := dto.CourseQuery{
+			CourseFilter: dto.CourseFilter{
+				TitleContains: values.Get("title_contains"),
+			},
+			PageRequest: page,
+		}
+		if query.StudentId, err = idFromQuery(values, "student_id"); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+		if query.GroupId, err = idFromQuery(values, "group_id"); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		courses, err := courseService.GetAll(r.Context(), query)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.CoursesResponse(courses))
+	}
+}
+
+func (h *CourseHandler) GetCourseById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseService := h.service
+
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid course id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		course, err := courseService.GetById(r.Context(), id)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseCourse(w, r, course)
+	}
+}
+
+func (h *CourseHandler) UpdateCourse() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseService := h.service
+
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid course id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		var req UpdateCourseRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
+
+		// id in the body is optional, but if present it must match the path
+		if req.Id != 0 && req.Id != id {
+			slog.InfoContext(r.Context(), "course id mismatch", "id", id, "body_id", req.Id)
+
+			h.responseError(w, r, apperror.Validation("id_mismatch", "course id in body doesn't match path",
+				apperror.FieldError{Field: "id", Message: "must match the id in the path"}))
+			return
+		}
+
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		course, err := courseService.Update(r.Context(), dto.CourseDto{Id: id, Code: req.Code, Title: req.Title})
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseCourse(w, r, course)
+	}
+}
+
+func (h *CourseHandler) DeleteCourseById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseService := h.service
+
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid course id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		if err := courseService.DeleteById(r.Context(), id); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *CourseHandler) responseCourse(w http.ResponseWriter, r *http.Request, course domain.Course) {
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp.CourseResponse(course))
+}
+
+func (h *CourseHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+// EnrollRequest enrolls either a whole group or, as an elective, a single student
+type EnrollRequest struct {
+	GroupId   int64 `json:"group_id"`
+	StudentId int64 `json:"student_id"`
+}
+
+type EnrollmentStatusRequest struct {
+	Status string `json:"status" validate:"required,enrollment_status"`
+}
+
+type EnrollmentHandler struct {
+	service   service.EnrollmentService
+	validator *validation.Validator
+}
+
+func NewEnrollmentHandler(service service.EnrollmentService, validator *validation.Validator) *EnrollmentHandler {
+	return &EnrollmentHandler{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// GetEnrollments returns the course with its enrollments, ?status=,
+// ?group_id= and ?student_id= filter them
+func (h *EnrollmentHandler) GetEnrollments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enrollmentService := h.service
+		values := r.URL.Query()
+
+		courseId, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid course id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		page, err := pageFromQuery(values)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid enrollments query", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		query := dto.EnrollmentQuery{
+			EnrollmentFilter: dto.EnrollmentFilter{
+				Status: values.Get("status"),
+			},
+			PageRequest: page,
+		}
+		if query.Status != "" {
+			if err := h.validator.Struct(EnrollmentStatusRequest{Status: query.Status}); err != nil {
+				slog.InfoContext(r.Context(), "invalid enrollments query", "err", err)
+
+				h.responseError(w, r, err)
+				return
+			}
+		}
+		if query.GroupId, err = idFromQuery(values, "group_id"); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+		if query.StudentId, err = idFromQuery(values, "student_id"); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		enrollments, err := enrollmentService.GetAll(r.Context(), courseId, query)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.EnrollmentsResponse(enrollments))
+	}
+}
+
+func (h *EnrollmentHandler) Enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enrollmentService := h.service
+
+		courseId, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid course id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		var req EnrollRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
+
+		if (req.GroupId == 0) == (req.StudentId == 0) || req.GroupId < 0 || req.StudentId < 0 {
+			slog.InfoContext(r.Context(), "invalid request", "request", req)
+
+			h.responseError(w, r, apperror.Validation("invalid_enrollment",
+				"exactly one of group_id and student_id must be set",
+				apperror.FieldError{Field: "group_id", Message: "must be a positive integer unless student_id is set"},
+				apperror.FieldError{Field: "student_id", Message: "must be a positive integer unless group_id is set"}))
+			return
+		}
+
+		enrollment, err := enrollmentService.Enroll(r.Context(), dto.EnrollmentDto{
+			CourseId:  courseId,
+			GroupId:   req.GroupId,
+			StudentId: req.StudentId,
+		})
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, resp.EnrollmentResponse(enrollment))
+	}
+}
+
+func (h *EnrollmentHandler) GetEnrollmentById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enrollmentService := h.service
+
+		courseId, id, err := enrollmentFromPath(r)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		enrollment, err := enrollmentService.GetById(r.Context(), courseId, id)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.EnrollmentResponse(enrollment))
+	}
+}
+
+// SetEnrollmentStatus drops, completes or reactivates an enrollment
+func (h *EnrollmentHandler) SetEnrollmentStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enrollmentService := h.service
+
+		courseId, id, err := enrollmentFromPath(r)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		var req EnrollmentStatusRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		enrollment, err := enrollmentService.SetStatus(r.Context(), courseId, id, req.Status)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.EnrollmentResponse(enrollment))
+	}
+}
+
+// enrollmentFromPath reads /courses/{Id}/enrollments/{enrollmentId}
+func enrollmentFromPath(r *http.Request) (courseId, id int64, err error) {
+	courseId, err = idFromPath(r)
+	if err != nil {
+		slog.InfoContext(r.Context(), "invalid course id", "err", err)
+		return 0, 0, err
+	}
+	id, err = idParamFromPath(r, "enrollmentId", "enrollment_id")
+	if err != nil {
+		slog.InfoContext(r.Context(), "invalid enrollment id", "err", err)
+		return 0, 0, err
+	}
+
+	return courseId, id, nil
+}
+
+func (h *EnrollmentHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
@@ -1,43 +1,43 @@
 package handler
 
 import (
+	"StudentManager/internal/apperror"
 	"StudentManager/internal/domain"
 	"StudentManager/internal/dto"
 	resp "StudentManager/internal/http/response"
 	"StudentManager/internal/http/service"
-	"context"
-	"errors"
+	"StudentManager/internal/validation"
 	"github.com/go-chi/render"
-	"io"
-	"log"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// CreateGroupRequest.Capacity of 0 leaves the group unlimited, an empty
+// Waitlist leaves it without a waitlist
 type CreateGroupRequest struct {
-	GroupNumber string `json:"group_number" env-required:"true"`
+	GroupNumber string `json:"group_number" validate:"required,group_number"`
+	Capacity    int    `json:"capacity" validate:"capacity"`
+	Waitlist    string `json:"waitlist" validate:"waitlist"`
 }
 
 type UpdateGroupRequest struct {
-	Id          int64  `json:"id" env-required:"true"`
-	GroupNumber string `json:"group_number" env-required:"true"`
-}
-
-type GroupIdRequest struct {
-	Id int64 `json:"id" env-required:"true"`
-}
-
-type GetGroupRequest struct {
-	GroupNumber string `json:"group_number" env-required:"true"`
+	Id          int64  `json:"id"`
+	GroupNumber string `json:"group_number" validate:"required,group_number"`
+	Capacity    int    `json:"capacity" validate:"capacity"`
+	Waitlist    string `json:"waitlist" validate:"waitlist"`
 }
 
 type GroupHandler struct {
-	service service.GroupService
+	service   service.GroupService
+	validator *validation.Validator
 }
 
-func NewGroupHandler(service service.GroupService) *GroupHandler {
+func NewGroupHandler(service service.GroupService, validator *validation.Validator) *GroupHandler {
 	return &GroupHandler{
-		service: service,
+		service:   service,
+		validator: validator,
 	}
 }
 
@@ -46,48 +46,33 @@ func (h *GroupHandler) CreateGroup() http.HandlerFunc {
 		groupService := h.service
 		var req CreateGroupRequest
 
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
-			log.Println("request body is empty")
-
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
 			return
 		}
-		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
-			return
-		}
-
-		log.Println("request body decoded", slog.Any("response", req))
-
-		if req.GroupNumber != "" {
-
-			groupDto := dto.GroupDto{
-				GroupNumber: req.GroupNumber,
-			}
-
-			group, err := groupService.Create(context.Background(), groupDto)
-			if err != nil {
-				if err.Error() == "group already exists" {
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
 
-					h.responseError(w, r, err.Error(), http.StatusBadRequest)
-					return
-				}
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
 
-				h.responseError(w, r, "failed to create group", http.StatusInternalServerError)
-				return
-			}
-
-			h.responseCreatedGroup(w, r, group)
+			h.responseError(w, r, err)
+			return
+		}
 
-		} else {
-			log.Println("invalid request")
+		groupDto := dto.GroupDto{
+			GroupNumber: req.GroupNumber,
+			Capacity:    req.Capacity,
+			Waitlist:    req.Waitlist,
+		}
 
-			h.responseError(w, r, "invalid request", http.StatusBadRequest)
+		group, err := groupService.Create(r.Context(), groupDto)
+		if err != nil {
+			h.responseError(w, r, err)
 			return
 		}
+
+		h.responseCreatedGroup(w, r, group)
 	}
 }
 
@@ -95,10 +80,24 @@ func (h *GroupHandler) GetAllGroups() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		groupService := h.service
 
-		groups, err := groupService.GetAll(context.Background())
+		page, err := pageFromQuery(r.URL.Query())
 		if err != nil {
+			slog.InfoContext(r.Context(), "invalid groups query", "err", err)
 
-			h.responseError(w, r, "failed to get groups", http.StatusInternalServerError)
+			h.responseError(w, r, err)
+			return
+		}
+
+		query := dto.GroupQuery{
+			GroupFilter: dto.GroupFilter{
+				NumberContains: r.URL.Query().Get("number_contains"),
+			},
+			PageRequest: page,
+		}
+
+		groups, err := groupService.GetAll(r.Context(), query)
+		if err != nil {
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -110,73 +109,132 @@ func (h *GroupHandler) GetGroupById() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		groupService := h.service
 
-		var req GroupIdRequest
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
 
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
+			h.responseError(w, r, err)
+			return
+		}
 
-			log.Println("request body is empty")
+		withStudents, err := includeStudents(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group query", "err", err)
 
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+			h.responseError(w, r, err)
 			return
 		}
-		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
+		if withStudents {
+			query, err := studentQueryFromRequest(r)
+			if err != nil {
+				slog.InfoContext(r.Context(), "invalid group query", "err", err)
+
+				h.responseError(w, r, err)
+				return
+			}
+
+			roster, err := groupService.GetRoster(r.Context(), id, query)
+			if err != nil {
+				h.responseError(w, r, err)
+				return
+			}
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
+			h.responseFoundRoster(w, r, roster)
 			return
 		}
 
-		log.Println("request body decoded", slog.Any("request", req))
+		group, err := groupService.GetById(r.Context(), id)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseFoundGroup(w, r, group)
+	}
+}
+
+// GetGroupStudents lists the students of a group, filtered, sorted and
+// paginated like GET /students
+func (h *GroupHandler) GetGroupStudents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupService := h.service
 
-		group, err := groupService.GetById(context.Background(), req.Id)
+		id, err := idFromPath(r)
 		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
 
-			h.responseError(w, r, "failed to get group", http.StatusNotFound)
+			h.responseError(w, r, err)
 			return
 		}
 
-		h.responseFoundGroup(w, r, group)
+		query, err := studentQueryFromRequest(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid students query", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		roster, err := groupService.GetRoster(r.Context(), id, query)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.RosterStudentsResponse(roster))
 	}
 }
 
 func (h *GroupHandler) UpdateGroup() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		groupService := h.service
+
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
 		// TODO write json decoder struct
 		var req UpdateGroupRequest
 
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
 
-			log.Println("request body is empty")
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
 
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+		// id in the body is optional, but if present it must match the path
+		if req.Id != 0 && req.Id != id {
+			slog.InfoContext(r.Context(), "group id mismatch", "id", id, "body_id", req.Id)
+
+			h.responseError(w, r, apperror.Validation("id_mismatch", "group id in body doesn't match path",
+				apperror.FieldError{Field: "id", Message: "must match the id in the path"}))
 			return
 		}
-		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
 			return
 		}
 
-		log.Println("request body decoded", slog.Any("response", req))
-
 		groupDto := dto.GroupDto{
-			Id:          req.Id,
+			Id:          id,
 			GroupNumber: req.GroupNumber,
+			Capacity:    req.Capacity,
+			Waitlist:    req.Waitlist,
 		}
 
-		group, err := groupService.Update(context.Background(), groupDto)
+		group, err := groupService.Update(r.Context(), groupDto)
 
 		if err != nil {
-			if err.Error() == "group doesn't exist" {
-				h.responseError(w, r, "group doesn't exist", http.StatusNotFound)
-				return
-			}
-
-			h.responseError(w, r, "failed to update group", http.StatusInternalServerError)
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -184,42 +242,69 @@ func (h *GroupHandler) UpdateGroup() http.HandlerFunc {
 	}
 }
 
-func (h *GroupHandler) DeleteGroupById() http.HandlerFunc {
+func (h *GroupHandler) PatchGroup() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		groupService := h.service
 
-		var req GroupIdRequest
-
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
-
-			log.Println("request body is empty")
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
 
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+			h.responseError(w, r, err)
 			return
 		}
+
+		patch, err := patcherFromRequest(r)
 		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
+			h.responseError(w, r, err)
+			return
+		}
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
+		group, err := groupService.Patch(r.Context(), id, func(current domain.Group) (domain.Group, error) {
+			var req UpdateGroupRequest
+			if err := applyPatch(current, patch, &req); err != nil {
+				return domain.Group{}, err
+			}
+
+			if req.Id != id {
+				return domain.Group{}, apperror.Validation("id_mismatch", "group id can't be changed",
+					apperror.FieldError{Field: "id", Message: "must match the id in the path"})
+			}
+			if err := h.validator.Struct(req); err != nil {
+				return domain.Group{}, err
+			}
 
+			return domain.Group{
+				Id:          id,
+				GroupNumber: req.GroupNumber,
+				Capacity:    req.Capacity,
+				Waitlist:    req.Waitlist,
+			}, nil
+		})
+		if err != nil {
+			h.responseError(w, r, err)
 			return
 		}
 
-		log.Println("request body decoded", slog.Any("request", req))
+		h.responseUpdatedGroup(w, r, group)
+	}
+}
+
+func (h *GroupHandler) DeleteGroupById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupService := h.service
 
-		err = groupService.DeleteById(context.Background(), req.Id)
+		id, err := idFromPath(r)
 		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
 
-			// TODO make own types of errors
-			if err.Error() == "group does not exist" {
-				w.WriteHeader(http.StatusNotFound)
-				// TODO write instead of word group or student, domain
-				h.responseError(w, r, "group doesn't exist", http.StatusNotFound)
-				return
-			}
+			h.responseError(w, r, err)
+			return
+		}
 
-			h.responseError(w, r, "failed to delete group", http.StatusInternalServerError)
+		err = groupService.DeleteById(r.Context(), id)
+		if err != nil {
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -227,9 +312,9 @@ func (h *GroupHandler) DeleteGroupById() http.HandlerFunc {
 	}
 }
 
-func (h *GroupHandler) responseFoundGroups(w http.ResponseWriter, r *http.Request, groups []domain.Group) {
+func (h *GroupHandler) responseFoundGroups(w http.ResponseWriter, r *http.Request, page dto.GroupPage) {
 	w.WriteHeader(http.StatusOK)
-	render.JSON(w, r, resp.GroupsResponse(groups))
+	render.JSON(w, r, resp.GroupsResponse(page))
 }
 
 func (h *GroupHandler) responseFoundGroup(w http.ResponseWriter, r *http.Request, group domain.Group) {
@@ -237,6 +322,11 @@ func (h *GroupHandler) responseFoundGroup(w http.ResponseWriter, r *http.Request
 	render.JSON(w, r, resp.GroupResponse(group))
 }
 
+func (h *GroupHandler) responseFoundRoster(w http.ResponseWriter, r *http.Request, roster dto.GroupRoster) {
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp.RosterResponse(roster))
+}
+
 func (h *GroupHandler) responseCreatedGroup(w http.ResponseWriter, r *http.Request, group domain.Group) {
 	w.WriteHeader(http.StatusCreated)
 	render.JSON(w, r, resp.GroupResponse(group))
@@ -247,7 +337,24 @@ func (h *GroupHandler) responseUpdatedGroup(w http.ResponseWriter, r *http.Reque
 	render.JSON(w, r, resp.GroupResponse(group))
 }
 
-func (h *GroupHandler) responseError(w http.ResponseWriter, r *http.Request, msg string, status int) {
-	w.WriteHeader(status)
-	render.JSON(w, r, resp.Error(msg))
+func (h *GroupHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
+
+// includeStudents reads the include query parameter, a comma separated list
+// of what to embed in a group. Only students can be embedded for now.
+func includeStudents(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("include")
+	if raw == "" {
+		return false, nil
+	}
+
+	for _, include := range strings.Split(raw, ",") {
+		if include != "students" {
+			return false, apperror.Validation("invalid_query", "can't include "+strconv.Quote(include)+" in a group",
+				apperror.FieldError{Field: "include", Message: "must be students"})
+		}
+	}
+
+	return true, nil
 }
@@ -1,25 +1,65 @@
 package handler
 
 import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
 	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"fmt"
 	"github.com/go-chi/chi/v5"
-	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 type Handlers struct {
-	Students StudentHandler
-	Groups   GroupHandler
+	Students    StudentHandler
+	Groups      GroupHandler
+	Manager     StudentManagerHandler
+	Users       UserHandler
+	Tenants     TenantHandler
+	Courses     CourseHandler
+	Enrollments EnrollmentHandler
+	// nil when token authentication is turned off
+	Auth *AuthHandler
 }
 
-func NewHandlers(services *service.Services) *Handlers {
-	log.Printf("Handlers are created")
-	return &Handlers{
-		Students: *NewStudentHandler(services.Students),
-		Groups:   *NewGroupHandler(services.Groups),
+func NewHandlers(services *service.Services, validator *validation.Validator) *Handlers {
+	slog.Info("Handlers are created")
+	handlers := &Handlers{
+		Students:    *NewStudentHandler(services.Students, validator),
+		Groups:      *NewGroupHandler(services.Groups, validator),
+		Manager:     *NewStudentManagerHandler(services.Manager, validator),
+		Users:       *NewUserHandler(services.Users, validator),
+		Tenants:     *NewTenantHandler(services.Tenants, validator),
+		Courses:     *NewCourseHandler(services.Courses, validator),
+		Enrollments: *NewEnrollmentHandler(services.Enrollments, validator),
 	}
+	if services.Auth != nil {
+		handlers.Auth = NewAuthHandler(services.Auth, validator)
+	}
+
+	return handlers
 }
 
 func (h *Handlers) InitRoutes(r chi.Router) {
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		responseProblem(w, r, apperror.NotFound("route_not_found", "no such route"))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		resp.WriteProblem(w, r, resp.NewProblem(http.StatusMethodNotAllowed, "method_not_allowed",
+			r.Method+" is not allowed here"))
+	})
+
+	if h.Auth != nil {
+		r.Route("/auth", func(r chi.Router) {
+			r.Post("/token", h.Auth.IssueToken())
+			r.Post("/revoke", h.Auth.RevokeToken())
+		})
+	}
 
 	r.Route("/students", func(r chi.Router) {
 		studentHandler := h.Students
@@ -27,9 +67,10 @@ func (h *Handlers) InitRoutes(r chi.Router) {
 		r.Get("/", studentHandler.GetAllStudents())
 
 		r.Route("/{Id}", func(r chi.Router) {
-			r.Get("/", studentHandler.GetStudentById()) //TODO add path variable
+			r.Get("/", studentHandler.GetStudentById())
 			r.Delete("/", studentHandler.DeleteStudentById())
 			r.Put("/", studentHandler.UpdateStudent())
+			r.Patch("/", studentHandler.PatchStudent())
 		})
 	})
 
@@ -39,9 +80,133 @@ func (h *Handlers) InitRoutes(r chi.Router) {
 		r.Get("/", groupHandler.GetAllGroups())
 
 		r.Route("/{Id}", func(r chi.Router) {
-			r.Get("/", groupHandler.GetGroupById()) //TODO add path variable
+			r.Get("/", groupHandler.GetGroupById())
 			r.Delete("/", groupHandler.DeleteGroupById())
 			r.Put("/", groupHandler.UpdateGroup())
+			r.Patch("/", groupHandler.PatchGroup())
+			r.Put("/curators/{username}", h.Users.AddCurator())
+			r.Delete("/curators/{username}", h.Users.RemoveCurator())
+			r.Get("/students", groupHandler.GetGroupStudents())
+			r.Post("/students/{studentId}", h.Manager.AddStudentToGroup())
+			r.Delete("/students/{studentId}", h.Manager.RemoveStudentFromGroup())
+			r.Get("/waitlist", h.Manager.GetWaitlist())
+			r.Put("/waitlist/{studentId}", h.Manager.PutOnWaitlist())
+			r.Delete("/waitlist/{studentId}", h.Manager.RemoveFromWaitlist())
+		})
+	})
+
+	r.Route("/courses", func(r chi.Router) {
+		courseHandler := h.Courses
+		r.Post("/", courseHandler.CreateCourse())
+		r.Get("/", courseHandler.GetAllCourses())
+
+		r.Route("/{Id}", func(r chi.Router) {
+			r.Get("/", courseHandler.GetCourseById())
+			r.Put("/", courseHandler.UpdateCourse())
+			r.Delete("/", courseHandler.DeleteCourseById())
+			r.Get("/enrollments", h.Enrollments.GetEnrollments())
+			r.Post("/enrollments", h.Enrollments.Enroll())
+			r.Get("/enrollments/{enrollmentId}", h.Enrollments.GetEnrollmentById())
+			r.Put("/enrollments/{enrollmentId}/status", h.Enrollments.SetEnrollmentStatus())
 		})
 	})
+
+	r.Route("/users", func(r chi.Router) {
+		userHandler := h.Users
+		r.Post("/", userHandler.CreateUser())
+
+		r.Route("/{username}", func(r chi.Router) {
+			r.Get("/", userHandler.GetUser())
+			r.Delete("/", userHandler.DeleteUser())
+			r.Put("/roles", userHandler.SetUserRoles())
+		})
+	})
+
+	r.Route("/tenants", func(r chi.Router) {
+		tenantHandler := h.Tenants
+		r.Post("/", tenantHandler.CreateTenant())
+		r.Get("/", tenantHandler.GetAllTenants())
+
+		r.Route("/{tenantId}", func(r chi.Router) {
+			r.Get("/", tenantHandler.GetTenant())
+			r.Put("/", tenantHandler.UpdateTenant())
+			r.Delete("/", tenantHandler.DeleteTenant())
+		})
+	})
+}
+
+// idFromPath reads the {Id} path parameter, ids are positive int64 values
+func idFromPath(r *http.Request) (int64, error) {
+	return idParamFromPath(r, "Id", "id")
+}
+
+// idParamFromPath reads another id path parameter, field names it in errors
+func idParamFromPath(r *http.Request, param, field string) (int64, error) {
+	raw := chi.URLParam(r, param)
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, apperror.Validation("invalid_id", field+" must be a positive integer, got "+strconv.Quote(raw),
+			apperror.FieldError{Field: field, Message: "must be a positive integer"})
+	}
+
+	return id, nil
+}
+
+// pageFromQuery reads limit, offset, cursor and sort query parameters.
+// sort is a column name, prefixed with "-" or suffixed with ":desc" for descending order.
+func pageFromQuery(values url.Values) (dto.PageRequest, error) {
+	var page dto.PageRequest
+	var err error
+
+	if page.Limit, err = intFromQuery(values, "limit"); err != nil {
+		return page, err
+	}
+	if page.Offset, err = intFromQuery(values, "offset"); err != nil {
+		return page, err
+	}
+	page.Cursor = values.Get("cursor")
+
+	sort := values.Get("sort")
+	switch {
+	case strings.HasPrefix(sort, "-"):
+		page.SortBy, page.SortDirection = sort[1:], dto.SortDesc
+	case strings.Contains(sort, ":"):
+		page.SortBy, page.SortDirection, _ = strings.Cut(sort, ":")
+	default:
+		page.SortBy = sort
+	}
+
+	return page, nil
+}
+
+// idFromQuery reads an optional id query parameter, 0 when it is missing
+func idFromQuery(values url.Values, name string) (int64, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, apperror.Validation("invalid_query", name+" must be a positive integer, got "+strconv.Quote(raw),
+			apperror.FieldError{Field: name, Message: "must be a positive integer"})
+	}
+
+	return id, nil
+}
+
+func intFromQuery(values url.Values, name string) (int, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, apperror.Validation("invalid_query", fmt.Sprintf("%s must be an integer, got %s", name, strconv.Quote(raw)),
+			apperror.FieldError{Field: name, Message: "must be an integer"})
+	}
+
+	return value, nil
 }
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"StudentManager/internal/health"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+// HealthHandler answers the orchestrator's probes. Its routes are mounted
+// ahead of authentication and tenant resolution, so a probe doesn't depend
+// on anything it doesn't check.
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+func (h *HealthHandler) InitRoutes(r chi.Router) {
+	r.Get("/healthz", h.Live())
+	r.Get("/readyz", h.Ready())
+	r.Get("/health", h.Health())
+}
+
+// Live only tells that the process serves requests
+func (h *HealthHandler) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, map[string]string{"status": health.StatusUp})
+	}
+}
+
+// Ready tells whether the app should get traffic: storage answers, the
+// schema is current and shutdown hasn't begun
+func (h *HealthHandler) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.checker.Check(r.Context())
+		if !report.Ready() {
+			slog.WarnContext(r.Context(), "not ready", "status", report.Status)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		render.JSON(w, r, map[string]string{"status": report.Status})
+	}
+}
+
+// Health is Ready with the result and latency of every check
+func (h *HealthHandler) Health() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.checker.Check(r.Context())
+		if !report.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		render.JSON(w, r, report)
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"StudentManager/internal/apperror"
+	"bytes"
+	"encoding/json"
+	"errors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patcher applies a patch from a request to a JSON document
+type patcher func(document []byte) ([]byte, error)
+
+// patcherFromRequest reads the patch in the request body. It's an RFC 7396
+// merge patch unless the body is sent as application/json-patch+json, in
+// which case it's an RFC 6902 JSON Patch.
+func patcherFromRequest(r *http.Request) (patcher, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.InfoContext(r.Context(), "failed to read request body", "err", err)
+		return nil, apperror.Validation("invalid_body", "failed to read request body")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		slog.InfoContext(r.Context(), "request body is empty")
+		return nil, apperror.Validation("empty_body", "request body is empty")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonPatchContentType:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			slog.InfoContext(r.Context(), "failed to decode json patch", "err", err)
+			return nil, apperror.Validation("invalid_body", "request body is not a valid JSON Patch")
+		}
+
+		return func(document []byte) ([]byte, error) {
+			patched, err := patch.Apply(document)
+			if err != nil {
+				return nil, apperror.Validation("patch_failed", "failed to apply JSON Patch: "+err.Error())
+			}
+			return patched, nil
+		}, nil
+	case "", "application/json", mergePatchContentType:
+		if !json.Valid(body) {
+			return nil, apperror.Validation("invalid_body", "request body is not valid JSON")
+		}
+
+		return func(document []byte) ([]byte, error) {
+			patched, err := jsonpatch.MergePatch(document, body)
+			if err != nil {
+				return nil, apperror.Validation("patch_failed", "failed to apply merge patch: "+err.Error())
+			}
+			return patched, nil
+		}, nil
+	default:
+		return nil, apperror.Validation("unsupported_media_type",
+			"patches must be sent as "+mergePatchContentType+" or "+jsonPatchContentType)
+	}
+}
+
+// applyPatch patches the JSON representation of current and decodes the
+// result into target, which is a request type so it can be validated as usual
+func applyPatch(current any, patch patcher, target any) error {
+	document, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	patched, err := patch(document)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return apperror.Validation("invalid_request", "request is invalid",
+				apperror.FieldError{Field: typeErr.Field, Message: "has the wrong type: " + typeErr.Value})
+		}
+
+		return apperror.Validation("invalid_request", "patched document is invalid: "+err.Error())
+	}
+
+	return nil
+}
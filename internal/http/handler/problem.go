@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"StudentManager/internal/apperror"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/metrics"
+	"errors"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+func responseProblem(w http.ResponseWriter, r *http.Request, err error) {
+	// not every driver says so when it stops because the request is done
+	if ctxErr := r.Context().Err(); ctxErr != nil && errors.Is(err, apperror.ErrDependency) {
+		err = apperror.Interrupted(ctxErr)
+	}
+
+	problem := resp.ProblemFromError(err)
+	if errors.Is(err, apperror.ErrValidation) {
+		metrics.ValidationFailures.WithLabelValues(problem.Code).Inc()
+	}
+	// interrupted requests are logged by middleware.CountOutcomes
+	if problem.Status >= http.StatusInternalServerError && !errors.Is(err, apperror.ErrTimeout) {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+	}
+
+	resp.WriteProblem(w, r, problem)
+}
+
+// decodeJSON decodes the request body into v
+func decodeJSON(r *http.Request, v any) error {
+	err := render.DecodeJSON(r.Body, v)
+	if errors.Is(err, io.EOF) {
+		slog.InfoContext(r.Context(), "request body is empty")
+		return apperror.Validation("empty_body", "request body is empty")
+	}
+	if err != nil {
+		slog.InfoContext(r.Context(), "failed to decode request body", "err", err)
+		return apperror.Validation("invalid_body", "request body is not valid JSON")
+	}
+
+	return nil
+}
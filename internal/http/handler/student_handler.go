@@ -1,48 +1,62 @@
 package handler
 
 import (
+	"StudentManager/internal/apperror"
 	"StudentManager/internal/domain"
 	"StudentManager/internal/dto"
 	resp "StudentManager/internal/http/response"
 	"StudentManager/internal/http/service"
-	"context"
-	"errors"
+	"StudentManager/internal/validation"
 	"github.com/go-chi/render"
-	"io"
-	"log"
 	"log/slog"
 	"net/http"
 )
 
 type CreateStudentRequest struct {
-	FullName    string `json:"full_name" env-required:"true"`
-	Age         int    `json:"age" env-required:"true"`
-	GroupNumber string `json:"group_number"`
-	Email       string `json:"email" env-required:"true"`
+	FullName    string `json:"full_name" validate:"required,full_name"`
+	Age         int    `json:"age" validate:"required,age"`
+	GroupNumber string `json:"group_number" validate:"required,group_number"`
+	Email       string `json:"email" validate:"required,email"`
 }
 
+// UpdateStudentRequest is also what a patch applies to, an empty GroupNumber
+// leaves the student without a group
 type UpdateStudentRequest struct {
-	Id          int64  `json:"id" env-required:"true"`
-	FullName    string `json:"full_name" env-required:"true"`
-	Age         int    `json:"age" env-required:"true"`
-	GroupNumber string `json:"group_number"`
-	Email       string `json:"email" env-required:"true"`
+	Id          int64  `json:"id"`
+	FullName    string `json:"full_name" validate:"required,full_name"`
+	Age         int    `json:"age" validate:"required,age"`
+	GroupNumber string `json:"group_number" validate:"group_number"`
+	Email       string `json:"email" validate:"required,email"`
 }
 
-type StudentIdRequest struct {
-	Id int64 `json:"id" env-required:"true"`
+// LogValue logs the request like the student it describes, so the logger
+// redacts the same fields
+func (req CreateStudentRequest) LogValue() slog.Value {
+	return domain.Student{
+		FullName:    req.FullName,
+		Age:         req.Age,
+		GroupNumber: req.GroupNumber,
+		Email:       req.Email,
+	}.LogValue()
 }
 
-type GetStudentRequest struct {
-	FullName string `json:"full_name" env-required:"true"`
+func (req UpdateStudentRequest) LogValue() slog.Value {
+	return domain.Student{
+		Id:          req.Id,
+		FullName:    req.FullName,
+		Age:         req.Age,
+		GroupNumber: req.GroupNumber,
+		Email:       req.Email,
+	}.LogValue()
 }
 
 type StudentHandler struct {
-	service service.StudentService
+	service   service.StudentService
+	validator *validation.Validator
 }
 
-func NewStudentHandler(service service.StudentService) *StudentHandler {
-	return &StudentHandler{service}
+func NewStudentHandler(service service.StudentService, validator *validation.Validator) *StudentHandler {
+	return &StudentHandler{service, validator}
 }
 
 func (h *StudentHandler) CreateStudent() http.HandlerFunc {
@@ -51,27 +65,17 @@ func (h *StudentHandler) CreateStudent() http.HandlerFunc {
 
 		var req CreateStudentRequest
 
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
-
-			log.Println("request body is empty")
-
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
 			return
 		}
-		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
-			return
-		}
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
 
-		log.Println("request body decoded", slog.Any("response", req))
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
 
-		if req.Age == 0 || req.Email == "" || req.FullName == "" || req.GroupNumber == "" {
-			log.Println("invalid request")
-
-			h.responseError(w, r, "invalid request", http.StatusBadRequest)
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -82,17 +86,9 @@ func (h *StudentHandler) CreateStudent() http.HandlerFunc {
 			Email:       req.Email,
 		}
 
-		student, err := studentService.Create(context.Background(), studentDto)
+		student, err := studentService.Create(r.Context(), studentDto)
 		if err != nil {
-			if err.Error() == "student already exists" {
-				h.responseError(w, r, "student already exists", http.StatusBadRequest)
-				return
-			} else if err.Error() == "group doesn't exist" {
-				h.responseError(w, r, "group doesn't exist", http.StatusBadRequest)
-				return
-			}
-
-			h.responseError(w, r, "failed to create student", http.StatusInternalServerError)
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -104,14 +100,21 @@ func (h *StudentHandler) GetAllStudents() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		studentService := h.service
 
-		students, err := studentService.GetAll(context.Background())
+		query, err := studentQueryFromRequest(r)
 		if err != nil {
+			slog.InfoContext(r.Context(), "invalid students query", "err", err)
 
-			h.responseError(w, r, "failed to get student", http.StatusNotFound)
+			h.responseError(w, r, err)
 			return
 		}
 
-		h.responseFoundStudents(w, r, students)
+		page, err := studentService.GetAll(r.Context(), query)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseFoundStudents(w, r, page)
 	}
 }
 
@@ -119,32 +122,17 @@ func (h *StudentHandler) GetStudentById() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		studentService := h.service
 
-		var req StudentIdRequest
-
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
-
-			log.Println("request body is empty")
-
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
-			return
-		}
+		id, err := idFromPath(r)
 		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
+			slog.InfoContext(r.Context(), "invalid student id", "err", err)
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
+			h.responseError(w, r, err)
 			return
 		}
 
-		log.Println("request body decoded", slog.Any("request", req))
-
-		student, err := studentService.GetById(context.Background(), req.Id)
+		student, err := studentService.GetById(r.Context(), id)
 		if err != nil {
-			if err.Error() == "student doesn't exist" {
-				h.responseError(w, r, "student doesn't exist", http.StatusNotFound)
-			}
-
-			h.responseError(w, r, "failed to get student", http.StatusNotFound)
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -156,43 +144,51 @@ func (h *StudentHandler) UpdateStudent() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		studentService := h.service
 
-		var req UpdateStudentRequest
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid student id", "err", err)
 
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
+			h.responseError(w, r, err)
+			return
+		}
 
-			log.Println("request body is empty")
+		var req UpdateStudentRequest
 
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
 			return
 		}
-		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
+		slog.DebugContext(r.Context(), "request body decoded", "request", req)
+
+		// id in the body is optional, but if present it must match the path
+		if req.Id != 0 && req.Id != id {
+			slog.InfoContext(r.Context(), "student id mismatch", "id", id, "body_id", req.Id)
+
+			h.responseError(w, r, apperror.Validation("id_mismatch", "student id in body doesn't match path",
+				apperror.FieldError{Field: "id", Message: "must match the id in the path"}))
 			return
 		}
 
-		log.Println("request body decoded", slog.Any("response", req))
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
 
 		studentDto := dto.StudentDto{
-			Id:          req.Id,
+			Id:          id,
 			FullName:    req.FullName,
 			Age:         req.Age,
 			GroupNumber: req.GroupNumber,
 			Email:       req.Email,
 		}
 
-		student, err := studentService.Update(context.Background(), studentDto)
+		student, err := studentService.Update(r.Context(), studentDto)
 
 		if err != nil {
-			if err.Error() == "student doesn't exist" {
-
-				h.responseError(w, r, "student doesn't exist", http.StatusNotFound)
-				return
-			}
-
-			h.responseError(w, r, "failed to update student", http.StatusInternalServerError)
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -200,39 +196,70 @@ func (h *StudentHandler) UpdateStudent() http.HandlerFunc {
 	}
 }
 
-func (h *StudentHandler) DeleteStudentById() http.HandlerFunc {
+func (h *StudentHandler) PatchStudent() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		studentService := h.service
 
-		var req StudentIdRequest
-
-		err := render.DecodeJSON(r.Body, &req)
-		if errors.Is(err, io.EOF) {
-
-			log.Println("request body is empty")
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid student id", "err", err)
 
-			h.responseError(w, r, "empty request", http.StatusBadRequest)
+			h.responseError(w, r, err)
 			return
 		}
-		if err != nil {
-			log.Printf("failed to decode request body: %v", err)
 
-			h.responseError(w, r, "failed to decode request", http.StatusBadRequest)
+		patch, err := patcherFromRequest(r)
+		if err != nil {
+			h.responseError(w, r, err)
 			return
 		}
 
-		log.Println("request body decoded", slog.Any("request", req))
+		student, err := studentService.Patch(r.Context(), id, func(current domain.Student) (domain.Student, error) {
+			var req UpdateStudentRequest
+			if err := applyPatch(current, patch, &req); err != nil {
+				return domain.Student{}, err
+			}
 
-		err = studentService.DeleteById(context.Background(), req.Id)
+			if req.Id != id {
+				return domain.Student{}, apperror.Validation("id_mismatch", "student id can't be changed",
+					apperror.FieldError{Field: "id", Message: "must match the id in the path"})
+			}
+			if err := h.validator.Struct(req); err != nil {
+				return domain.Student{}, err
+			}
+
+			return domain.Student{
+				Id:          id,
+				FullName:    req.FullName,
+				Age:         req.Age,
+				GroupNumber: req.GroupNumber,
+				Email:       req.Email,
+			}, nil
+		})
 		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
 
-			if err.Error() == "student does not exist" {
+		h.responseStudentUpdated(w, r, student)
+	}
+}
 
-				h.responseError(w, r, "student doesn't exist", http.StatusNotFound)
-				return
-			}
+func (h *StudentHandler) DeleteStudentById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		studentService := h.service
 
-			h.responseError(w, r, "failed to delete student", http.StatusInternalServerError)
+		id, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid student id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		err = studentService.DeleteById(r.Context(), id)
+		if err != nil {
+			h.responseError(w, r, err)
 			return
 		}
 
@@ -240,9 +267,9 @@ func (h *StudentHandler) DeleteStudentById() http.HandlerFunc {
 	}
 }
 
-func (h *StudentHandler) responseFoundStudents(w http.ResponseWriter, r *http.Request, students []domain.Student) {
+func (h *StudentHandler) responseFoundStudents(w http.ResponseWriter, r *http.Request, page dto.StudentPage) {
 	w.WriteHeader(http.StatusOK)
-	render.JSON(w, r, resp.StudentsResponse(students))
+	render.JSON(w, r, resp.StudentsResponse(page))
 }
 
 func (h *StudentHandler) responseFoundStudent(w http.ResponseWriter, r *http.Request, student domain.Student) {
@@ -260,7 +287,32 @@ func (h *StudentHandler) responseStudentUpdated(w http.ResponseWriter, r *http.R
 	render.JSON(w, r, resp.StudentResponse(student))
 }
 
-func (h *StudentHandler) responseError(w http.ResponseWriter, r *http.Request, msg string, status int) {
-	w.WriteHeader(status)
-	render.JSON(w, r, resp.Error(msg))
+func (h *StudentHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
+
+func studentQueryFromRequest(r *http.Request) (dto.StudentQuery, error) {
+	values := r.URL.Query()
+
+	page, err := pageFromQuery(values)
+	if err != nil {
+		return dto.StudentQuery{}, err
+	}
+
+	query := dto.StudentQuery{
+		StudentFilter: dto.StudentFilter{
+			GroupNumber:  values.Get("group_number"),
+			EmailDomain:  values.Get("email_domain"),
+			NameContains: values.Get("name_contains"),
+		},
+		PageRequest: page,
+	}
+	if query.AgeMin, err = intFromQuery(values, "age_min"); err != nil {
+		return dto.StudentQuery{}, err
+	}
+	if query.AgeMax, err = intFromQuery(values, "age_max"); err != nil {
+		return dto.StudentQuery{}, err
+	}
+
+	return query, nil
 }
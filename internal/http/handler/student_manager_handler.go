@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+// WaitlistRequest is the optional body of PUT /groups/{Id}/waitlist/{studentId},
+// priority only matters for groups with a priority waitlist
+type WaitlistRequest struct {
+	Priority int `json:"priority" validate:"waitlist_priority"`
+}
+
+type StudentManagerHandler struct {
+	service   service.StudentManagerService
+	validator *validation.Validator
+}
+
+func NewStudentManagerHandler(service service.StudentManagerService,
+	validator *validation.Validator) *StudentManagerHandler {
+	return &StudentManagerHandler{
+		service:   service,
+		validator: validator,
+	}
+}
+
+// AddStudentToGroup puts the student into the group. With ?from={groupId}
+// the student is transferred from that group instead, it has to be there.
+func (h *StudentManagerHandler) AddStudentToGroup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerService := h.service
+
+		groupId, studentId, err := membershipFromPath(r)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		fromGroupId, err := idFromQuery(r.URL.Query(), "from")
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		var roster dto.GroupRoster
+		if fromGroupId != 0 {
+			roster, err = managerService.TransferStudent(r.Context(), studentId, fromGroupId, groupId)
+		} else {
+			roster, err = managerService.AddStudentToGroup(r.Context(), groupId, studentId)
+		}
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseRoster(w, r, roster)
+	}
+}
+
+func (h *StudentManagerHandler) RemoveStudentFromGroup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerService := h.service
+
+		groupId, studentId, err := membershipFromPath(r)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		roster, err := managerService.RemoveStudentFromGroup(r.Context(), groupId, studentId)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseRoster(w, r, roster)
+	}
+}
+
+func (h *StudentManagerHandler) GetWaitlist() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerService := h.service
+
+		groupId, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		waitlist, err := managerService.GetWaitlist(r.Context(), groupId)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseWaitlist(w, r, waitlist)
+	}
+}
+
+// PutOnWaitlist queues the student for a seat in the full group, calling it
+// again for a waiting student changes its priority
+func (h *StudentManagerHandler) PutOnWaitlist() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerService := h.service
+
+		groupId, studentId, err := membershipFromPath(r)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		var req WaitlistRequest
+		if r.ContentLength != 0 {
+			if err := decodeJSON(r, &req); err != nil {
+				h.responseError(w, r, err)
+				return
+			}
+		}
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		waitlist, err := managerService.PutOnWaitlist(r.Context(), groupId, studentId, req.Priority)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseWaitlist(w, r, waitlist)
+	}
+}
+
+func (h *StudentManagerHandler) RemoveFromWaitlist() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerService := h.service
+
+		groupId, studentId, err := membershipFromPath(r)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		waitlist, err := managerService.RemoveFromWaitlist(r.Context(), groupId, studentId)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseWaitlist(w, r, waitlist)
+	}
+}
+
+// membershipFromPath reads /groups/{Id}/students/{studentId} and
+// /groups/{Id}/waitlist/{studentId}
+func membershipFromPath(r *http.Request) (groupId, studentId int64, err error) {
+	groupId, err = idFromPath(r)
+	if err != nil {
+		slog.InfoContext(r.Context(), "invalid group id", "err", err)
+		return 0, 0, err
+	}
+	studentId, err = idParamFromPath(r, "studentId", "student_id")
+	if err != nil {
+		slog.InfoContext(r.Context(), "invalid student id", "err", err)
+		return 0, 0, err
+	}
+
+	return groupId, studentId, nil
+}
+
+func (h *StudentManagerHandler) responseRoster(w http.ResponseWriter, r *http.Request, roster dto.GroupRoster) {
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp.RosterResponse(roster))
+}
+
+func (h *StudentManagerHandler) responseWaitlist(w http.ResponseWriter, r *http.Request,
+	waitlist dto.GroupWaitlist) {
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp.WaitlistResponse(waitlist))
+}
+
+func (h *StudentManagerHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
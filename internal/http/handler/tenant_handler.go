@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type CreateTenantRequest struct {
+	Id   string `json:"id" validate:"required,tenant_id"`
+	Name string `json:"name" validate:"required,tenant_name"`
+}
+
+type UpdateTenantRequest struct {
+	Name string `json:"name" validate:"required,tenant_name"`
+}
+
+type TenantHandler struct {
+	service   service.TenantService
+	validator *validation.Validator
+}
+
+func NewTenantHandler(service service.TenantService, validator *validation.Validator) *TenantHandler {
+	return &TenantHandler{
+		service:   service,
+		validator: validator,
+	}
+}
+
+func (h *TenantHandler) CreateTenant() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantService := h.service
+
+		var req CreateTenantRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		tenant, err := tenantService.Create(r.Context(), dto.TenantDto{Id: req.Id, Name: req.Name})
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, resp.TenantResponse(tenant))
+	}
+}
+
+func (h *TenantHandler) GetAllTenants() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantService := h.service
+
+		tenants, err := tenantService.GetAll(r.Context())
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.TenantsResponse(tenants))
+	}
+}
+
+func (h *TenantHandler) GetTenant() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantService := h.service
+
+		tenant, err := tenantService.GetById(r.Context(), chi.URLParam(r, "tenantId"))
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.TenantResponse(tenant))
+	}
+}
+
+func (h *TenantHandler) UpdateTenant() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantService := h.service
+
+		var req UpdateTenantRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		tenant, err := tenantService.Update(r.Context(),
+			dto.TenantDto{Id: chi.URLParam(r, "tenantId"), Name: req.Name})
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, resp.TenantResponse(tenant))
+	}
+}
+
+func (h *TenantHandler) DeleteTenant() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantService := h.service
+
+		err := tenantService.DeleteById(r.Context(), chi.URLParam(r, "tenantId"))
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *TenantHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
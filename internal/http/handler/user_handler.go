@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/http/service"
+	"StudentManager/internal/validation"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type CreateUserRequest struct {
+	Username  string   `json:"username" validate:"required,username"`
+	Password  string   `json:"password" validate:"required,password"`
+	StudentId *int64   `json:"student_id"`
+	Roles     []string `json:"roles"`
+}
+
+type SetRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type UserHandler struct {
+	service   service.UserService
+	validator *validation.Validator
+}
+
+func NewUserHandler(service service.UserService, validator *validation.Validator) *UserHandler {
+	return &UserHandler{
+		service:   service,
+		validator: validator,
+	}
+}
+
+func (h *UserHandler) CreateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userService := h.service
+
+		var req CreateUserRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		if err := h.validator.Struct(req); err != nil {
+			slog.InfoContext(r.Context(), "invalid request", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		userDto := dto.UserDto{
+			Username:  req.Username,
+			Password:  req.Password,
+			StudentId: req.StudentId,
+			Roles:     req.Roles,
+		}
+
+		user, err := userService.Create(r.Context(), userDto)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		render.JSON(w, r, resp.UserResponse(user))
+	}
+}
+
+func (h *UserHandler) GetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userService := h.service
+
+		user, err := userService.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseFoundUser(w, r, user)
+	}
+}
+
+func (h *UserHandler) SetUserRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userService := h.service
+
+		var req SetRolesRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+		if req.Roles == nil {
+			h.responseError(w, r, apperror.Validation("invalid_request", "request is invalid",
+				apperror.FieldError{Field: "roles", Message: "is required"}))
+			return
+		}
+
+		user, err := userService.SetRoles(r.Context(), chi.URLParam(r, "username"), req.Roles)
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		h.responseFoundUser(w, r, user)
+	}
+}
+
+func (h *UserHandler) DeleteUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userService := h.service
+
+		err := userService.DeleteByUsername(r.Context(), chi.URLParam(r, "username"))
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *UserHandler) AddCurator() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userService := h.service
+
+		groupId, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		err = userService.AddCurator(r.Context(), groupId, chi.URLParam(r, "username"))
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *UserHandler) RemoveCurator() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userService := h.service
+
+		groupId, err := idFromPath(r)
+		if err != nil {
+			slog.InfoContext(r.Context(), "invalid group id", "err", err)
+
+			h.responseError(w, r, err)
+			return
+		}
+
+		err = userService.RemoveCurator(r.Context(), groupId, chi.URLParam(r, "username"))
+		if err != nil {
+			h.responseError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *UserHandler) responseFoundUser(w http.ResponseWriter, r *http.Request, user domain.User) {
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp.UserResponse(user))
+}
+
+func (h *UserHandler) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, r, err)
+}
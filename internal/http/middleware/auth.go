@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/auth"
+	resp "StudentManager/internal/http/response"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const realm = "student-manager"
+
+// Authenticate puts the caller identity into the request context. Callers
+// use either HTTP basic credentials or a bearer token from /auth/token, the
+// latter only when tokens is not nil. Every request but those to the public
+// paths must be authenticated, reads included: the services scope what a
+// caller may see by their roles, and an anonymous caller has none.
+func Authenticate(passwords auth.PasswordChecker, tokens *auth.Tokens,
+	publicPaths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPublicPath(r.URL.Path, publicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			identity, err := identityFromRequest(r, passwords, tokens)
+			if err != nil {
+				slog.InfoContext(r.Context(), "failed to authenticate", "method", r.Method, "path", r.URL.Path, "err", err)
+				challenge(w, r, tokens != nil, err)
+				return
+			}
+			if identity == nil {
+				challenge(w, r, tokens != nil,
+					apperror.Unauthorized("unauthenticated", "authentication is required"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), *identity)))
+		})
+	}
+}
+
+// identityFromRequest returns nil without an error for anonymous requests
+func identityFromRequest(r *http.Request, passwords auth.PasswordChecker,
+	tokens *auth.Tokens) (*auth.Identity, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+
+	scheme, token, _ := strings.Cut(header, " ")
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && tokens != nil:
+		identity, err := tokens.Parse(strings.TrimSpace(token))
+		if err != nil {
+			return nil, apperror.Unauthorized("invalid_token", "access token is invalid or expired")
+		}
+		return &identity, nil
+	case strings.EqualFold(scheme, "Basic"):
+		user, password, ok := r.BasicAuth()
+		if !ok || !passwords.CheckPassword(r.Context(), user, password) {
+			return nil, apperror.Unauthorized("invalid_credentials", "invalid user name or password")
+		}
+		return &auth.Identity{Subject: user, Method: auth.MethodBasic}, nil
+	default:
+		return nil, apperror.Unauthorized("unsupported_authorization",
+			"unsupported authorization scheme "+scheme)
+	}
+}
+
+func challenge(w http.ResponseWriter, r *http.Request, bearer bool, err error) {
+	if bearer {
+		challenge := `Bearer realm="` + realm + `"`
+		if appErr, ok := apperror.As(err); ok && appErr.Code == "invalid_token" {
+			challenge += `, error="invalid_token"`
+		}
+		w.Header().Add("WWW-Authenticate", challenge)
+	}
+	w.Header().Add("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+
+	resp.WriteProblem(w, r, resp.ProblemFromError(err))
+}
+
+// isPublicPath matches exact paths, and path prefixes written as "/prefix/*"
+func isPublicPath(path string, publicPaths []string) bool {
+	for _, public := range publicPaths {
+		if prefix, ok := strings.CutSuffix(public, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == public {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestIdHeader hands the request id to the client, so a report of a
+// failed request can be matched with the log lines it produced
+const RequestIdHeader = "X-Request-Id"
+
+// LogRequests logs every request once it is answered. It has to run after
+// chi's RequestID, whose id the logger adds to this line and every line
+// logged deeper down with the request's context.
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(RequestIdHeader, id)
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.InfoContext(r.Context(), "request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr,
+		)
+	})
+}
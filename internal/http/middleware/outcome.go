@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"StudentManager/internal/http/response"
+	"StudentManager/internal/metrics"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RequestStats counts how requests ended. Requests the client gave up on and
+// those that ran out of time are no failures of the app and are kept apart.
+type RequestStats struct {
+	completed atomic.Int64
+	failed    atomic.Int64
+	canceled  atomic.Int64
+	timedOut  atomic.Int64
+}
+
+type RequestCounts struct {
+	Completed int64
+	Failed    int64
+	Canceled  int64
+	TimedOut  int64
+}
+
+func (s *RequestStats) Counts() RequestCounts {
+	return RequestCounts{
+		Completed: s.completed.Load(),
+		Failed:    s.failed.Load(),
+		Canceled:  s.canceled.Load(),
+		TimedOut:  s.timedOut.Load(),
+	}
+}
+
+// CountOutcomes sorts every request into stats by how it ended. It has to run
+// after Timeout to tell timeouts from cancellations.
+func CountOutcomes(stats *RequestStats) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			// a request answered before its context was done still completed
+			status := ww.Status()
+			failed := status >= http.StatusInternalServerError
+			ctxErr := r.Context().Err()
+			switch {
+			case status == response.StatusClientClosedRequest || failed && errors.Is(ctxErr, context.Canceled):
+				stats.canceled.Add(1)
+				metrics.HTTPRequestOutcomes.WithLabelValues("canceled").Inc()
+				slog.InfoContext(r.Context(), "request canceled by the client", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+			case status == http.StatusGatewayTimeout || failed && errors.Is(ctxErr, context.DeadlineExceeded):
+				stats.timedOut.Add(1)
+				metrics.HTTPRequestOutcomes.WithLabelValues("timed_out").Inc()
+				slog.InfoContext(r.Context(), "request timed out", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+			case failed:
+				stats.failed.Add(1)
+				metrics.HTTPRequestOutcomes.WithLabelValues("failed").Inc()
+			default:
+				stats.completed.Add(1)
+				metrics.HTTPRequestOutcomes.WithLabelValues("completed").Inc()
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/auth"
+	"StudentManager/internal/config"
+	resp "StudentManager/internal/http/response"
+	"StudentManager/internal/tenant"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TenantLookup tells whether a tenant exists
+type TenantLookup interface {
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+// ResolveTenant puts the tenant a request works on into its context. The
+// tenant is named by the configured header, by the subdomain of the base
+// domain or by the tenant a bearer token was issued for, and a request can't
+// name another tenant than its token. Requests that name none work on the
+// default tenant, unless a tenant is required for all but the public paths.
+// It has to run after Authenticate.
+func ResolveTenant(cfg config.Tenancy, tenants TenantLookup,
+	publicPaths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := requestedTenant(r, cfg)
+
+			if identity, ok := auth.IdentityFrom(r.Context()); ok && identity.Tenant != "" {
+				if id != "" && id != identity.Tenant {
+					slog.WarnContext(r.Context(), "tenant mismatch", "username", identity.Subject, "tenant_id", id, "token_tenant", identity.Tenant)
+					resp.WriteProblem(w, r, resp.ProblemFromError(
+						apperror.Forbidden("tenant_mismatch", "token was issued for another tenant")))
+					return
+				}
+				id = identity.Tenant
+			}
+
+			if id == "" {
+				if cfg.Required && !isPublicPath(r.URL.Path, publicPaths) {
+					resp.WriteProblem(w, r, resp.ProblemFromError(
+						apperror.Validation("tenant_required", "request must name a tenant")))
+					return
+				}
+				id = tenant.DefaultId
+			}
+
+			exists, err := tenants.Exists(r.Context(), id)
+			if err != nil {
+				resp.WriteProblem(w, r, resp.ProblemFromError(err))
+				return
+			}
+			if !exists {
+				slog.InfoContext(r.Context(), "tenant doesn't exist", "tenant_id", id)
+				resp.WriteProblem(w, r, resp.ProblemFromError(
+					apperror.NotFound("tenant_not_found", "tenant doesn't exist")))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithId(r.Context(), id)))
+		})
+	}
+}
+
+// requestedTenant returns the tenant named by the header or the subdomain,
+// the header wins when both are there
+func requestedTenant(r *http.Request, cfg config.Tenancy) string {
+	if cfg.Header != "" {
+		if id := strings.TrimSpace(r.Header.Get(cfg.Header)); id != "" {
+			return id
+		}
+	}
+	if cfg.BaseDomain == "" {
+		return ""
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	sub, ok := strings.CutSuffix(host, "."+strings.ToLower(cfg.BaseDomain))
+	if !ok || strings.Contains(sub, ".") {
+		return ""
+	}
+
+	return sub
+}
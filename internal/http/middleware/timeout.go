@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type routeTimeout struct {
+	// empty for every method
+	method  string
+	path    string
+	prefix  bool
+	timeout time.Duration
+}
+
+// Timeout gives every request a deadline, the one of the most specific route
+// in routes matching it or fallback. Routes are written as "/path",
+// "/prefix/*" or "METHOD /path"; a timeout of 0 means no deadline. The
+// deadline reaches the storage through the request context, handlers answer
+// 504 when it passes.
+func Timeout(fallback time.Duration, routes map[string]time.Duration) (func(http.Handler) http.Handler, error) {
+	parsed := make([]routeTimeout, 0, len(routes))
+	for route, timeout := range routes {
+		rt, err := parseRouteTimeout(route, timeout)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, rt)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := fallback
+			if rt, ok := matchRouteTimeout(parsed, r.Method, r.URL.Path); ok {
+				timeout = rt.timeout
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+func parseRouteTimeout(route string, timeout time.Duration) (routeTimeout, error) {
+	rt := routeTimeout{timeout: timeout}
+
+	path := strings.TrimSpace(route)
+	if method, rest, ok := strings.Cut(path, " "); ok {
+		rt.method = strings.ToUpper(method)
+		path = strings.TrimSpace(rest)
+	}
+	if !strings.HasPrefix(path, "/") {
+		return routeTimeout{}, fmt.Errorf("invalid route %q in route_timeouts, paths start with /", route)
+	}
+	if timeout < 0 {
+		return routeTimeout{}, fmt.Errorf("negative timeout for route %q", route)
+	}
+
+	rt.path, rt.prefix = strings.CutSuffix(path, "*")
+
+	return rt, nil
+}
+
+// matchRouteTimeout prefers exact paths to prefixes, longer prefixes to
+// shorter ones and routes with a method to those without
+func matchRouteTimeout(routes []routeTimeout, method, path string) (routeTimeout, bool) {
+	var best routeTimeout
+	bestRank := -1
+	for _, rt := range routes {
+		if rt.method != "" && rt.method != method {
+			continue
+		}
+
+		var rank int
+		switch {
+		case !rt.prefix && path == rt.path:
+			rank = 1 << 20
+		case rt.prefix && strings.HasPrefix(path, rt.path):
+			rank = len(rt.path) * 2
+		default:
+			continue
+		}
+		if rt.method != "" {
+			rank++
+		}
+
+		if rank > bestRank {
+			best, bestRank = rt, rank
+		}
+	}
+
+	return best, bestRank >= 0
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"StudentManager/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"net/http"
+)
+
+// TraceIdHeader hands the trace id to the client, so a slow request can be
+// looked up in the tracing backend
+const TraceIdHeader = "X-Trace-Id"
+
+// Trace records a span for every request, continuing the trace of the
+// caller when it sends a traceparent header. The span is named after the chi
+// route pattern once the request is routed, like "GET /students/{Id}".
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		// the path isn't recorded, it may hold a username
+		ctx, span := tracing.StartRequest(ctx, r.Method, semconv.HTTPRequestMethodKey.String(r.Method))
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+			w.Header().Set(TraceIdHeader, spanContext.TraceID().String())
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// client errors are the client's, they don't fail the span
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package response
+
+import (
+	"StudentManager/internal/apperror"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// StatusClientClosedRequest is the nginx status for requests the client gave
+// up on, nobody reads the response but it shows up in logs and metrics
+const StatusClientClosedRequest = 499
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for
+// clients, Detail is human-readable and may change.
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     string                `json:"code"`
+	Errors   []apperror.FieldError `json:"errors,omitempty"`
+}
+
+func NewProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  statusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// ProblemFromError is the only place where errors become HTTP statuses
+func ProblemFromError(err error) Problem {
+	appErr, ok := apperror.As(err)
+	if !ok {
+		return NewProblem(http.StatusInternalServerError, "internal_error", "internal server error")
+	}
+
+	var status int
+	switch {
+	case errors.Is(appErr.Kind, apperror.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(appErr.Kind, apperror.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(appErr.Kind, apperror.ErrValidation):
+		status = http.StatusBadRequest
+	case errors.Is(appErr.Kind, apperror.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(appErr.Kind, apperror.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(appErr.Kind, apperror.ErrCanceled):
+		status = StatusClientClosedRequest
+	case errors.Is(appErr.Kind, apperror.ErrTimeout):
+		status = http.StatusGatewayTimeout
+	case errors.Is(appErr.Kind, apperror.ErrDependency):
+		// the cause stays in the logs, it may leak storage details
+		return NewProblem(http.StatusServiceUnavailable, appErr.Code, appErr.Message)
+	default:
+		status = http.StatusInternalServerError
+	}
+
+	problem := NewProblem(status, appErr.Code, appErr.Message)
+	problem.Errors = appErr.Fields
+
+	return problem
+}
+
+func statusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+
+	return http.StatusText(status)
+}
+
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	problem.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write problem response", "err", err)
+	}
+}
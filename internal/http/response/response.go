@@ -2,14 +2,24 @@ package response
 
 import (
 	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
 )
 
 type Response struct {
-	Error    string           `json:"error,omitempty"`
-	Student  *domain.Student  `json:"student,omitempty"`
-	Students []domain.Student `json:"students,omitempty"`
-	Groups   []domain.Group   `json:"groups,omitempty"`
-	Group    *domain.Group    `json:"group,omitempty"`
+	Student     *domain.Student        `json:"student,omitempty"`
+	Students    []domain.Student       `json:"students,omitempty"`
+	Groups      []domain.Group         `json:"groups,omitempty"`
+	Group       *domain.Group          `json:"group,omitempty"`
+	User        *domain.User           `json:"user,omitempty"`
+	Tenant      *domain.Tenant         `json:"tenant,omitempty"`
+	Tenants     []domain.Tenant        `json:"tenants,omitempty"`
+	Waitlist    []domain.WaitlistEntry `json:"waitlist,omitempty"`
+	Course      *domain.Course         `json:"course,omitempty"`
+	Courses     []domain.Course        `json:"courses,omitempty"`
+	Enrollment  *domain.Enrollment     `json:"enrollment,omitempty"`
+	Enrollments []domain.Enrollment    `json:"enrollments,omitempty"`
+	NextCursor  string                 `json:"next_cursor,omitempty"`
+	Total       *int64                 `json:"total,omitempty"`
 }
 
 func StudentResponse(student domain.Student) Response {
@@ -18,15 +28,19 @@ func StudentResponse(student domain.Student) Response {
 	}
 }
 
-func StudentsResponse(students []domain.Student) Response {
+func StudentsResponse(page dto.StudentPage) Response {
 	return Response{
-		Students: students,
+		Students:   page.Students,
+		NextCursor: page.NextCursor,
+		Total:      &page.Total,
 	}
 }
 
-func GroupsResponse(groups []domain.Group) Response {
+func GroupsResponse(page dto.GroupPage) Response {
 	return Response{
-		Groups: groups,
+		Groups:     page.Groups,
+		NextCursor: page.NextCursor,
+		Total:      &page.Total,
 	}
 }
 
@@ -36,8 +50,77 @@ func GroupResponse(group domain.Group) Response {
 	}
 }
 
-func Error(msg string) Response {
+func UserResponse(user domain.User) Response {
 	return Response{
-		Error: msg,
+		User: &user,
+	}
+}
+
+func TenantResponse(tenant domain.Tenant) Response {
+	return Response{
+		Tenant: &tenant,
+	}
+}
+
+func TenantsResponse(tenants []domain.Tenant) Response {
+	return Response{
+		Tenants: tenants,
+	}
+}
+
+// RosterResponse is a group with a page of its students, total counts all of them
+func RosterResponse(roster dto.GroupRoster) Response {
+	return Response{
+		Group:      &roster.Group,
+		Students:   roster.Students,
+		NextCursor: roster.NextCursor,
+		Total:      &roster.Total,
+	}
+}
+
+// RosterStudentsResponse is the page of a roster without the group
+func RosterStudentsResponse(roster dto.GroupRoster) Response {
+	return Response{
+		Students:   roster.Students,
+		NextCursor: roster.NextCursor,
+		Total:      &roster.Total,
+	}
+}
+
+// WaitlistResponse is a group with the students waiting for a seat in it
+func WaitlistResponse(waitlist dto.GroupWaitlist) Response {
+	return Response{
+		Group:    &waitlist.Group,
+		Waitlist: waitlist.Entries,
+	}
+}
+
+func CourseResponse(course domain.Course) Response {
+	return Response{
+		Course: &course,
+	}
+}
+
+func CoursesResponse(page dto.CoursePage) Response {
+	return Response{
+		Courses:    page.Courses,
+		NextCursor: page.NextCursor,
+		Total:      &page.Total,
+	}
+}
+
+func EnrollmentResponse(enrollment domain.Enrollment) Response {
+	return Response{
+		Enrollment: &enrollment,
+	}
+}
+
+// EnrollmentsResponse is a course with a page of its enrollments, total counts all of them
+func EnrollmentsResponse(enrollments dto.CourseEnrollments) Response {
+	return Response{
+		Course:      &enrollments.Course,
+		Enrollments: enrollments.Enrollments,
+		NextCursor:  enrollments.NextCursor,
+		Total:       &enrollments.Total,
 	}
 }
@@ -0,0 +1,24 @@
+package response
+
+import (
+	"StudentManager/internal/dto"
+	"time"
+)
+
+type Token struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+func TokenResponse(pair dto.TokenPair, now time.Time) Token {
+	return Token{
+		AccessToken:      pair.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(pair.AccessExpiresAt.Sub(now).Seconds()),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresIn: int64(pair.RefreshExpiresAt.Sub(now).Seconds()),
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/auth"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"StudentManager/internal/tenant"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrInvalidCredentials  = apperror.Unauthorized("invalid_credentials", "invalid user name or password")
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid_refresh_token", "refresh token is invalid or expired")
+)
+
+type AuthServiceImpl struct {
+	tokens        *auth.Tokens
+	passwords     auth.PasswordChecker
+	refreshTokens repository.RefreshTokenRepository
+	txManager     repository.TxManager
+	now           func() time.Time
+}
+
+func NewAuthServiceImpl(tokens *auth.Tokens, passwords auth.PasswordChecker,
+	refreshTokens repository.RefreshTokenRepository, txManager repository.TxManager) *AuthServiceImpl {
+	return &AuthServiceImpl{
+		tokens:        tokens,
+		passwords:     passwords,
+		refreshTokens: refreshTokens,
+		txManager:     txManager,
+		now:           time.Now,
+	}
+}
+
+func (authService *AuthServiceImpl) Login(ctx context.Context, user, password string) (dto.TokenPair, error) {
+	if !authService.passwords.CheckPassword(ctx, user, password) {
+		slog.InfoContext(ctx, "invalid credentials")
+		return dto.TokenPair{}, ErrInvalidCredentials
+	}
+
+	familyId, err := auth.NewFamilyId()
+	if err != nil {
+		return dto.TokenPair{}, storageError(err)
+	}
+
+	// tokens are bound to the tenant they were issued for
+	pair, err := authService.issue(ctx, user, tenant.IdFrom(ctx), familyId)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to issue tokens", "err", err)
+		return dto.TokenPair{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "tokens issued", "username", user)
+	return pair, nil
+}
+
+// Refresh rotates a refresh token: the presented one is revoked and a new
+// one of the same family is issued. Presenting a revoked token means it has
+// leaked, so the whole family is revoked.
+func (authService *AuthServiceImpl) Refresh(ctx context.Context, refreshToken string) (dto.TokenPair, error) {
+	repo := authService.refreshTokens
+	tokenHash := auth.HashRefreshToken(refreshToken)
+
+	var pair dto.TokenPair
+	var reused *domain.RefreshToken
+	err := authService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		reused = nil
+		now := authService.now()
+
+		stored, err := repo.GetByHash(ctx, tokenHash)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		if stored.RevokedAt != nil {
+			reused = &stored
+			return nil
+		}
+		if !now.Before(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		err = repo.Revoke(ctx, tokenHash, now)
+		if errors.Is(err, repository.ErrNotFound) {
+			// a concurrent refresh of the same token got there first
+			reused = &stored
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pair, err = authService.issue(ctx, stored.Subject, stored.TenantId, stored.FamilyId)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to refresh tokens", "err", err)
+		return dto.TokenPair{}, storageError(err)
+	}
+
+	// revoked outside of the transaction above, which has nothing to commit then
+	if reused != nil {
+		slog.WarnContext(ctx, "revoked refresh token reused, revoking its family", "username", reused.Subject)
+		if err := repo.RevokeFamily(ctx, reused.FamilyId, authService.now()); err != nil {
+			slog.WarnContext(ctx, "failed to revoke refresh token family", "err", err)
+			return dto.TokenPair{}, storageError(err)
+		}
+		return dto.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	slog.InfoContext(ctx, "tokens refreshed")
+	return pair, nil
+}
+
+// Revoke ends the session a refresh token belongs to. Unknown tokens are
+// ignored, as RFC 7009 asks.
+func (authService *AuthServiceImpl) Revoke(ctx context.Context, refreshToken string) error {
+	repo := authService.refreshTokens
+
+	stored, err := repo.GetByHash(ctx, auth.HashRefreshToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get refresh token", "err", err)
+		return storageError(err)
+	}
+
+	if err := repo.RevokeFamily(ctx, stored.FamilyId, authService.now()); err != nil {
+		slog.WarnContext(ctx, "failed to revoke refresh tokens", "err", err)
+		return storageError(err)
+	}
+
+	slog.InfoContext(ctx, "refresh tokens revoked", "username", stored.Subject)
+	return nil
+}
+
+func (authService *AuthServiceImpl) issue(ctx context.Context, subject, tenantId,
+	familyId string) (dto.TokenPair, error) {
+	now := authService.now()
+
+	accessToken, accessExpiresAt, err := authService.tokens.Sign(auth.Identity{Subject: subject, Tenant: tenantId}, now)
+	if err != nil {
+		return dto.TokenPair{}, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return dto.TokenPair{}, err
+	}
+
+	stored := domain.RefreshToken{
+		TokenHash: refreshHash,
+		Subject:   subject,
+		TenantId:  tenantId,
+		FamilyId:  familyId,
+		ExpiresAt: now.Add(authService.tokens.RefreshTTL()),
+		CreatedAt: now,
+	}
+	if err := authService.refreshTokens.Create(ctx, stored); err != nil {
+		return dto.TokenPair{}, err
+	}
+
+	return dto.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+)
+
+var (
+	ErrCourseNotFound  = apperror.NotFound("course_not_found", "course doesn't exist")
+	ErrCourseCodeTaken = apperror.Conflict("course_code_taken", "course with this code already exists")
+	// enrollments keep the history of a course, even dropped and completed ones
+	ErrCourseHasEnrollments = apperror.Conflict("course_has_enrollments", "course still has enrollments")
+)
+
+// CourseServiceImpl keeps the course catalog of a tenant. Everybody who may
+// read courses sees the whole catalog, only the courses a student or a group
+// takes are limited to the students and groups the caller may read.
+type CourseServiceImpl struct {
+	repo              repository.CourseRepository
+	studentRepository repository.StudentRepository
+	groupRepository   repository.GroupRepository
+	policy            *Policy
+}
+
+func NewCourseServiceImpl(repo repository.CourseRepository, studentRepo repository.StudentRepository,
+	groupRepo repository.GroupRepository, policy *Policy) *CourseServiceImpl {
+	return &CourseServiceImpl{
+		repo:              repo,
+		studentRepository: studentRepo,
+		groupRepository:   groupRepo,
+		policy:            policy,
+	}
+}
+
+func (courseService *CourseServiceImpl) Create(ctx context.Context, courseDto dto.CourseDto) (domain.Course, error) {
+	if _, err := courseService.policy.authorize(ctx, domain.PermissionCoursesManage); err != nil {
+		return domain.Course{}, storageError(err)
+	}
+
+	course := domain.Course{
+		Code:  courseDto.Code,
+		Title: courseDto.Title,
+	}
+
+	createdCourse, err := courseService.repo.Create(ctx, course)
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "course already exists", "code", course.Code)
+		return domain.Course{}, ErrCourseCodeTaken
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to create course", "err", err)
+		return domain.Course{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "created course", "course", createdCourse)
+	return createdCourse, nil
+}
+
+// GetAll lists the catalog. With a student or a group in the filter it lists
+// what they take, and nothing when the caller may not see them.
+func (courseService *CourseServiceImpl) GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error) {
+	var err error
+	query.PageRequest, err = normalizePage(query.PageRequest)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+
+	if _, err := courseService.policy.authorize(ctx, domain.PermissionCoursesRead); err != nil {
+		return dto.CoursePage{}, storageError(err)
+	}
+
+	visible, err := courseService.takerVisible(ctx, &query.CourseFilter)
+	if err != nil {
+		return dto.CoursePage{}, storageError(err)
+	}
+	if !visible {
+		return dto.CoursePage{Courses: []domain.Course{}}, nil
+	}
+
+	page, err := courseService.repo.GetAll(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get courses", "err", err)
+		return dto.CoursePage{}, storageError(err)
+	}
+
+	slog.DebugContext(ctx, "received courses", "count", len(page.Courses), "total", page.Total)
+
+	return page, nil
+}
+
+// takerVisible checks the caller may see the student and the group of the
+// filter, and fills in the group of the student
+func (courseService *CourseServiceImpl) takerVisible(ctx context.Context, filter *dto.CourseFilter) (bool, error) {
+	if filter.StudentId != 0 {
+		grant, err := courseService.policy.authorize(ctx, domain.PermissionStudentsRead)
+		if err != nil {
+			return false, err
+		}
+
+		student, err := courseService.studentRepository.GetById(ctx, filter.StudentId)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && !grant.allowsStudent(student) {
+			slog.InfoContext(ctx, "student doesn't exist", "id", filter.StudentId)
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		filter.StudentGroupId = student.GroupId
+	}
+
+	if filter.GroupId != 0 {
+		grant, err := courseService.policy.authorize(ctx, domain.PermissionGroupsRead)
+		if err != nil {
+			return false, err
+		}
+
+		group, err := courseService.groupRepository.GetById(ctx, filter.GroupId)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && !grant.allowsGroup(group.GroupNumber) {
+			slog.InfoContext(ctx, "group doesn't exist", "id", filter.GroupId)
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (courseService *CourseServiceImpl) GetById(ctx context.Context, id int64) (domain.Course, error) {
+	if _, err := courseService.policy.authorize(ctx, domain.PermissionCoursesRead); err != nil {
+		return domain.Course{}, storageError(err)
+	}
+
+	course, err := courseService.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "course doesn't exist")
+		return domain.Course{}, ErrCourseNotFound
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get course", "err", err)
+		return domain.Course{}, storageError(err)
+	}
+
+	slog.DebugContext(ctx, "received course", "course", course)
+
+	return course, nil
+}
+
+func (courseService *CourseServiceImpl) Update(ctx context.Context, courseDto dto.CourseDto) (domain.Course, error) {
+	if _, err := courseService.policy.authorize(ctx, domain.PermissionCoursesManage); err != nil {
+		return domain.Course{}, storageError(err)
+	}
+
+	course := domain.Course{
+		Id:    courseDto.Id,
+		Code:  courseDto.Code,
+		Title: courseDto.Title,
+	}
+
+	updatedCourse, err := courseService.repo.Update(ctx, course)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "course doesn't exist")
+		return domain.Course{}, ErrCourseNotFound
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "course already exists", "code", course.Code)
+		return domain.Course{}, ErrCourseCodeTaken
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to update course", "err", err)
+		return domain.Course{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "course updated", "course", updatedCourse)
+	return updatedCourse, nil
+}
+
+// DeleteById refuses to delete a course that has enrollments, the database
+// checks that in the same statement
+func (courseService *CourseServiceImpl) DeleteById(ctx context.Context, id int64) error {
+	if _, err := courseService.policy.authorize(ctx, domain.PermissionCoursesManage); err != nil {
+		return storageError(err)
+	}
+
+	err := courseService.repo.DeleteById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "course doesn't exist")
+		return ErrCourseNotFound
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "course still has enrollments", "id", id)
+		return ErrCourseHasEnrollments
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to delete course", "err", err)
+		return storageError(err)
+	}
+
+	slog.InfoContext(ctx, "deleted course", "id", id)
+	return nil
+}
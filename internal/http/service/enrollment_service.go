@@ -0,0 +1,318 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrEnrollmentNotFound = apperror.NotFound("enrollment_not_found", "enrollment doesn't exist")
+	ErrAlreadyEnrolled    = apperror.Conflict("already_enrolled", "group or student is enrolled in this course already")
+	// an elective would duplicate the course the student takes with its group
+	ErrEnrolledWithGroup = apperror.Conflict("student_enrolled_with_group",
+		"student takes this course with its group already")
+	ErrEnrollmentCompleted = apperror.Conflict("enrollment_completed", "completed enrollment can't change")
+	// the request refers to a group or a student that doesn't exist
+	ErrEnrollmentGroupNotFound = apperror.Validation("enrollment_group_not_found", "group doesn't exist",
+		apperror.FieldError{Field: "group_id", Message: "group doesn't exist"})
+	ErrEnrollmentStudentNotFound = apperror.Validation("enrollment_student_not_found", "student doesn't exist",
+		apperror.FieldError{Field: "student_id", Message: "student doesn't exist"})
+)
+
+// EnrollmentServiceImpl enrolls whole groups and single students into
+// courses. An enrollment of a group covers every student in it, electives
+// are for courses a student takes without its group. Enrollments are never
+// deleted, they are dropped or completed instead and keep the history of the
+// course.
+type EnrollmentServiceImpl struct {
+	repo              repository.EnrollmentRepository
+	courseRepository  repository.CourseRepository
+	studentRepository repository.StudentRepository
+	groupRepository   repository.GroupRepository
+	txManager         repository.TxManager
+	policy            *Policy
+}
+
+func NewEnrollmentServiceImpl(repo repository.EnrollmentRepository, courseRepo repository.CourseRepository,
+	studentRepo repository.StudentRepository, groupRepo repository.GroupRepository,
+	txManager repository.TxManager, policy *Policy) *EnrollmentServiceImpl {
+	return &EnrollmentServiceImpl{
+		repo:              repo,
+		courseRepository:  courseRepo,
+		studentRepository: studentRepo,
+		groupRepository:   groupRepo,
+		txManager:         txManager,
+		policy:            policy,
+	}
+}
+
+// GetAll returns the course with a page of the enrollments of the groups and
+// students the caller may read
+func (enrollmentService *EnrollmentServiceImpl) GetAll(ctx context.Context, courseId int64,
+	query dto.EnrollmentQuery) (dto.CourseEnrollments, error) {
+	var err error
+	query.PageRequest, err = normalizePage(query.PageRequest)
+	if err != nil {
+		return dto.CourseEnrollments{}, err
+	}
+
+	if _, err := enrollmentService.policy.authorize(ctx, domain.PermissionCoursesRead); err != nil {
+		return dto.CourseEnrollments{}, storageError(err)
+	}
+	grant, err := enrollmentService.policy.authorize(ctx, domain.PermissionStudentsRead)
+	if err != nil {
+		return dto.CourseEnrollments{}, storageError(err)
+	}
+
+	course, err := enrollmentService.getCourse(ctx, courseId)
+	if err != nil {
+		return dto.CourseEnrollments{}, storageError(err)
+	}
+	if !grant.sees() {
+		return dto.CourseEnrollments{Course: course, Enrollments: []domain.Enrollment{}}, nil
+	}
+
+	grant.limitEnrollments(&query.EnrollmentFilter)
+	query.CourseId = course.Id
+
+	page, err := enrollmentService.repo.GetAll(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get enrollments", "err", err)
+		return dto.CourseEnrollments{}, storageError(err)
+	}
+
+	slog.DebugContext(ctx, "received enrollments", "course_id", courseId, "count", len(page.Enrollments),
+		"total", page.Total)
+
+	return dto.CourseEnrollments{
+		Course:      course,
+		Enrollments: page.Enrollments,
+		NextCursor:  page.NextCursor,
+		Total:       page.Total,
+	}, nil
+}
+
+// GetById answers an enrollment of a group or a student the caller may not
+// read as if it didn't exist
+func (enrollmentService *EnrollmentServiceImpl) GetById(ctx context.Context,
+	courseId, id int64) (domain.Enrollment, error) {
+	if _, err := enrollmentService.policy.authorize(ctx, domain.PermissionCoursesRead); err != nil {
+		return domain.Enrollment{}, storageError(err)
+	}
+	grant, err := enrollmentService.policy.authorize(ctx, domain.PermissionStudentsRead)
+	if err != nil {
+		return domain.Enrollment{}, storageError(err)
+	}
+
+	enrollment, err := enrollmentService.getEnrollment(ctx, courseId, id)
+	if err != nil {
+		return domain.Enrollment{}, storageError(err)
+	}
+	_, err = enrollmentService.subject(ctx, grant, enrollment.GroupId, enrollment.StudentId)
+	if errors.Is(err, ErrGroupOutOfScope) || errors.Is(err, ErrStudentOutOfScope) ||
+		errors.Is(err, apperror.ErrValidation) {
+		return domain.Enrollment{}, ErrEnrollmentNotFound
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get enrollment", "err", err)
+		return domain.Enrollment{}, storageError(err)
+	}
+
+	slog.DebugContext(ctx, "received enrollment", "enrollment", enrollment)
+
+	return enrollment, nil
+}
+
+// Enroll enrolls a group or, as an elective, a single student into the
+// course. A student can't take a course as an elective while its group takes
+// it.
+func (enrollmentService *EnrollmentServiceImpl) Enroll(ctx context.Context,
+	enrollmentDto dto.EnrollmentDto) (domain.Enrollment, error) {
+	grant, err := enrollmentService.policy.authorize(ctx, domain.PermissionEnrollmentsManage)
+	if err != nil {
+		return domain.Enrollment{}, storageError(err)
+	}
+
+	var createdEnrollment domain.Enrollment
+	err = enrollmentService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		course, err := enrollmentService.getCourse(ctx, enrollmentDto.CourseId)
+		if err != nil {
+			return err
+		}
+		student, err := enrollmentService.subject(ctx, grant, enrollmentDto.GroupId, enrollmentDto.StudentId)
+		if err != nil {
+			return err
+		}
+		if enrollmentDto.StudentId != 0 {
+			if err := enrollmentService.checkGroupEnrollment(ctx, course.Id, student); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		createdEnrollment, err = enrollmentService.repo.Create(ctx, domain.Enrollment{
+			CourseId:   course.Id,
+			GroupId:    enrollmentDto.GroupId,
+			StudentId:  enrollmentDto.StudentId,
+			Status:     domain.EnrollmentActive,
+			EnrolledAt: now,
+			UpdatedAt:  now,
+		})
+		if errors.Is(err, repository.ErrConflict) {
+			slog.InfoContext(ctx, "already enrolled", "course_id", course.Id,
+				"group_id", enrollmentDto.GroupId, "student_id", enrollmentDto.StudentId)
+			return ErrAlreadyEnrolled
+		}
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to enroll", "err", err)
+		return domain.Enrollment{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "enrolled", "enrollment", createdEnrollment)
+	return createdEnrollment, nil
+}
+
+// SetStatus drops, completes or reactivates an enrollment. Setting the
+// current status changes nothing, a completed enrollment doesn't change any
+// more.
+func (enrollmentService *EnrollmentServiceImpl) SetStatus(ctx context.Context, courseId, id int64,
+	status string) (domain.Enrollment, error) {
+	grant, err := enrollmentService.policy.authorize(ctx, domain.PermissionEnrollmentsManage)
+	if err != nil {
+		return domain.Enrollment{}, storageError(err)
+	}
+
+	var updatedEnrollment domain.Enrollment
+	err = enrollmentService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		enrollment, err := enrollmentService.getEnrollment(ctx, courseId, id)
+		if err != nil {
+			return err
+		}
+		student, err := enrollmentService.subject(ctx, grant, enrollment.GroupId, enrollment.StudentId)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case enrollment.Status == status:
+			updatedEnrollment = enrollment
+			return nil
+		case enrollment.Status == domain.EnrollmentCompleted:
+			slog.InfoContext(ctx, "enrollment is completed", "id", id)
+			return ErrEnrollmentCompleted
+		case status == domain.EnrollmentActive && enrollment.StudentId != 0:
+			if err := enrollmentService.checkGroupEnrollment(ctx, courseId, student); err != nil {
+				return err
+			}
+		}
+
+		updatedEnrollment, err = enrollmentService.repo.UpdateStatus(ctx, id, status, time.Now())
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrEnrollmentNotFound
+		}
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to set enrollment status", "err", err)
+		return domain.Enrollment{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "enrollment status set", "id", id, "status", updatedEnrollment.Status)
+	return updatedEnrollment, nil
+}
+
+func (enrollmentService *EnrollmentServiceImpl) getCourse(ctx context.Context, id int64) (domain.Course, error) {
+	course, err := enrollmentService.courseRepository.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "course doesn't exist", "id", id)
+		return domain.Course{}, ErrCourseNotFound
+	}
+
+	return course, err
+}
+
+// getEnrollment finds an enrollment of the course, one of another course
+// doesn't exist here
+func (enrollmentService *EnrollmentServiceImpl) getEnrollment(ctx context.Context,
+	courseId, id int64) (domain.Enrollment, error) {
+	enrollment, err := enrollmentService.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && enrollment.CourseId != courseId {
+		slog.InfoContext(ctx, "enrollment doesn't exist", "id", id, "course_id", courseId)
+		return domain.Enrollment{}, ErrEnrollmentNotFound
+	}
+
+	return enrollment, err
+}
+
+// subject checks the group or the student of an enrollment exists and is in
+// the scope of the grant, the student is returned for electives
+func (enrollmentService *EnrollmentServiceImpl) subject(ctx context.Context, grant grant,
+	groupId, studentId int64) (domain.Student, error) {
+	if groupId != 0 {
+		group, err := enrollmentService.groupRepository.GetById(ctx, groupId)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist", "id", groupId)
+			return domain.Student{}, ErrEnrollmentGroupNotFound
+		}
+		if err != nil {
+			return domain.Student{}, err
+		}
+		if !grant.allowsGroup(group.GroupNumber) {
+			slog.InfoContext(ctx, "group is out of scope", "group_number", group.GroupNumber)
+			return domain.Student{}, ErrGroupOutOfScope
+		}
+
+		return domain.Student{}, nil
+	}
+
+	student, err := enrollmentService.studentRepository.GetById(ctx, studentId)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "student doesn't exist", "id", studentId)
+		return domain.Student{}, ErrEnrollmentStudentNotFound
+	}
+	if err != nil {
+		return domain.Student{}, err
+	}
+	if !grant.allowsStudent(student) {
+		slog.InfoContext(ctx, "student is out of scope", "id", studentId)
+		return domain.Student{}, ErrStudentOutOfScope
+	}
+
+	return student, nil
+}
+
+// checkGroupEnrollment refuses an active elective for a course the group of
+// the student takes
+func (enrollmentService *EnrollmentServiceImpl) checkGroupEnrollment(ctx context.Context, courseId int64,
+	student domain.Student) error {
+	if student.GroupId == 0 {
+		return nil
+	}
+
+	page, err := enrollmentService.repo.GetAll(ctx, dto.EnrollmentQuery{
+		EnrollmentFilter: dto.EnrollmentFilter{
+			CourseId: courseId,
+			Status:   domain.EnrollmentActive,
+			GroupId:  student.GroupId,
+		},
+		PageRequest: dto.PageRequest{Limit: 1},
+	})
+	if err != nil {
+		return err
+	}
+	if len(page.Enrollments) > 0 {
+		slog.InfoContext(ctx, "student takes the course with its group", "id", student.Id,
+			"group_number", student.GroupNumber)
+		return ErrEnrolledWithGroup
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+)
+
+var (
+	ErrStudentNotFound   = apperror.NotFound("student_not_found", "student doesn't exist")
+	ErrStudentEmailTaken = apperror.Conflict("student_email_taken", "student with this email already exists")
+	ErrGroupNotFound     = apperror.NotFound("group_not_found", "group doesn't exist")
+	ErrGroupNumberTaken  = apperror.Conflict("group_number_taken", "group with this number already exists")
+	ErrGroupNotEmpty     = apperror.Conflict("group_not_empty", "group still has students")
+	// the reassign delete policy names a group this tenant doesn't have
+	ErrReassignTargetNotFound = apperror.Conflict("reassign_target_not_found",
+		"group that takes the students of deleted groups doesn't exist")
+	ErrGroupIsReassignTarget = apperror.Conflict("group_is_reassign_target",
+		"group takes the students of deleted groups, it can't be deleted")
+	// a student refers to a group that doesn't exist, it's the payload that is wrong
+	ErrStudentGroupNotFound = apperror.Validation("student_group_not_found", "group doesn't exist",
+		apperror.FieldError{Field: "group_number", Message: "group doesn't exist"})
+	ErrConcurrentModification = apperror.Conflict("concurrent_modification",
+		"the data was modified concurrently, please retry")
+)
+
+// storageError converts whatever is left after the service handled the errors
+// it expects from repositories, so handlers only ever see apperror errors
+func storageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := apperror.As(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return apperror.Interrupted(err)
+	}
+
+	if errors.Is(err, repository.ErrTxConflict) {
+		return ErrConcurrentModification
+	}
+	if errors.Is(err, repository.ErrInvalidQuery) {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	return apperror.Dependency("storage_failed", "storage request failed", err)
+}
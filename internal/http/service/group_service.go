@@ -1,28 +1,62 @@
 package service
 
 import (
+	"StudentManager/internal/config"
 	"StudentManager/internal/domain"
 	"StudentManager/internal/dto"
+	"StudentManager/internal/metrics"
 	"StudentManager/internal/repository"
 	"context"
-	"database/sql"
 	"errors"
-	"github.com/jackc/pgx/v4"
-	_ "github.com/jackc/pgx/v4"
-	"log"
+	"fmt"
+	"log/slog"
 )
 
-type GetGroupRequest struct {
-	GroupNumber string `json:"group_number" env-required:"true"`
+// DeletePolicy tells what happens to the students of a deleted group, the
+// zero value restricts
+type DeletePolicy struct {
+	mode string
+	// number of the group that takes the students with config.DeleteReassign
+	reassignTo string
+}
+
+func NewDeletePolicy(cfg config.Groups) (DeletePolicy, error) {
+	switch cfg.DeletePolicy {
+	case config.DeleteRestrict, config.DeleteUnassign:
+	case config.DeleteReassign:
+		if cfg.ReassignTo == "" {
+			return DeletePolicy{}, fmt.Errorf("groups.reassign_to is required by the %s delete policy", cfg.DeletePolicy)
+		}
+	default:
+		return DeletePolicy{}, fmt.Errorf("unknown group delete policy %q, use restrict, unassign or reassign",
+			cfg.DeletePolicy)
+	}
+
+	return DeletePolicy{
+		mode:       cfg.DeletePolicy,
+		reassignTo: cfg.ReassignTo,
+	}, nil
 }
 
 type GroupServiceImpl struct {
-	repo repository.GroupRepository
+	repo              repository.GroupRepository
+	studentRepository repository.StudentRepository
+	txManager         repository.TxManager
+	policy            *Policy
+	deletePolicy      DeletePolicy
+	seats             seats
 }
 
-func NewGroupServiceImpl(repo repository.GroupRepository) *GroupServiceImpl {
+func NewGroupServiceImpl(repo repository.GroupRepository, studentRepo repository.StudentRepository,
+	waitlistRepo repository.WaitlistRepository, txManager repository.TxManager, policy *Policy,
+	deletePolicy DeletePolicy) *GroupServiceImpl {
 	return &GroupServiceImpl{
-		repo: repo,
+		repo:              repo,
+		studentRepository: studentRepo,
+		txManager:         txManager,
+		policy:            policy,
+		deletePolicy:      deletePolicy,
+		seats:             seats{students: studentRepo, groups: repo, waitlists: waitlistRepo},
 	}
 }
 
@@ -32,70 +66,133 @@ func (repo *GroupServiceImpl) Create(
 
 	group := domain.Group{
 		GroupNumber: groupDto.GroupNumber,
+		Capacity:    groupDto.Capacity,
+		Waitlist:    groupDto.Waitlist,
+	}
+
+	grant, err := repo.policy.authorize(ctx, domain.PermissionGroupsManage)
+	if err != nil {
+		return domain.Group{}, storageError(err)
+	}
+	if !grant.allowsGroup(group.GroupNumber) {
+		slog.InfoContext(ctx, "group is out of scope", "group_number", group.GroupNumber)
+		return domain.Group{}, ErrGroupOutOfScope
 	}
 
 	if repo.IsGroupExistsByNumber(ctx, group.GroupNumber) {
 
-		log.Println("group already exists")
-		return domain.Group{}, errors.New("group already exists")
+		slog.InfoContext(ctx, "group already exists")
+		return domain.Group{}, ErrGroupNumberTaken
 	}
-	groupRow, err := service.Create(ctx, group)
 
-	if err != nil {
-		log.Printf("failed to create group %v", err)
-		return domain.Group{}, err
+	createdGroup, err := service.Create(ctx, group)
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "group already exists")
+		return domain.Group{}, ErrGroupNumberTaken
 	}
-
-	createdGroup, err := convertGroupsRowsToDomain(groupRow)
 	if err != nil {
-		log.Printf("failed to convert group into domain %v", err)
-		return domain.Group{}, err
+		slog.WarnContext(ctx, "failed to create group", "err", err)
+		return domain.Group{}, storageError(err)
 	}
 
-	log.Printf("created group: %v", createdGroup)
-	return createdGroup[0], err
+	metrics.GroupsCreated.Inc()
+	slog.InfoContext(ctx, "created group", "group", createdGroup)
+	return createdGroup, nil
 }
 
-func (repo *GroupServiceImpl) GetAll(ctx context.Context) ([]domain.Group, error) {
+func (repo *GroupServiceImpl) GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error) {
 	service := repo.repo
 
-	rows, err := service.GetAll(ctx)
+	var err error
+	query.PageRequest, err = normalizePage(query.PageRequest)
 	if err != nil {
-		log.Printf("failed to get groups %v", err)
-		return []domain.Group{}, err
+		return dto.GroupPage{}, err
 	}
 
-	groups, err := convertGroupsRowsToDomain(rows)
+	grant, err := repo.policy.authorize(ctx, domain.PermissionGroupsRead)
 	if err != nil {
-		log.Printf("failed to convert groups into domain %v", err)
+		return dto.GroupPage{}, storageError(err)
+	}
+	if !grant.sees() {
+		return dto.GroupPage{Groups: []domain.Group{}}, nil
+	}
+	grant.limitGroups(&query.GroupFilter)
 
+	page, err := service.GetAll(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get groups", "err", err)
+		return dto.GroupPage{}, storageError(err)
 	}
 
-	log.Println("received all groups")
+	slog.DebugContext(ctx, "received groups", "count", len(page.Groups), "total", page.Total)
 
-	return groups, nil
+	return page, nil
 }
 
 func (repo *GroupServiceImpl) GetById(ctx context.Context, id int64) (domain.Group, error) {
 	service := repo.repo
 
-	row := service.GetById(ctx, id)
-	if errors.Is(row.Scan(), sql.ErrNoRows) {
-		log.Printf("group doesn't exist")
-		return domain.Group{}, errors.New("group doesn't exist")
+	grant, err := repo.policy.authorize(ctx, domain.PermissionGroupsRead)
+	if err != nil {
+		return domain.Group{}, storageError(err)
 	}
 
-	group, err := convertGroupRowToDomain(row)
+	group, err := service.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && !grant.allowsGroup(group.GroupNumber) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return domain.Group{}, ErrGroupNotFound
+	}
 	if err != nil {
-		log.Printf("failed to convert group into domain %v", err)
-
+		slog.WarnContext(ctx, "failed to get group", "err", err)
+		return domain.Group{}, storageError(err)
 	}
 
-	log.Printf("received group by id: %v", group)
+	slog.DebugContext(ctx, "received group", "group", group)
 
 	return group, nil
 }
 
+func (repo *GroupServiceImpl) GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error) {
+	service := repo.repo
+
+	var err error
+	query.PageRequest, err = normalizePage(query.PageRequest)
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+
+	groupGrant, err := repo.policy.authorize(ctx, domain.PermissionGroupsRead)
+	if err != nil {
+		return dto.GroupRoster{}, storageError(err)
+	}
+	studentGrant, err := repo.policy.authorize(ctx, domain.PermissionStudentsRead)
+	if err != nil {
+		return dto.GroupRoster{}, storageError(err)
+	}
+	studentGrant.limitStudents(&query.StudentFilter)
+
+	roster, err := service.GetRoster(ctx, id, query)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && !groupGrant.allowsGroup(roster.Group.GroupNumber) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return dto.GroupRoster{}, ErrGroupNotFound
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get group roster", "err", err)
+		return dto.GroupRoster{}, storageError(err)
+	}
+	if !studentGrant.sees() {
+		// the filter didn't narrow anything, hide the students it let through
+		roster.Students, roster.NextCursor, roster.Total = []domain.Student{}, "", 0
+	}
+
+	slog.DebugContext(ctx, "received group roster", "group", roster.Group, "count", len(roster.Students),
+		"total", roster.Total)
+
+	return roster, nil
+}
+
+// Update can't lower the capacity below the students in the group. Seats it
+// adds go to the waitlist, turning the waitlist off empties it.
 func (repo *GroupServiceImpl) Update(ctx context.Context,
 	groupDto dto.GroupDto) (domain.Group, error) {
 	service := repo.repo
@@ -103,96 +200,239 @@ func (repo *GroupServiceImpl) Update(ctx context.Context,
 	group := domain.Group{
 		Id:          groupDto.Id,
 		GroupNumber: groupDto.GroupNumber,
+		Capacity:    groupDto.Capacity,
+		Waitlist:    groupDto.Waitlist,
 	}
 
-	if !repo.IsGroupExistsById(ctx, group.Id) {
-		log.Println("group doesn't exist")
-		return domain.Group{}, errors.New("group doesn't exist")
+	grant, err := repo.policy.authorize(ctx, domain.PermissionGroupsManage)
+	if err != nil {
+		return domain.Group{}, storageError(err)
+	}
+
+	var updatedGroup domain.Group
+	err = repo.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		current, err := service.GetById(ctx, group.Id)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist")
+			return ErrGroupNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !grant.allowsGroup(current.GroupNumber) {
+			slog.InfoContext(ctx, "group is out of scope", "group_number", current.GroupNumber)
+			return ErrGroupOutOfScope
+		}
+
+		updatedGroup, err = repo.save(ctx, current, group)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to update group", "err", err)
+		return domain.Group{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "group updated", "group", updatedGroup)
+	return updatedGroup, nil
+}
+
+func (repo *GroupServiceImpl) Patch(ctx context.Context, id int64, patch GroupPatch) (domain.Group, error) {
+	service := repo.repo
+
+	grant, err := repo.policy.authorize(ctx, domain.PermissionGroupsManage)
+	if err != nil {
+		return domain.Group{}, storageError(err)
 	}
 
-	groupRow, err := service.Update(ctx, group)
+	var patchedGroup domain.Group
+	err = repo.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		current, err := service.GetById(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist")
+			return ErrGroupNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !grant.allowsGroup(current.GroupNumber) {
+			slog.InfoContext(ctx, "group is out of scope", "group_number", current.GroupNumber)
+			return ErrGroupOutOfScope
+		}
+
+		patched, err := patch(current)
+		if err != nil {
+			return err
+		}
+
+		patched.Id = current.Id
+		if patched == current {
+			patchedGroup = current
+			return nil
+		}
+
+		patchedGroup, err = repo.save(ctx, current, patched)
+		return err
+	})
 	if err != nil {
+		slog.WarnContext(ctx, "failed to patch group", "err", err)
+		return domain.Group{}, storageError(err)
+	}
 
-		log.Printf("failed to update group %v", err)
+	slog.InfoContext(ctx, "group patched", "group", patchedGroup)
+	return patchedGroup, nil
+}
 
+// save writes the changed group and hands the seats it gains to its waitlist
+func (repo *GroupServiceImpl) save(ctx context.Context, current, changed domain.Group) (domain.Group, error) {
+	if err := repo.seats.fits(ctx, changed); err != nil {
 		return domain.Group{}, err
 	}
 
-	updatedGroup, err := convertGroupsRowsToDomain(groupRow)
+	saved, err := repo.repo.Update(ctx, changed)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return domain.Group{}, ErrGroupNotFound
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "group already exists")
+		return domain.Group{}, ErrGroupNumberTaken
+	}
 	if err != nil {
-		log.Printf("failed to convert group into domain %v", err)
 		return domain.Group{}, err
 	}
 
-	log.Printf("group updated: %v", updatedGroup)
-	return updatedGroup[0], err
+	if current.Waitlist != "" && saved.Waitlist == "" {
+		if err := repo.seats.waitlists.RemoveByGroupId(ctx, saved.Id); err != nil {
+			return domain.Group{}, err
+		}
+		slog.InfoContext(ctx, "waitlist turned off", "group_number", saved.GroupNumber)
+	}
+
+	return saved, repo.seats.release(ctx, saved.Id)
 }
 
+// DeleteById deals with the students of the group as the delete policy says:
+// it refuses to delete a group that still has students, leaves them without a
+// group or moves them to another one. That and the deletion share a
+// serializable transaction, so a student put into the group concurrently
+// makes one of the two operations retry.
 func (repo *GroupServiceImpl) DeleteById(ctx context.Context, id int64) error {
 	service := repo.repo
 
-	if !repo.IsGroupExistsById(ctx, id) {
-		log.Println("group doesn't exist")
-		return errors.New("group doesn't exist")
-	}
-	err := service.DeleteById(ctx, id)
+	grant, err := repo.policy.authorize(ctx, domain.PermissionGroupsManage)
 	if err != nil {
-		log.Printf("failed to delete group %v", err)
+		return storageError(err)
 	}
 
-	log.Printf("deleted group with id: %v", id)
-	return nil
-}
+	err = repo.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		group, err := service.GetById(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist")
+			return ErrGroupNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !grant.allowsGroup(group.GroupNumber) {
+			slog.InfoContext(ctx, "group is out of scope", "group_number", group.GroupNumber)
+			return ErrGroupOutOfScope
+		}
+		if repo.deletePolicy.mode == config.DeleteReassign && group.GroupNumber == repo.deletePolicy.reassignTo {
+			slog.InfoContext(ctx, "group takes the students of deleted groups", "group_number", group.GroupNumber)
+			return ErrGroupIsReassignTarget
+		}
 
-func convertGroupRowToDomain(row pgx.Row) (domain.Group, error) {
-	var group domain.Group
+		students, err := repo.studentRepository.CountByGroupId(ctx, group.Id)
+		if err != nil {
+			return err
+		}
+		if students > 0 {
+			if err := repo.releaseStudents(ctx, grant, group, students); err != nil {
+				return err
+			}
+		}
 
-	err := row.Scan(&group.Id, &group.GroupNumber)
+		err = service.DeleteById(ctx, id)
+		// a student still refers to the group
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrGroupNotEmpty
+		}
 
+		return err
+	})
 	if err != nil {
-		return domain.Group{}, err
+		slog.WarnContext(ctx, "failed to delete group", "err", err)
+		return storageError(err)
 	}
 
-	log.Println("successfully converted group row to domain")
-
-	return group, err
+	metrics.GroupsDeleted.Inc()
+	slog.InfoContext(ctx, "deleted group", "id", id)
+	return nil
 }
 
-func convertGroupsRowsToDomain(rows pgx.Rows) ([]domain.Group, error) {
-	var groups []domain.Group
+// releaseStudents applies the delete policy to the students of a group that
+// is about to be deleted
+func (repo *GroupServiceImpl) releaseStudents(ctx context.Context, grant grant, group domain.Group,
+	students int64) error {
+	service := repo.repo
 
-	for rows.Next() {
-		var r domain.Group
-		err := rows.Scan(&r.Id, &r.GroupNumber)
-		if err != nil {
-			return nil, err
+	switch repo.deletePolicy.mode {
+	case config.DeleteUnassign:
+		if _, err := repo.studentRepository.MoveGroup(ctx, group.Id, 0); err != nil {
+			return err
 		}
-		groups = append(groups, r)
-	}
 
-	log.Println("successfully converted groups rows to domain")
+		slog.InfoContext(ctx, "students left without a group", "group_number", group.GroupNumber,
+			"students", students)
+		return nil
+	case config.DeleteReassign:
+		target, err := service.GetByGroupNumber(ctx, repo.deletePolicy.reassignTo)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "group that takes the students doesn't exist", "group_number",
+				repo.deletePolicy.reassignTo)
+			return ErrReassignTargetNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !grant.allowsGroup(target.GroupNumber) {
+			slog.InfoContext(ctx, "group is out of scope", "group_number", target.GroupNumber)
+			return ErrGroupOutOfScope
+		}
+		free, err := repo.seats.free(ctx, target)
+		if err != nil {
+			return err
+		}
+		if int64(free) < students {
+			slog.InfoContext(ctx, "group that takes the students is full", "group_number", target.GroupNumber,
+				"students", students, "free", free)
+			return ErrGroupFull
+		}
+		if _, err := repo.studentRepository.MoveGroup(ctx, group.Id, target.Id); err != nil {
+			return err
+		}
 
-	return groups, nil
+		slog.InfoContext(ctx, "students moved to another group", "group_number", group.GroupNumber,
+			"to_group_number", target.GroupNumber, "students", students)
+		return nil
+	default:
+		slog.InfoContext(ctx, "group still has students", "group_number", group.GroupNumber, "students", students)
+		return ErrGroupNotEmpty
+	}
 }
 
 func (repo *GroupServiceImpl) IsGroupExistsByNumber(ctx context.Context, groupNumber string) bool {
 	service := repo.repo
 
-	err := service.GetByGroupNumber(ctx, groupNumber)
+	_, err := service.GetByGroupNumber(ctx, groupNumber)
 
-	if errors.Is(err.Scan(), pgx.ErrNoRows) {
-		return false
-	}
-
-	return true
+	return !errors.Is(err, repository.ErrNotFound)
 }
 
 func (repo *GroupServiceImpl) IsGroupExistsById(ctx context.Context, id int64) bool {
 	service := repo.repo
 
-	if errors.Is(service.GetById(ctx, id).Scan(), pgx.ErrNoRows) {
-		return false
-	}
+	_, err := service.GetById(ctx, id)
 
-	return true
+	return !errors.Is(err, repository.ErrNotFound)
 }
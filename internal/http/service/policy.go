@@ -0,0 +1,214 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/auth"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"StudentManager/internal/tenant"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+var (
+	ErrUnauthenticated = apperror.Unauthorized("unauthenticated", "authentication is required")
+	ErrForbidden       = apperror.Forbidden("forbidden", "you are not allowed to do this")
+	// the caller may change some students or groups, but not this one
+	ErrStudentOutOfScope = apperror.Forbidden("student_out_of_scope", "student is outside of the groups you manage")
+	ErrGroupOutOfScope   = apperror.Forbidden("group_out_of_scope", "group is outside of the groups you manage")
+	ErrTenantForbidden   = apperror.Forbidden("tenant_forbidden", "you don't belong to this tenant")
+)
+
+// Policy decides what the caller in a context may do. Every service method
+// asks it first, so the rules hold whatever transport the call came from.
+// Users only act in their own tenant, the configured user in all of them.
+type Policy struct {
+	access   repository.AccessRepository
+	students repository.StudentRepository
+	// the user from the server config, it can do everything
+	admin string
+}
+
+func NewPolicy(access repository.AccessRepository, students repository.StudentRepository, admin string) *Policy {
+	return &Policy{
+		access:   access,
+		students: students,
+		admin:    admin,
+	}
+}
+
+// grant is what a permission allows the caller to touch
+type grant struct {
+	scope string
+	// curated groups for ScopeCurated, the group of the own record for ScopeOwn
+	groupNumbers map[string]bool
+	studentId    int64
+}
+
+func (g grant) allowsGroup(groupNumber string) bool {
+	return g.scope == domain.ScopeAll || g.groupNumbers[groupNumber]
+}
+
+func (g grant) allowsStudent(student domain.Student) bool {
+	switch g.scope {
+	case domain.ScopeAll:
+		return true
+	case domain.ScopeCurated:
+		return g.groupNumbers[student.GroupNumber]
+	case domain.ScopeOwn:
+		return g.studentId != 0 && student.Id == g.studentId
+	default:
+		return false
+	}
+}
+
+// limitStudents narrows a student list to the rows the grant allows
+func (g grant) limitStudents(filter *dto.StudentFilter) {
+	switch g.scope {
+	case domain.ScopeCurated:
+		filter.GroupNumberIn = g.groupNumberIn()
+	case domain.ScopeOwn:
+		filter.Id = g.studentId
+	}
+}
+
+// limitGroups narrows a group list to the rows the grant allows
+func (g grant) limitGroups(filter *dto.GroupFilter) {
+	if g.scope != domain.ScopeAll {
+		filter.GroupNumberIn = g.groupNumberIn()
+	}
+}
+
+// limitEnrollments narrows an enrollment list to the groups and students the
+// grant allows, a student only sees its own electives
+func (g grant) limitEnrollments(filter *dto.EnrollmentFilter) {
+	switch g.scope {
+	case domain.ScopeCurated:
+		filter.GroupNumberIn = g.groupNumberIn()
+	case domain.ScopeOwn:
+		filter.GroupNumberIn = g.groupNumberIn()
+		filter.ElectivesOf = g.studentId
+	}
+}
+
+func (g grant) groupNumberIn() []string {
+	groupNumbers := make([]string, 0, len(g.groupNumbers))
+	for groupNumber := range g.groupNumbers {
+		groupNumbers = append(groupNumbers, groupNumber)
+	}
+	slices.Sort(groupNumbers)
+
+	return groupNumbers
+}
+
+// sees reports whether the grant can match any row at all, lists are empty
+// without asking the storage when it can't
+func (g grant) sees() bool {
+	switch g.scope {
+	case domain.ScopeAll:
+		return true
+	case domain.ScopeOwn:
+		return g.studentId != 0
+	default:
+		return len(g.groupNumbers) > 0
+	}
+}
+
+// authorize returns the widest grant of permission the caller has. When
+// roles give the same permission with different scopes, the widest one wins.
+func (p *Policy) authorize(ctx context.Context, permission string) (grant, error) {
+	identity, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		return grant{}, ErrUnauthenticated
+	}
+	if identity.Subject == p.admin {
+		return grant{scope: domain.ScopeAll}, nil
+	}
+
+	user, err := p.access.GetUser(ctx, identity.Subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "user doesn't exist anymore", "username", identity.Subject)
+		return grant{}, ErrForbidden
+	}
+	if err != nil {
+		return grant{}, err
+	}
+	if user.TenantId != tenant.IdFrom(ctx) {
+		slog.InfoContext(ctx, "user doesn't belong to tenant", "username", identity.Subject)
+		return grant{}, ErrTenantForbidden
+	}
+
+	grants, err := p.access.GetGrants(ctx, identity.Subject)
+	if err != nil {
+		return grant{}, err
+	}
+
+	scope := ""
+	for _, g := range grants {
+		if g.Permission == permission && scopeRank(g.Scope) > scopeRank(scope) {
+			scope = g.Scope
+		}
+	}
+
+	switch scope {
+	case domain.ScopeAll:
+		return grant{scope: scope}, nil
+	case domain.ScopeCurated:
+		groupNumbers, err := p.access.CuratedGroupNumbers(ctx, identity.Subject)
+		if err != nil {
+			return grant{}, err
+		}
+		return grant{scope: scope, groupNumbers: setOf(groupNumbers)}, nil
+	case domain.ScopeOwn:
+		return p.ownGrant(ctx, user)
+	default:
+		slog.InfoContext(ctx, "permission denied", "username", identity.Subject, "permission", permission)
+		return grant{}, ErrForbidden
+	}
+}
+
+func (p *Policy) ownGrant(ctx context.Context, user domain.User) (grant, error) {
+	own := grant{scope: domain.ScopeOwn, groupNumbers: map[string]bool{}}
+
+	if user.StudentId == nil {
+		return own, nil
+	}
+
+	student, err := p.students.GetById(ctx, *user.StudentId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return own, nil
+	}
+	if err != nil {
+		return grant{}, err
+	}
+
+	own.studentId = student.Id
+	own.groupNumbers[student.GroupNumber] = true
+
+	return own, nil
+}
+
+func scopeRank(scope string) int {
+	switch scope {
+	case domain.ScopeAll:
+		return 3
+	case domain.ScopeCurated:
+		return 2
+	case domain.ScopeOwn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func setOf(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
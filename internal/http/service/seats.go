@@ -0,0 +1,163 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+)
+
+var (
+	ErrGroupFull = apperror.Conflict("group_full", "group has no free seats")
+	// a capacity can't be lowered below the students already in the group
+	ErrGroupOverCapacity = apperror.Conflict("group_over_capacity", "group has more students than that capacity")
+)
+
+// seats keeps groups within their capacity and gives the seats that free up
+// to the students waiting for them. Services call it inside their
+// serializable transaction, so two students can't take the last seat at once:
+// one of the transactions fails and is retried, and then finds it taken.
+type seats struct {
+	students  repository.StudentRepository
+	groups    repository.GroupRepository
+	waitlists repository.WaitlistRepository
+}
+
+// take checks the group has a seat left for the student, who stops waiting
+// for it. A groupId of 0 needs no seat, a studentId of 0 is a new student.
+func (s seats) take(ctx context.Context, groupId, studentId int64) error {
+	if groupId == 0 {
+		return nil
+	}
+
+	group, err := s.groups.GetById(ctx, groupId)
+	if err != nil {
+		return err
+	}
+	free, err := s.free(ctx, group)
+	if err != nil {
+		return err
+	}
+	if free == 0 {
+		slog.InfoContext(ctx, "group is full", "group_number", group.GroupNumber, "capacity", group.Capacity)
+		return ErrGroupFull
+	}
+
+	// a student that is only being created waits for nothing
+	if studentId == 0 {
+		return nil
+	}
+	err = s.waitlists.Remove(ctx, groupId, studentId)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// fits checks the students already in the group fit into its new capacity
+func (s seats) fits(ctx context.Context, group domain.Group) error {
+	if group.Capacity == 0 {
+		return nil
+	}
+
+	students, err := s.students.CountByGroupId(ctx, group.Id)
+	if err != nil {
+		return err
+	}
+	if students > int64(group.Capacity) {
+		slog.InfoContext(ctx, "group has more students than the capacity", "group_number", group.GroupNumber,
+			"students", students, "capacity", group.Capacity)
+		return ErrGroupOverCapacity
+	}
+
+	return nil
+}
+
+// release promotes waiting students into the free seats of the group. A
+// promoted student leaves its previous group, whose seat is passed on to the
+// students waiting there in turn.
+func (s seats) release(ctx context.Context, groupId int64) error {
+	pending := []int64{groupId}
+	for len(pending) > 0 {
+		groupId := pending[0]
+		pending = pending[1:]
+
+		left, err := s.promote(ctx, groupId)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, left...)
+	}
+
+	return nil
+}
+
+// promote fills the free seats of one group from its waitlist and returns the
+// groups the promoted students left
+func (s seats) promote(ctx context.Context, groupId int64) ([]int64, error) {
+	if groupId == 0 {
+		return nil, nil
+	}
+
+	group, err := s.groups.GetById(ctx, groupId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if group.Waitlist == "" {
+		return nil, nil
+	}
+
+	queue, err := s.waitlists.GetByGroupId(ctx, group.Id, group.Waitlist)
+	if err != nil || len(queue) == 0 {
+		return nil, err
+	}
+	free, err := s.free(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var left []int64
+	for _, entry := range queue[:min(free, len(queue))] {
+		student, err := s.students.GetById(ctx, entry.StudentId)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.students.Patch(ctx, student.Id, dto.StudentChanges{GroupId: &group.Id}); err != nil {
+			return nil, err
+		}
+		if err := s.waitlists.Remove(ctx, group.Id, student.Id); err != nil {
+			return nil, err
+		}
+
+		slog.InfoContext(ctx, "student promoted from waitlist", "id", student.Id, "group_number", group.GroupNumber,
+			"from_group_number", student.GroupNumber)
+		if student.GroupId != 0 {
+			left = append(left, student.GroupId)
+		}
+	}
+
+	return left, nil
+}
+
+// free returns the number of seats left in the group, an unlimited group
+// always has room for its whole waitlist
+func (s seats) free(ctx context.Context, group domain.Group) (int, error) {
+	if group.Capacity == 0 {
+		return math.MaxInt, nil
+	}
+
+	students, err := s.students.CountByGroupId(ctx, group.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(group.Capacity-int(students), 0), nil
+}
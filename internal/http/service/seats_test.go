@@ -0,0 +1,179 @@
+package service
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// concurrent creates into a full group take the seat check and the insert in
+// one transaction each, so the group is never overbooked
+func TestGroupCapacityConcurrentCreates(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			group := createTestGroups(t, services, ctx, dto.GroupDto{GroupNumber: "A-101", Capacity: 5})[0]
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			created, full := 0, 0
+			for i := 0; i < 30; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := services.Students.Create(ctx, dto.StudentDto{
+						FullName: "Ivan Ivanov", Age: 20, GroupNumber: group.GroupNumber,
+						Email: fmt.Sprintf("student%d@example.com", i)})
+
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						created++
+					case errors.Is(err, ErrGroupFull):
+						full++
+					default:
+						t.Errorf("create student: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if created != 5 || full != 25 {
+				t.Fatalf("created %d and refused %d students, want 5 and 25", created, full)
+			}
+			roster, err := services.Groups.GetRoster(ctx, group.Id, dto.StudentQuery{})
+			if err != nil || roster.Total != 5 {
+				t.Fatalf("get roster: %+v, %v", roster, err)
+			}
+		})
+	}
+}
+
+// seats freed at the same time go to the waiting students by priority, then
+// by the time they queued, each one to a single student
+func TestWaitlistPriorityPromotion(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			group := createTestGroups(t, services, ctx, dto.GroupDto{
+				GroupNumber: "A-101", Capacity: 2, Waitlist: domain.WaitlistPriority})[0]
+			seated := []domain.Student{
+				createTestStudent(t, services, ctx, "seated0@example.com", group.GroupNumber),
+				createTestStudent(t, services, ctx, "seated1@example.com", group.GroupNumber),
+			}
+
+			var waiting []int64
+			for i, priority := range []int{1, 7, 1} {
+				student := createTestStudent(t, services, ctx, fmt.Sprintf("waiting%d@example.com", i), "")
+				if _, err := services.Manager.PutOnWaitlist(ctx, group.Id, student.Id, priority); err != nil {
+					t.Fatalf("put on waitlist: %v", err)
+				}
+				waiting = append(waiting, student.Id)
+			}
+			if got := waitlistStudents(t, services, ctx, group.Id); !slices.Equal(got,
+				[]int64{waiting[1], waiting[0], waiting[2]}) {
+				t.Fatalf("waitlist %v, want %v", got, []int64{waiting[1], waiting[0], waiting[2]})
+			}
+			if _, err := services.Manager.AddStudentToGroup(ctx, group.Id, waiting[0]); !errors.Is(err, ErrGroupFull) {
+				t.Fatalf("add student to full group: %v, want %v", err, ErrGroupFull)
+			}
+
+			var wg sync.WaitGroup
+			for _, student := range seated {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := services.Students.DeleteById(ctx, student.Id); err != nil {
+						t.Errorf("delete student: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			roster, err := services.Groups.GetRoster(ctx, group.Id, dto.StudentQuery{})
+			if err != nil || roster.Total != 2 {
+				t.Fatalf("get roster: %+v, %v", roster, err)
+			}
+			if got := waitlistStudents(t, services, ctx, group.Id); !slices.Equal(got, []int64{waiting[2]}) {
+				t.Fatalf("waitlist %v, want %v", got, []int64{waiting[2]})
+			}
+
+			// a bigger group takes the rest of the queue, a smaller one than its students is refused
+			if _, err := services.Groups.Update(ctx, dto.GroupDto{
+				Id: group.Id, GroupNumber: group.GroupNumber, Capacity: 5, Waitlist: domain.WaitlistPriority}); err != nil {
+				t.Fatalf("raise capacity: %v", err)
+			}
+			if roster, err = services.Groups.GetRoster(ctx, group.Id, dto.StudentQuery{}); err != nil || roster.Total != 3 {
+				t.Fatalf("get roster: %+v, %v", roster, err)
+			}
+			if _, err := services.Groups.Update(ctx, dto.GroupDto{
+				Id: group.Id, GroupNumber: group.GroupNumber, Capacity: 2}); !errors.Is(err, ErrGroupOverCapacity) {
+				t.Fatalf("lower capacity: %v, want %v", err, ErrGroupOverCapacity)
+			}
+		})
+	}
+}
+
+// a promoted student leaves a seat in its old group, which goes to the first
+// student waiting for that one in the same transaction
+func TestWaitlistFIFOPromotionChain(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			groups := createTestGroups(t, services, ctx,
+				dto.GroupDto{GroupNumber: "A-101", Capacity: 1, Waitlist: domain.WaitlistFIFO},
+				dto.GroupDto{GroupNumber: "B-202", Capacity: 1, Waitlist: domain.WaitlistFIFO})
+			inA := createTestStudent(t, services, ctx, "a@example.com", groups[0].GroupNumber)
+			inB := createTestStudent(t, services, ctx, "b@example.com", groups[1].GroupNumber)
+			alone := createTestStudent(t, services, ctx, "alone@example.com", "")
+
+			if _, err := services.Manager.PutOnWaitlist(ctx, groups[1].Id, inA.Id, 0); err != nil {
+				t.Fatalf("put on waitlist of B: %v", err)
+			}
+			if _, err := services.Manager.PutOnWaitlist(ctx, groups[0].Id, alone.Id, 0); err != nil {
+				t.Fatalf("put on waitlist of A: %v", err)
+			}
+			if _, err := services.Manager.RemoveStudentFromGroup(ctx, groups[1].Id, inB.Id); err != nil {
+				t.Fatalf("remove student from B: %v", err)
+			}
+
+			for _, want := range []struct {
+				student     domain.Student
+				groupNumber string
+			}{{inA, groups[1].GroupNumber}, {alone, groups[0].GroupNumber}, {inB, ""}} {
+				student, err := services.Students.GetById(ctx, want.student.Id)
+				if err != nil || student.GroupNumber != want.groupNumber {
+					t.Fatalf("student %s: %+v, %v, want group %q", want.student.Email, student, err, want.groupNumber)
+				}
+			}
+			for _, group := range groups {
+				if got := waitlistStudents(t, services, ctx, group.Id); len(got) != 0 {
+					t.Fatalf("waitlist of %s: %v, want it empty", group.GroupNumber, got)
+				}
+			}
+		})
+	}
+}
+
+// waitlistStudents returns the ids of the waiting students in their order
+func waitlistStudents(t *testing.T, services *Services, ctx context.Context, groupId int64) []int64 {
+	t.Helper()
+
+	waitlist, err := services.Manager.GetWaitlist(ctx, groupId)
+	if err != nil {
+		t.Fatalf("get waitlist: %v", err)
+	}
+
+	ids := []int64{}
+	for _, entry := range waitlist.Entries {
+		ids = append(ids, entry.StudentId)
+	}
+
+	return ids
+}
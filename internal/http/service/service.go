@@ -1,18 +1,34 @@
 package service
 
 import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/auth"
 	"StudentManager/internal/domain"
 	"StudentManager/internal/dto"
 	"StudentManager/internal/repository"
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 )
 
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// StudentPatch computes the patched student from the current one. It runs
+// inside the service transaction and may be called more than once.
+type StudentPatch func(current domain.Student) (domain.Student, error)
+
+// GroupPatch is StudentPatch for groups
+type GroupPatch func(current domain.Group) (domain.Group, error)
+
 type StudentService interface {
 	Create(ctx context.Context, dto dto.StudentDto) (domain.Student, error)
-	GetAll(ctx context.Context) ([]domain.Student, error)
+	GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error)
 	GetById(ctx context.Context, id int64) (domain.Student, error)
 	Update(ctx context.Context, dto dto.StudentDto) (domain.Student, error)
+	Patch(ctx context.Context, id int64, patch StudentPatch) (domain.Student, error)
 	DeleteById(ctx context.Context, id int64) error
 	IsStudentExistsByEmail(ctx context.Context, email string) bool
 	IsStudentExistsById(ctx context.Context, id int64) bool
@@ -20,23 +36,129 @@ type StudentService interface {
 
 type GroupService interface {
 	Create(ctx context.Context, dto dto.GroupDto) (domain.Group, error)
-	GetAll(ctx context.Context) ([]domain.Group, error)
+	GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error)
 	GetById(ctx context.Context, id int64) (domain.Group, error)
+	// GetRoster returns the group with a page of the students the caller may see in it
+	GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error)
 	Update(ctx context.Context, dto dto.GroupDto) (domain.Group, error)
+	Patch(ctx context.Context, id int64, patch GroupPatch) (domain.Group, error)
 	DeleteById(ctx context.Context, id int64) error
 	IsGroupExistsByNumber(ctx context.Context, groupNumber string) bool
 	IsGroupExistsById(ctx context.Context, id int64) bool
 }
 
+// StudentManagerService moves students between groups and keeps the
+// waitlists of full groups, seats freed by a move go to the next student
+// waiting for them
+type StudentManagerService interface {
+	AddStudentToGroup(ctx context.Context, groupId, studentId int64) (dto.GroupRoster, error)
+	RemoveStudentFromGroup(ctx context.Context, groupId, studentId int64) (dto.GroupRoster, error)
+	TransferStudent(ctx context.Context, studentId, fromGroupId, toGroupId int64) (dto.GroupRoster, error)
+	GetWaitlist(ctx context.Context, groupId int64) (dto.GroupWaitlist, error)
+	PutOnWaitlist(ctx context.Context, groupId, studentId int64, priority int) (dto.GroupWaitlist, error)
+	RemoveFromWaitlist(ctx context.Context, groupId, studentId int64) (dto.GroupWaitlist, error)
+}
+
+// UserService manages the users kept in storage. It also checks passwords,
+// of those users and of the configured one, for basic auth and logins.
+type UserService interface {
+	auth.PasswordChecker
+	Create(ctx context.Context, dto dto.UserDto) (domain.User, error)
+	GetByUsername(ctx context.Context, username string) (domain.User, error)
+	SetRoles(ctx context.Context, username string, roles []string) (domain.User, error)
+	DeleteByUsername(ctx context.Context, username string) error
+	AddCurator(ctx context.Context, groupId int64, username string) error
+	RemoveCurator(ctx context.Context, groupId int64, username string) error
+}
+
+type TenantService interface {
+	Create(ctx context.Context, dto dto.TenantDto) (domain.Tenant, error)
+	GetAll(ctx context.Context) ([]domain.Tenant, error)
+	GetById(ctx context.Context, id string) (domain.Tenant, error)
+	Update(ctx context.Context, dto dto.TenantDto) (domain.Tenant, error)
+	DeleteById(ctx context.Context, id string) error
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+type CourseService interface {
+	Create(ctx context.Context, dto dto.CourseDto) (domain.Course, error)
+	GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error)
+	GetById(ctx context.Context, id int64) (domain.Course, error)
+	Update(ctx context.Context, dto dto.CourseDto) (domain.Course, error)
+	DeleteById(ctx context.Context, id int64) error
+}
+
+// EnrollmentService enrolls groups and single students into courses and
+// moves enrollments between the active, dropped and completed statuses
+type EnrollmentService interface {
+	// GetAll returns the course with a page of the enrollments the caller may see in it
+	GetAll(ctx context.Context, courseId int64, query dto.EnrollmentQuery) (dto.CourseEnrollments, error)
+	GetById(ctx context.Context, courseId, id int64) (domain.Enrollment, error)
+	Enroll(ctx context.Context, dto dto.EnrollmentDto) (domain.Enrollment, error)
+	SetStatus(ctx context.Context, courseId, id int64, status string) (domain.Enrollment, error)
+}
+
+type AuthService interface {
+	Login(ctx context.Context, user, password string) (dto.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (dto.TokenPair, error)
+	Revoke(ctx context.Context, refreshToken string) error
+}
+
 type Services struct {
-	Students StudentService
-	Groups   GroupService
+	Students    StudentService
+	Groups      GroupService
+	Manager     StudentManagerService
+	Users       UserService
+	Tenants     TenantService
+	Courses     CourseService
+	Enrollments EnrollmentService
+	// nil when token authentication is turned off
+	Auth AuthService
+}
+
+func NewServices(repositories *repository.Repositories, tokens *auth.Tokens,
+	credentials *auth.Credentials, deletePolicy DeletePolicy) *Services {
+	slog.Info("Services are created")
+	policy := NewPolicy(repositories.Access, repositories.Students, credentials.User())
+	services := &Services{
+		Students: tracedStudentService{
+			next: NewStudentServiceImpl(repositories.Students, repositories.Groups, repositories.Waitlists,
+				repositories.Tx, policy),
+		},
+		Groups: tracedGroupService{
+			next: NewGroupServiceImpl(repositories.Groups, repositories.Students, repositories.Waitlists,
+				repositories.Tx, policy, deletePolicy),
+		},
+		Manager: tracedStudentManagerService{
+			next: NewStudentManagerServiceImpl(repositories.Students, repositories.Groups, repositories.Waitlists,
+				repositories.Tx, policy),
+		},
+		Users: NewUserServiceImpl(repositories.Access, repositories.Students, repositories.Groups,
+			repositories.Tx, policy, credentials),
+		Tenants: NewTenantServiceImpl(repositories.Tenants, policy),
+		Courses: tracedCourseService{
+			next: NewCourseServiceImpl(repositories.Courses, repositories.Students, repositories.Groups, policy),
+		},
+		Enrollments: tracedEnrollmentService{
+			next: NewEnrollmentServiceImpl(repositories.Enrollments, repositories.Courses, repositories.Students,
+				repositories.Groups, repositories.Tx, policy),
+		},
+	}
+	if tokens != nil {
+		services.Auth = NewAuthServiceImpl(tokens, services.Users, repositories.RefreshTokens, repositories.Tx)
+	}
+
+	return services
 }
 
-func NewServices(repositories *repository.Repositories) *Services {
-	log.Printf("Services are created")
-	return &Services{
-		Students: NewStudentServiceImpl(repositories.Students, NewGroupServiceImpl(repositories.Groups)),
-		Groups:   NewGroupServiceImpl(repositories.Groups),
+func normalizePage(page dto.PageRequest) (dto.PageRequest, error) {
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if page.Limit < 0 || page.Limit > MaxPageLimit {
+		return page, apperror.Validation("invalid_query", fmt.Sprintf("limit must be between 1 and %d", MaxPageLimit),
+			apperror.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", MaxPageLimit)})
 	}
+
+	return page, nil
 }
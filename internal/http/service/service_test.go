@@ -0,0 +1,55 @@
+package service
+
+import (
+	"StudentManager/internal/auth"
+	"StudentManager/internal/config"
+	"StudentManager/internal/repository"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// testBackends are the storages the service tests run against: memory and a
+// migrated SQLite file, which serializes transactions for real
+var testBackends = []string{config.StorageMemory, config.DriverSQLite}
+
+// newTestServices returns services over empty storage of the backend and a
+// context of the configured admin
+func newTestServices(t *testing.T, backend string) (*Services, context.Context) {
+	t.Helper()
+
+	return newTestServicesOver(newTestRepositories(t, backend))
+}
+
+// newTestRepositories returns empty storage of the backend, for tests that
+// wrap a repository before building the services over it
+func newTestRepositories(t *testing.T, backend string) *repository.Repositories {
+	t.Helper()
+
+	cfg := &config.Config{Storage: config.StorageMemory}
+	if backend == config.DriverSQLite {
+		cfg = &config.Config{
+			Storage: config.StorageDatabase,
+			Database: config.Database{
+				Driver:      config.DriverSQLite,
+				Path:        filepath.Join(t.TempDir(), "test.db"),
+				AutoMigrate: true,
+			},
+		}
+	}
+
+	repositories, err := repository.NewRepositories(cfg)
+	if err != nil {
+		t.Fatalf("create repositories: %v", err)
+	}
+	t.Cleanup(repositories.Close)
+
+	return repositories
+}
+
+func newTestServicesOver(repositories *repository.Repositories) (*Services, context.Context) {
+	services := NewServices(repositories, nil, auth.NewCredentials("admin", "password"), DeletePolicy{})
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "admin", Method: auth.MethodBasic})
+
+	return services, ctx
+}
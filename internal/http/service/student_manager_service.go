@@ -0,0 +1,392 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+var (
+	ErrStudentInOtherGroup = apperror.Conflict("student_in_other_group",
+		"student is in another group, transfer it instead")
+	ErrStudentNotInGroup = apperror.NotFound("student_not_in_group", "student isn't in this group")
+	// a transfer was based on a roster that is out of date
+	ErrStudentNotInSourceGroup = apperror.Conflict("student_not_in_source_group",
+		"student isn't in the group it is transferred from")
+	ErrWaitlistDisabled      = apperror.Conflict("waitlist_disabled", "group has no waitlist")
+	ErrStudentAlreadyInGroup = apperror.Conflict("student_already_in_group", "student is in this group already")
+	// students only wait for full groups, otherwise they are added right away
+	ErrGroupHasSeats        = apperror.Conflict("group_has_seats", "group has free seats, add the student instead")
+	ErrStudentNotWaitlisted = apperror.NotFound("student_not_waitlisted", "student isn't waiting for this group")
+)
+
+// StudentManagerServiceImpl moves students between groups and manages the
+// waitlists of full groups. Every operation checks the group, its seats and
+// the student in one serializable transaction with the move, and answers with
+// the roster or the waitlist of the group as it is afterwards.
+type StudentManagerServiceImpl struct {
+	studentRepository  repository.StudentRepository
+	groupRepository    repository.GroupRepository
+	waitlistRepository repository.WaitlistRepository
+	txManager          repository.TxManager
+	policy             *Policy
+	seats              seats
+}
+
+func NewStudentManagerServiceImpl(studentRepo repository.StudentRepository, groupRepo repository.GroupRepository,
+	waitlistRepo repository.WaitlistRepository, txManager repository.TxManager,
+	policy *Policy) *StudentManagerServiceImpl {
+	return &StudentManagerServiceImpl{
+		studentRepository:  studentRepo,
+		groupRepository:    groupRepo,
+		waitlistRepository: waitlistRepo,
+		txManager:          txManager,
+		policy:             policy,
+		seats:              seats{students: studentRepo, groups: groupRepo, waitlists: waitlistRepo},
+	}
+}
+
+// AddStudentToGroup puts a student without a group into the group. Adding a
+// student that is already there changes nothing.
+func (manager *StudentManagerServiceImpl) AddStudentToGroup(ctx context.Context,
+	groupId, studentId int64) (dto.GroupRoster, error) {
+	grant, err := manager.policy.authorize(ctx, domain.PermissionStudentsWrite)
+	if err != nil {
+		return dto.GroupRoster{}, storageError(err)
+	}
+
+	var roster dto.GroupRoster
+	err = manager.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		group, err := manager.getGroup(ctx, grant, groupId)
+		if err != nil {
+			return err
+		}
+		student, err := manager.getStudent(ctx, grant, studentId)
+		if err != nil {
+			return err
+		}
+
+		switch student.GroupId {
+		case group.Id:
+		case 0:
+			if err := manager.seats.take(ctx, group.Id, student.Id); err != nil {
+				return err
+			}
+			if err := manager.move(ctx, student, group.Id); err != nil {
+				return err
+			}
+		default:
+			slog.InfoContext(ctx, "student is in another group", "id", studentId,
+				"group_number", student.GroupNumber)
+			return ErrStudentInOtherGroup
+		}
+
+		roster, err = manager.roster(ctx, group)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to add student to group", "err", err)
+		return dto.GroupRoster{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "student added to group", "id", studentId, "group_id", groupId)
+	return roster, nil
+}
+
+// RemoveStudentFromGroup leaves the student without a group, its seat goes to
+// the first student waiting for one
+func (manager *StudentManagerServiceImpl) RemoveStudentFromGroup(ctx context.Context,
+	groupId, studentId int64) (dto.GroupRoster, error) {
+	grant, err := manager.policy.authorize(ctx, domain.PermissionStudentsWrite)
+	if err != nil {
+		return dto.GroupRoster{}, storageError(err)
+	}
+
+	var roster dto.GroupRoster
+	err = manager.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		group, err := manager.getGroup(ctx, grant, groupId)
+		if err != nil {
+			return err
+		}
+		student, err := manager.getStudent(ctx, grant, studentId)
+		if err != nil {
+			return err
+		}
+		if student.GroupId != group.Id {
+			slog.InfoContext(ctx, "student isn't in the group", "id", studentId, "group_id", groupId)
+			return ErrStudentNotInGroup
+		}
+
+		if err := manager.move(ctx, student, 0); err != nil {
+			return err
+		}
+		if err := manager.seats.release(ctx, group.Id); err != nil {
+			return err
+		}
+
+		roster, err = manager.roster(ctx, group)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to remove student from group", "err", err)
+		return dto.GroupRoster{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "student removed from group", "id", studentId, "group_id", groupId)
+	return roster, nil
+}
+
+// TransferStudent moves a student from one group to another. The student has
+// to be in fromGroupId still, so a transfer based on a stale roster fails
+// instead of pulling the student out of a group it was moved to meanwhile.
+func (manager *StudentManagerServiceImpl) TransferStudent(ctx context.Context,
+	studentId, fromGroupId, toGroupId int64) (dto.GroupRoster, error) {
+	grant, err := manager.policy.authorize(ctx, domain.PermissionStudentsWrite)
+	if err != nil {
+		return dto.GroupRoster{}, storageError(err)
+	}
+
+	var roster dto.GroupRoster
+	err = manager.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		from, err := manager.getGroup(ctx, grant, fromGroupId)
+		if err != nil {
+			return err
+		}
+		to, err := manager.getGroup(ctx, grant, toGroupId)
+		if err != nil {
+			return err
+		}
+		student, err := manager.getStudent(ctx, grant, studentId)
+		if err != nil {
+			return err
+		}
+		if student.GroupId != from.Id {
+			slog.InfoContext(ctx, "student isn't in the group", "id", studentId, "group_id", fromGroupId)
+			return ErrStudentNotInSourceGroup
+		}
+
+		if from.Id != to.Id {
+			if err := manager.seats.take(ctx, to.Id, student.Id); err != nil {
+				return err
+			}
+			if err := manager.move(ctx, student, to.Id); err != nil {
+				return err
+			}
+			if err := manager.seats.release(ctx, from.Id); err != nil {
+				return err
+			}
+		}
+
+		roster, err = manager.roster(ctx, to)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to transfer student", "err", err)
+		return dto.GroupRoster{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "student transferred", "id", studentId, "from_group_id", fromGroupId,
+		"to_group_id", toGroupId)
+	return roster, nil
+}
+
+// GetWaitlist is for those who may read the students of the group
+func (manager *StudentManagerServiceImpl) GetWaitlist(ctx context.Context, groupId int64) (dto.GroupWaitlist, error) {
+	grant, err := manager.policy.authorize(ctx, domain.PermissionStudentsRead)
+	if err != nil {
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	group, err := manager.groupRepository.GetById(ctx, groupId)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && !grant.allowsGroup(group.GroupNumber) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return dto.GroupWaitlist{}, ErrGroupNotFound
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get group", "err", err)
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	waitlist, err := manager.waitlist(ctx, group)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get waitlist", "err", err)
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	slog.DebugContext(ctx, "received waitlist", "group", group, "count", len(waitlist.Entries))
+	return waitlist, nil
+}
+
+// PutOnWaitlist queues the student for a seat in the full group, or changes
+// the priority of a student already waiting there
+func (manager *StudentManagerServiceImpl) PutOnWaitlist(ctx context.Context,
+	groupId, studentId int64, priority int) (dto.GroupWaitlist, error) {
+	grant, err := manager.policy.authorize(ctx, domain.PermissionStudentsWrite)
+	if err != nil {
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	var waitlist dto.GroupWaitlist
+	err = manager.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		group, err := manager.getGroup(ctx, grant, groupId)
+		if err != nil {
+			return err
+		}
+		student, err := manager.getStudent(ctx, grant, studentId)
+		if err != nil {
+			return err
+		}
+		if group.Waitlist == "" {
+			slog.InfoContext(ctx, "group has no waitlist", "group_number", group.GroupNumber)
+			return ErrWaitlistDisabled
+		}
+		if student.GroupId == group.Id {
+			slog.InfoContext(ctx, "student is in the group already", "id", studentId, "group_id", groupId)
+			return ErrStudentAlreadyInGroup
+		}
+
+		queue, err := manager.waitlistRepository.GetByGroupId(ctx, group.Id, group.Waitlist)
+		if err != nil {
+			return err
+		}
+		waiting := slices.ContainsFunc(queue, func(entry domain.WaitlistEntry) bool {
+			return entry.StudentId == student.Id
+		})
+		if !waiting {
+			free, err := manager.seats.free(ctx, group)
+			if err != nil {
+				return err
+			}
+			if free > 0 {
+				slog.InfoContext(ctx, "group has free seats", "group_number", group.GroupNumber, "free", free)
+				return ErrGroupHasSeats
+			}
+		}
+
+		err = manager.waitlistRepository.Put(ctx, domain.WaitlistEntry{
+			GroupId:   group.Id,
+			StudentId: student.Id,
+			Priority:  priority,
+			QueuedAt:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		waitlist, err = manager.waitlist(ctx, group)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to put student on waitlist", "err", err)
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "student put on waitlist", "id", studentId, "group_id", groupId, "priority", priority)
+	return waitlist, nil
+}
+
+func (manager *StudentManagerServiceImpl) RemoveFromWaitlist(ctx context.Context,
+	groupId, studentId int64) (dto.GroupWaitlist, error) {
+	grant, err := manager.policy.authorize(ctx, domain.PermissionStudentsWrite)
+	if err != nil {
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	var waitlist dto.GroupWaitlist
+	err = manager.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		group, err := manager.getGroup(ctx, grant, groupId)
+		if err != nil {
+			return err
+		}
+		if _, err := manager.getStudent(ctx, grant, studentId); err != nil {
+			return err
+		}
+
+		err = manager.waitlistRepository.Remove(ctx, group.Id, studentId)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "student isn't waiting for the group", "id", studentId, "group_id", groupId)
+			return ErrStudentNotWaitlisted
+		}
+		if err != nil {
+			return err
+		}
+
+		waitlist, err = manager.waitlist(ctx, group)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to remove student from waitlist", "err", err)
+		return dto.GroupWaitlist{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "student removed from waitlist", "id", studentId, "group_id", groupId)
+	return waitlist, nil
+}
+
+func (manager *StudentManagerServiceImpl) getGroup(ctx context.Context, grant grant, id int64) (domain.Group, error) {
+	group, err := manager.groupRepository.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return domain.Group{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return domain.Group{}, err
+	}
+	if !grant.allowsGroup(group.GroupNumber) {
+		slog.InfoContext(ctx, "group is out of scope", "group_number", group.GroupNumber)
+		return domain.Group{}, ErrGroupOutOfScope
+	}
+
+	return group, nil
+}
+
+func (manager *StudentManagerServiceImpl) getStudent(ctx context.Context, grant grant, id int64) (domain.Student, error) {
+	student, err := manager.studentRepository.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "student doesn't exist")
+		return domain.Student{}, ErrStudentNotFound
+	}
+	if err != nil {
+		return domain.Student{}, err
+	}
+	if !grant.allowsStudent(student) {
+		slog.InfoContext(ctx, "student is out of scope", "id", student.Id)
+		return domain.Student{}, ErrStudentOutOfScope
+	}
+
+	return student, nil
+}
+
+// move puts the student into the group, a groupId of 0 leaves it without one
+func (manager *StudentManagerServiceImpl) move(ctx context.Context, student domain.Student, groupId int64) error {
+	_, err := manager.studentRepository.Patch(ctx, student.Id, dto.StudentChanges{GroupId: &groupId})
+
+	return err
+}
+
+// roster reads the group as it is now with the first page of its students,
+// the rest is paged through GET /groups/{Id}/students like any other list
+func (manager *StudentManagerServiceImpl) roster(ctx context.Context, group domain.Group) (dto.GroupRoster, error) {
+	page, err := normalizePage(dto.PageRequest{})
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+
+	return manager.groupRepository.GetRoster(ctx, group.Id, dto.StudentQuery{PageRequest: page})
+}
+
+// waitlist reads the queue of the group in the order of its waitlist mode
+func (manager *StudentManagerServiceImpl) waitlist(ctx context.Context, group domain.Group) (dto.GroupWaitlist, error) {
+	entries, err := manager.waitlistRepository.GetByGroupId(ctx, group.Id, group.Waitlist)
+	if err != nil {
+		return dto.GroupWaitlist{}, err
+	}
+
+	return dto.GroupWaitlist{Group: group, Entries: entries}, nil
+}
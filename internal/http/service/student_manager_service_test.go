@@ -0,0 +1,158 @@
+package service
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestStudentManagerMembership(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			groups := createTestGroups(t, services, ctx, dto.GroupDto{GroupNumber: "A-101"},
+				dto.GroupDto{GroupNumber: "B-202"})
+			student := createTestStudent(t, services, ctx, "ivan@example.com", "")
+
+			roster, err := services.Manager.AddStudentToGroup(ctx, groups[0].Id, student.Id)
+			if err != nil || roster.Total != 1 {
+				t.Fatalf("add student: %+v, %v", roster, err)
+			}
+			// adding it again changes nothing
+			if roster, err = services.Manager.AddStudentToGroup(ctx, groups[0].Id, student.Id); err != nil ||
+				roster.Total != 1 {
+				t.Fatalf("add student again: %+v, %v", roster, err)
+			}
+			if _, err := services.Manager.AddStudentToGroup(ctx, groups[1].Id, student.Id); !errors.Is(err,
+				ErrStudentInOtherGroup) {
+				t.Fatalf("add student of another group: %v, want %v", err, ErrStudentInOtherGroup)
+			}
+
+			roster, err = services.Manager.TransferStudent(ctx, student.Id, groups[0].Id, groups[1].Id)
+			if err != nil || roster.Total != 1 || roster.Group.Id != groups[1].Id {
+				t.Fatalf("transfer student: %+v, %v", roster, err)
+			}
+			if _, err := services.Manager.TransferStudent(ctx, student.Id, groups[0].Id,
+				groups[1].Id); !errors.Is(err, ErrStudentNotInSourceGroup) {
+				t.Fatalf("transfer from a stale group: %v, want %v", err, ErrStudentNotInSourceGroup)
+			}
+
+			if roster, err = services.Manager.RemoveStudentFromGroup(ctx, groups[1].Id, student.Id); err != nil ||
+				roster.Total != 0 {
+				t.Fatalf("remove student: %+v, %v", roster, err)
+			}
+			if _, err := services.Manager.RemoveStudentFromGroup(ctx, groups[1].Id, student.Id); !errors.Is(err,
+				ErrStudentNotInGroup) {
+				t.Fatalf("remove student again: %v, want %v", err, ErrStudentNotInGroup)
+			}
+		})
+	}
+}
+
+// the roster an operation answers with is paged like GET /groups/{Id}/students
+func TestStudentManagerRosterPage(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			group := createTestGroups(t, services, ctx, dto.GroupDto{GroupNumber: "A-101"})[0]
+			for i := 0; i < DefaultPageLimit; i++ {
+				createTestStudent(t, services, ctx, fmt.Sprintf("student%d@example.com", i), group.GroupNumber)
+			}
+			student := createTestStudent(t, services, ctx, "ivan@example.com", "")
+
+			roster, err := services.Manager.AddStudentToGroup(ctx, group.Id, student.Id)
+			if err != nil {
+				t.Fatalf("add student: %v", err)
+			}
+			if len(roster.Students) != DefaultPageLimit || roster.Total != DefaultPageLimit+1 ||
+				roster.NextCursor == "" {
+				t.Fatalf("roster has %d of %d students, next cursor %q, want the first %d and a cursor",
+					len(roster.Students), roster.Total, roster.NextCursor, DefaultPageLimit)
+			}
+		})
+	}
+}
+
+// concurrent transfers of one student out of the same group can't all see it
+// there, only one of them moves it
+func TestStudentManagerConcurrentTransfers(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			groups := createTestGroups(t, services, ctx, dto.GroupDto{GroupNumber: "A-101"},
+				dto.GroupDto{GroupNumber: "B-202"}, dto.GroupDto{GroupNumber: "C-303"})
+			student := createTestStudent(t, services, ctx, "ivan@example.com", groups[0].GroupNumber)
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			transferred := 0
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := services.Manager.TransferStudent(ctx, student.Id, groups[0].Id, groups[1+i%2].Id)
+
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						transferred++
+					case !errors.Is(err, ErrStudentNotInSourceGroup):
+						t.Errorf("transfer student: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if transferred != 1 {
+				t.Fatalf("transferred %d times, want 1", transferred)
+			}
+			total := int64(0)
+			for _, group := range groups {
+				roster, err := services.Groups.GetRoster(ctx, group.Id, dto.StudentQuery{})
+				if err != nil {
+					t.Fatalf("get roster: %v", err)
+				}
+				total += roster.Total
+			}
+			if total != 1 {
+				t.Fatalf("student is in %d groups, want 1", total)
+			}
+		})
+	}
+}
+
+func createTestGroups(t *testing.T, services *Services, ctx context.Context,
+	groupDtos ...dto.GroupDto) []domain.Group {
+	t.Helper()
+
+	var groups []domain.Group
+	for _, groupDto := range groupDtos {
+		group, err := services.Groups.Create(ctx, groupDto)
+		if err != nil {
+			t.Fatalf("create group %s: %v", groupDto.GroupNumber, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// createTestStudent creates a student in the group, or without one for an
+// empty groupNumber
+func createTestStudent(t *testing.T, services *Services, ctx context.Context, email,
+	groupNumber string) domain.Student {
+	t.Helper()
+
+	student, err := services.Students.Create(ctx, dto.StudentDto{
+		FullName: "Ivan Ivanov", Age: 20, GroupNumber: groupNumber, Email: email})
+	if err != nil {
+		t.Fatalf("create student %s: %v", email, err)
+	}
+
+	return student
+}
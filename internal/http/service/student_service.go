@@ -3,28 +3,29 @@ package service
 import (
 	"StudentManager/internal/domain"
 	"StudentManager/internal/dto"
+	"StudentManager/internal/metrics"
 	"StudentManager/internal/repository"
 	"context"
-	"database/sql"
 	"errors"
-	"github.com/jackc/pgx/v4"
-	_ "github.com/jackc/pgx/v4"
-	"log"
+	"log/slog"
 )
 
-type GetStudentRequest struct {
-	FullName string `json:"full_name" env-required:"true"`
-}
-
 type StudentServiceImpl struct {
 	studentRepository repository.StudentRepository
-	groupService      GroupService
+	groupRepository   repository.GroupRepository
+	txManager         repository.TxManager
+	policy            *Policy
+	seats             seats
 }
 
-func NewStudentServiceImpl(repo repository.StudentRepository, service GroupService) *StudentServiceImpl {
+func NewStudentServiceImpl(repo repository.StudentRepository, groupRepo repository.GroupRepository,
+	waitlistRepo repository.WaitlistRepository, txManager repository.TxManager, policy *Policy) *StudentServiceImpl {
 	return &StudentServiceImpl{
 		studentRepository: repo,
-		groupService:      service,
+		groupRepository:   groupRepo,
+		txManager:         txManager,
+		policy:            policy,
+		seats:             seats{students: repo, groups: groupRepo, waitlists: waitlistRepo},
 	}
 }
 
@@ -33,7 +34,6 @@ func (studentService *StudentServiceImpl) Create(
 	dto dto.StudentDto,
 ) (domain.Student, error) {
 	repo := studentService.studentRepository
-	groupService := studentService.groupService
 
 	student := domain.Student{
 		FullName:    dto.FullName,
@@ -41,70 +41,105 @@ func (studentService *StudentServiceImpl) Create(
 		GroupNumber: dto.GroupNumber,
 		Email:       dto.Email,
 	}
-	if studentService.IsStudentExistsByEmail(ctx, student.Email) {
-		log.Println("student already exists")
-		return domain.Student{}, errors.New("student already exists")
-	}
 
-	if !groupService.IsGroupExistsByNumber(ctx, student.GroupNumber) {
-		log.Println("group doesn't exist")
-		return domain.Student{}, errors.New("group doesn't exist")
-	}
-
-	studentRow, err := repo.Create(ctx, student)
+	grant, err := studentService.policy.authorize(ctx, domain.PermissionStudentsWrite)
 	if err != nil {
-		log.Printf("failed to create student %v", err)
-		return domain.Student{}, err
+		return domain.Student{}, storageError(err)
+	}
+	if !grant.allowsGroup(student.GroupNumber) {
+		slog.InfoContext(ctx, "group is out of scope", "group_number", student.GroupNumber)
+		return domain.Student{}, ErrGroupOutOfScope
 	}
 
-	createdStudent, err := convertStudentsRowsToDomain(studentRow)
+	var createdStudent domain.Student
+	err = studentService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		if err := studentService.checkEmailIsFree(ctx, student.Email, 0); err != nil {
+			return err
+		}
+		var err error
+		if student.GroupId, err = studentService.groupIdByNumber(ctx, student.GroupNumber); err != nil {
+			return err
+		}
+		if err := studentService.seats.take(ctx, student.GroupId, 0); err != nil {
+			return err
+		}
+
+		createdStudent, err = repo.Create(ctx, student)
+		if errors.Is(err, repository.ErrConflict) {
+			slog.InfoContext(ctx, "student already exists")
+			return ErrStudentEmailTaken
+		}
+		if errors.Is(err, repository.ErrReferenceNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist")
+			return ErrStudentGroupNotFound
+		}
+
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to convert student into domain %v", err)
-		return domain.Student{}, err
+		slog.WarnContext(ctx, "failed to create student", "err", err)
+		return domain.Student{}, storageError(err)
 	}
 
-	log.Printf("created student: %v", student)
-	return createdStudent[0], err
+	metrics.StudentsCreated.Inc()
+	slog.InfoContext(ctx, "created student", "student", createdStudent)
+	return createdStudent, nil
 }
 
-func (studentService *StudentServiceImpl) GetAll(ctx context.Context) ([]domain.Student, error) {
+func (studentService *StudentServiceImpl) GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error) {
 	service := studentService.studentRepository
 
-	rows, err := service.GetAll(ctx)
+	var err error
+	query.PageRequest, err = normalizePage(query.PageRequest)
 	if err != nil {
-		log.Printf("failed to get students %v", err)
-		return []domain.Student{}, err
+		return dto.StudentPage{}, err
 	}
 
-	students, err := convertStudentsRowsToDomain(rows)
+	grant, err := studentService.policy.authorize(ctx, domain.PermissionStudentsRead)
 	if err != nil {
-		log.Printf("failed to convert students into domain %v", err)
+		return dto.StudentPage{}, storageError(err)
+	}
+	if !grant.sees() {
+		return dto.StudentPage{Students: []domain.Student{}}, nil
+	}
+	grant.limitStudents(&query.StudentFilter)
 
+	page, err := service.GetAll(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get students", "err", err)
+		return dto.StudentPage{}, storageError(err)
 	}
 
-	log.Println("received all students")
-	return students, nil
+	slog.DebugContext(ctx, "received students", "count", len(page.Students), "total", page.Total)
+	return page, nil
 }
 
 func (studentService *StudentServiceImpl) GetById(ctx context.Context, id int64) (domain.Student, error) {
 	service := studentService.studentRepository
 
-	row := service.GetById(ctx, id)
-
-	if errors.Is(row.Scan(), sql.ErrNoRows) {
-		log.Println("student doesn't exist")
-		return domain.Student{}, errors.New("student doesn't exist")
+	grant, err := studentService.policy.authorize(ctx, domain.PermissionStudentsRead)
+	if err != nil {
+		return domain.Student{}, storageError(err)
 	}
 
-	students, err := convertStudentRowToDomain(row)
+	student, err := service.GetById(ctx, id)
+	// students the caller may not see don't exist for them
+	if errors.Is(err, repository.ErrNotFound) || err == nil && !grant.allowsStudent(student) {
+		slog.InfoContext(ctx, "student doesn't exist")
+		return domain.Student{}, ErrStudentNotFound
+	}
 	if err != nil {
-		log.Printf("failed to convert students into domain %v", err)
+		slog.WarnContext(ctx, "failed to get student", "err", err)
+		return domain.Student{}, storageError(err)
 	}
 
-	log.Printf("received student by id: %v", students)
-	return students, nil
+	slog.DebugContext(ctx, "received student", "student", student)
+	return student, nil
 }
 
+// Update also transfers the student when the group number changes, the target
+// group and its free seats are checked in the same transaction as the update
+// itself. The seat the student leaves goes to the waitlist of its old group.
 func (studentService *StudentServiceImpl) Update(ctx context.Context,
 	studentDto dto.StudentDto) (domain.Student, error) {
 	repo := studentService.studentRepository
@@ -117,88 +152,258 @@ func (studentService *StudentServiceImpl) Update(ctx context.Context,
 		Email:       studentDto.Email,
 	}
 
-	if !studentService.IsStudentExistsById(ctx, student.Id) {
-		log.Println("student doesn't exists")
-		return domain.Student{}, errors.New("student doesn't exists")
-	}
-
-	studentRow, err := repo.Update(ctx, student)
+	grant, err := studentService.policy.authorize(ctx, domain.PermissionStudentsWrite)
 	if err != nil {
-		log.Printf("failed to update student %v", err)
-		return domain.Student{}, err
+		return domain.Student{}, storageError(err)
 	}
 
-	updatedStudent, err := convertStudentsRowsToDomain(studentRow)
+	var updatedStudent domain.Student
+	err = studentService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		current, err := repo.GetById(ctx, student.Id)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "student doesn't exist")
+			return ErrStudentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkStudentInScope(ctx, grant, current, student.GroupNumber); err != nil {
+			return err
+		}
+
+		if current.Email != student.Email {
+			if err := studentService.checkEmailIsFree(ctx, student.Email, student.Id); err != nil {
+				return err
+			}
+		}
+		student.GroupId = current.GroupId
+		if current.GroupNumber != student.GroupNumber {
+			if student.GroupId, err = studentService.groupIdByNumber(ctx, student.GroupNumber); err != nil {
+				return err
+			}
+			if err := studentService.seats.take(ctx, student.GroupId, student.Id); err != nil {
+				return err
+			}
+		}
+
+		updatedStudent, err = repo.Update(ctx, student)
+		if errors.Is(err, repository.ErrConflict) {
+			slog.InfoContext(ctx, "student already exists")
+			return ErrStudentEmailTaken
+		}
+		if errors.Is(err, repository.ErrReferenceNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist")
+			return ErrStudentGroupNotFound
+		}
+		if err != nil || current.GroupId == updatedStudent.GroupId {
+			return err
+		}
+
+		return studentService.seats.release(ctx, current.GroupId)
+	})
 	if err != nil {
-		log.Printf("failed to convert student into domain %v", err)
-		return domain.Student{}, err
+		slog.WarnContext(ctx, "failed to update student", "err", err)
+		return domain.Student{}, storageError(err)
 	}
 
-	log.Printf("student updated: %v", updatedStudent)
-	return updatedStudent[0], err
+	slog.InfoContext(ctx, "student updated", "student", updatedStudent)
+	return updatedStudent, nil
 }
 
-func (studentService *StudentServiceImpl) DeleteById(ctx context.Context, id int64) error {
+func (studentService *StudentServiceImpl) Patch(ctx context.Context, id int64,
+	patch StudentPatch) (domain.Student, error) {
 	repo := studentService.studentRepository
 
-	if !studentService.IsStudentExistsById(ctx, id) {
-		log.Println("student doesn't exist")
-		return errors.New("student does not exist")
-	}
-	err := repo.DeleteById(ctx, id)
+	grant, err := studentService.policy.authorize(ctx, domain.PermissionStudentsWrite)
 	if err != nil {
-		log.Printf("failed to delete student %v", err)
+		return domain.Student{}, storageError(err)
 	}
 
-	log.Printf("student deleted with id: %v", id)
-	return nil
-}
+	var patchedStudent domain.Student
+	err = studentService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		current, err := repo.GetById(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "student doesn't exist")
+			return ErrStudentNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		patched, err := patch(current)
+		if err != nil {
+			return err
+		}
+		if err := checkStudentInScope(ctx, grant, current, patched.GroupNumber); err != nil {
+			return err
+		}
+		// patches address the group by number, the id only follows a changed one
+		patched.GroupId = current.GroupId
+		if patched.GroupNumber != current.GroupNumber {
+			if patched.GroupId, err = studentService.groupIdByNumber(ctx, patched.GroupNumber); err != nil {
+				return err
+			}
+			if err := studentService.seats.take(ctx, patched.GroupId, id); err != nil {
+				return err
+			}
+		}
+
+		changes := studentChanges(current, patched)
+		if changes.IsEmpty() {
+			patchedStudent = current
+			return nil
+		}
 
-func convertStudentRowToDomain(row pgx.Row) (domain.Student, error) {
-	var student domain.Student
+		if changes.Email != nil {
+			if err := studentService.checkEmailIsFree(ctx, *changes.Email, id); err != nil {
+				return err
+			}
+		}
 
-	err := row.Scan(&student.Id, &student.FullName, &student.Age, &student.GroupNumber, &student.Email)
+		patchedStudent, err = repo.Patch(ctx, id, changes)
+		if errors.Is(err, repository.ErrConflict) {
+			slog.InfoContext(ctx, "student already exists")
+			return ErrStudentEmailTaken
+		}
+		if errors.Is(err, repository.ErrReferenceNotFound) {
+			slog.InfoContext(ctx, "group doesn't exist")
+			return ErrStudentGroupNotFound
+		}
+		if err != nil || changes.GroupId == nil {
+			return err
+		}
 
+		return studentService.seats.release(ctx, current.GroupId)
+	})
 	if err != nil {
-		return domain.Student{}, err
+		slog.WarnContext(ctx, "failed to patch student", "err", err)
+		return domain.Student{}, storageError(err)
 	}
 
-	log.Println("successfully converted student row to domain")
-	return student, err
+	slog.InfoContext(ctx, "student patched", "student", patchedStudent)
+	return patchedStudent, nil
 }
 
-func convertStudentsRowsToDomain(rows pgx.Rows) ([]domain.Student, error) {
-	var students []domain.Student
+func (studentService *StudentServiceImpl) DeleteById(ctx context.Context, id int64) error {
+	repo := studentService.studentRepository
+
+	grant, err := studentService.policy.authorize(ctx, domain.PermissionStudentsWrite)
+	if err != nil {
+		return storageError(err)
+	}
 
-	for rows.Next() {
-		var r domain.Student
-		err := rows.Scan(&r.Id, &r.FullName, &r.Age, &r.GroupNumber, &r.Email)
+	err = studentService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		current, err := repo.GetById(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "student doesn't exist")
+			return ErrStudentNotFound
+		}
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := checkStudentInScope(ctx, grant, current, current.GroupNumber); err != nil {
+			return err
 		}
-		students = append(students, r)
+
+		if err := repo.DeleteById(ctx, id); err != nil {
+			return err
+		}
+
+		return studentService.seats.release(ctx, current.GroupId)
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to delete student", "err", err)
+		return storageError(err)
 	}
 
-	log.Println("successfully converted students rows to domain")
-	return students, nil
+	metrics.StudentsDeleted.Inc()
+	slog.InfoContext(ctx, "student deleted", "id", id)
+	return nil
 }
 
 func (studentService *StudentServiceImpl) IsStudentExistsByEmail(ctx context.Context, email string) bool {
 	service := studentService.studentRepository
 
-	if errors.Is(service.GetByEmail(ctx, email).Scan(), pgx.ErrNoRows) {
-		return false
-	}
+	_, err := service.GetByEmail(ctx, email)
 
-	return true
+	return !errors.Is(err, repository.ErrNotFound)
 }
 
 func (studentService *StudentServiceImpl) IsStudentExistsById(ctx context.Context, id int64) bool {
 	service := studentService.studentRepository
 
-	if errors.Is(service.GetById(ctx, id).Scan(), pgx.ErrNoRows) {
-		return false
+	_, err := service.GetById(ctx, id)
+
+	return !errors.Is(err, repository.ErrNotFound)
+}
+
+func (studentService *StudentServiceImpl) checkEmailIsFree(ctx context.Context, email string, exceptId int64) error {
+	student, err := studentService.studentRepository.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if student.Id == exceptId {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "student already exists")
+	return ErrStudentEmailTaken
+}
+
+// groupIdByNumber finds the group a student refers to, an empty number means
+// no group
+func (studentService *StudentServiceImpl) groupIdByNumber(ctx context.Context, groupNumber string) (int64, error) {
+	if groupNumber == "" {
+		return 0, nil
+	}
+
+	group, err := studentService.groupRepository.GetByGroupNumber(ctx, groupNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return 0, ErrStudentGroupNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return group.Id, nil
+}
+
+// checkStudentInScope lets a caller change a student only when both the
+// student and the group it ends up in are within their grant, so a curator
+// can neither edit other groups nor move students out of theirs
+func checkStudentInScope(ctx context.Context, grant grant, current domain.Student, groupNumber string) error {
+	if !grant.allowsStudent(current) {
+		slog.InfoContext(ctx, "student is out of scope", "id", current.Id)
+		return ErrStudentOutOfScope
+	}
+	if !grant.allowsGroup(groupNumber) {
+		slog.InfoContext(ctx, "group is out of scope", "group_number", groupNumber)
+		return ErrGroupOutOfScope
+	}
+
+	return nil
+}
+
+// studentChanges keeps only the fields that differ between current and patched
+func studentChanges(current, patched domain.Student) dto.StudentChanges {
+	var changes dto.StudentChanges
+
+	if patched.FullName != current.FullName {
+		changes.FullName = &patched.FullName
+	}
+	if patched.Age != current.Age {
+		changes.Age = &patched.Age
+	}
+	if patched.GroupId != current.GroupId {
+		changes.GroupId = &patched.GroupId
+	}
+	if patched.Email != current.Email {
+		changes.Email = &patched.Email
 	}
 
-	return true
+	return changes
 }
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// concurrent creates with the same email check and insert in one transaction
+// each, so exactly one of them wins
+func TestStudentCreateConcurrentEmail(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+
+			group, err := services.Groups.Create(ctx, dto.GroupDto{GroupNumber: "A-101"})
+			if err != nil {
+				t.Fatalf("create group: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			created := 0
+			for i := 0; i < 30; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := services.Students.Create(ctx, dto.StudentDto{
+						FullName: "Ivan Ivanov", Age: 20, GroupNumber: group.GroupNumber, Email: "ivan@example.com"})
+
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						created++
+					case !errors.Is(err, ErrStudentEmailTaken):
+						t.Errorf("create student: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if created != 1 {
+				t.Fatalf("created %d students, want 1", created)
+			}
+			if err := services.Groups.DeleteById(ctx, group.Id); !errors.Is(err, ErrGroupNotEmpty) {
+				t.Fatalf("delete group with a student: %v, want %v", err, ErrGroupNotEmpty)
+			}
+		})
+	}
+}
+
+// a patch that leaves the group number alone keeps the student in its group,
+// patches never carry the group id
+func TestStudentPatchKeepsGroup(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			group := createTestGroups(t, services, ctx, dto.GroupDto{GroupNumber: "A-101", Capacity: 1})[0]
+			student := createTestStudent(t, services, ctx, "ivan@example.com", group.GroupNumber)
+
+			patched, err := services.Students.Patch(ctx, student.Id, func(current domain.Student) (domain.Student, error) {
+				return domain.Student{
+					Id:          current.Id,
+					FullName:    current.FullName,
+					Age:         current.Age + 1,
+					GroupNumber: current.GroupNumber,
+					Email:       current.Email,
+				}, nil
+			})
+			if err != nil || patched.Age != student.Age+1 || patched.GroupNumber != group.GroupNumber {
+				t.Fatalf("patch student: %+v, %v", patched, err)
+			}
+
+			roster, err := services.Groups.GetRoster(ctx, group.Id, dto.StudentQuery{})
+			if err != nil || roster.Total != 1 {
+				t.Fatalf("get roster: %+v, %v", roster, err)
+			}
+			// the student still holds the only seat
+			if _, err := services.Students.Create(ctx, dto.StudentDto{FullName: "Petr Petrov", Age: 20,
+				GroupNumber: group.GroupNumber, Email: "petr@example.com"}); !errors.Is(err, ErrGroupFull) {
+				t.Fatalf("create student in full group: %v, want %v", err, ErrGroupFull)
+			}
+		})
+	}
+}
+
+// an empty group number takes the student out of its group, its seat goes to
+// the waitlist, and a student without a group can still be edited
+func TestStudentUpdateWithoutGroup(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			services, ctx := newTestServices(t, backend)
+			group := createTestGroups(t, services, ctx, dto.GroupDto{
+				GroupNumber: "A-101", Capacity: 1, Waitlist: domain.WaitlistFIFO})[0]
+			student := createTestStudent(t, services, ctx, "ivan@example.com", group.GroupNumber)
+			waiting := createTestStudent(t, services, ctx, "petr@example.com", "")
+			if _, err := services.Manager.PutOnWaitlist(ctx, group.Id, waiting.Id, 0); err != nil {
+				t.Fatalf("put on waitlist: %v", err)
+			}
+
+			updated, err := services.Students.Update(ctx, dto.StudentDto{
+				Id: student.Id, FullName: student.FullName, Age: student.Age, Email: student.Email})
+			if err != nil || updated.GroupId != 0 || updated.GroupNumber != "" {
+				t.Fatalf("update student: %+v, %v", updated, err)
+			}
+			if promoted, err := services.Students.GetById(ctx, waiting.Id); err != nil ||
+				promoted.GroupNumber != group.GroupNumber {
+				t.Fatalf("waiting student: %+v, %v", promoted, err)
+			}
+
+			patched, err := services.Students.Patch(ctx, student.Id, func(current domain.Student) (domain.Student, error) {
+				current.Age++
+				return current, nil
+			})
+			if err != nil || patched.Age != student.Age+1 || patched.GroupNumber != "" {
+				t.Fatalf("patch student without group: %+v, %v", patched, err)
+			}
+		})
+	}
+}
+
+// vanishingGroupStudents loses the group of every student it writes, as if
+// the group was deleted between the service looking it up and the write
+type vanishingGroupStudents struct {
+	repository.StudentRepository
+}
+
+const vanishedGroupId = 42
+
+func (repo vanishingGroupStudents) Create(ctx context.Context, student domain.Student) (domain.Student, error) {
+	if student.GroupId != 0 {
+		student.GroupId = vanishedGroupId
+	}
+
+	return repo.StudentRepository.Create(ctx, student)
+}
+
+func (repo vanishingGroupStudents) Update(ctx context.Context, student domain.Student) (domain.Student, error) {
+	if student.GroupId != 0 {
+		student.GroupId = vanishedGroupId
+	}
+
+	return repo.StudentRepository.Update(ctx, student)
+}
+
+func (repo vanishingGroupStudents) Patch(ctx context.Context, id int64,
+	changes dto.StudentChanges) (domain.Student, error) {
+	if changes.GroupId != nil && *changes.GroupId != 0 {
+		missing := int64(vanishedGroupId)
+		changes.GroupId = &missing
+	}
+
+	return repo.StudentRepository.Patch(ctx, id, changes)
+}
+
+// a group that is gone by the time the student is written is reported like
+// one that never existed, not as a taken email
+func TestStudentWriteToVanishedGroup(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			repositories := newTestRepositories(t, backend)
+			repositories.Students = vanishingGroupStudents{StudentRepository: repositories.Students}
+			services, ctx := newTestServicesOver(repositories)
+			group := createTestGroups(t, services, ctx, dto.GroupDto{GroupNumber: "A-101"})[0]
+
+			if _, err := services.Students.Create(ctx, dto.StudentDto{FullName: "Ivan Ivanov", Age: 20,
+				GroupNumber: group.GroupNumber, Email: "ivan@example.com"}); !errors.Is(err, ErrStudentGroupNotFound) {
+				t.Fatalf("create student: %v, want %v", err, ErrStudentGroupNotFound)
+			}
+
+			student := createTestStudent(t, services, ctx, "petr@example.com", "")
+			if _, err := services.Students.Update(ctx, dto.StudentDto{Id: student.Id, FullName: student.FullName,
+				Age: student.Age, GroupNumber: group.GroupNumber, Email: student.Email}); !errors.Is(err,
+				ErrStudentGroupNotFound) {
+				t.Fatalf("update student: %v, want %v", err, ErrStudentGroupNotFound)
+			}
+			if _, err := services.Students.Patch(ctx, student.Id, func(current domain.Student) (domain.Student, error) {
+				current.GroupNumber = group.GroupNumber
+				return current, nil
+			}); !errors.Is(err, ErrStudentGroupNotFound) {
+				t.Fatalf("patch student: %v, want %v", err, ErrStudentGroupNotFound)
+			}
+		})
+	}
+}
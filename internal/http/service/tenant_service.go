@@ -0,0 +1,146 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrTenantNotFound = apperror.NotFound("tenant_not_found", "tenant doesn't exist")
+	ErrTenantIdTaken  = apperror.Conflict("tenant_id_taken", "tenant with this id already exists")
+	ErrTenantNotEmpty = apperror.Conflict("tenant_not_empty", "tenant still has groups, students, users or courses")
+)
+
+type TenantServiceImpl struct {
+	repo   repository.TenantRepository
+	policy *Policy
+}
+
+func NewTenantServiceImpl(repo repository.TenantRepository, policy *Policy) *TenantServiceImpl {
+	return &TenantServiceImpl{
+		repo:   repo,
+		policy: policy,
+	}
+}
+
+func (tenantService *TenantServiceImpl) Create(ctx context.Context, tenantDto dto.TenantDto) (domain.Tenant, error) {
+	if _, err := tenantService.policy.authorize(ctx, domain.PermissionTenantsManage); err != nil {
+		return domain.Tenant{}, storageError(err)
+	}
+
+	tenant := domain.Tenant{
+		Id:        tenantDto.Id,
+		Name:      tenantDto.Name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	createdTenant, err := tenantService.repo.Create(ctx, tenant)
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "tenant already exists")
+		return domain.Tenant{}, ErrTenantIdTaken
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to create tenant", "err", err)
+		return domain.Tenant{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "created tenant", "tenant", createdTenant)
+	return createdTenant, nil
+}
+
+func (tenantService *TenantServiceImpl) GetAll(ctx context.Context) ([]domain.Tenant, error) {
+	if _, err := tenantService.policy.authorize(ctx, domain.PermissionTenantsManage); err != nil {
+		return nil, storageError(err)
+	}
+
+	tenants, err := tenantService.repo.GetAll(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get tenants", "err", err)
+		return nil, storageError(err)
+	}
+
+	return tenants, nil
+}
+
+func (tenantService *TenantServiceImpl) GetById(ctx context.Context, id string) (domain.Tenant, error) {
+	if _, err := tenantService.policy.authorize(ctx, domain.PermissionTenantsManage); err != nil {
+		return domain.Tenant{}, storageError(err)
+	}
+
+	tenant, err := tenantService.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "tenant doesn't exist")
+		return domain.Tenant{}, ErrTenantNotFound
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get tenant", "err", err)
+		return domain.Tenant{}, storageError(err)
+	}
+
+	return tenant, nil
+}
+
+func (tenantService *TenantServiceImpl) Update(ctx context.Context, tenantDto dto.TenantDto) (domain.Tenant, error) {
+	if _, err := tenantService.policy.authorize(ctx, domain.PermissionTenantsManage); err != nil {
+		return domain.Tenant{}, storageError(err)
+	}
+
+	updatedTenant, err := tenantService.repo.Update(ctx, domain.Tenant{Id: tenantDto.Id, Name: tenantDto.Name})
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "tenant doesn't exist")
+		return domain.Tenant{}, ErrTenantNotFound
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to update tenant", "err", err)
+		return domain.Tenant{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "updated tenant", "tenant", updatedTenant)
+	return updatedTenant, nil
+}
+
+// DeleteById only deletes empty tenants, their groups, students and users
+// have to be deleted first
+func (tenantService *TenantServiceImpl) DeleteById(ctx context.Context, id string) error {
+	if _, err := tenantService.policy.authorize(ctx, domain.PermissionTenantsManage); err != nil {
+		return storageError(err)
+	}
+
+	err := tenantService.repo.DeleteById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "tenant doesn't exist")
+		return ErrTenantNotFound
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		slog.InfoContext(ctx, "tenant isn't empty")
+		return ErrTenantNotEmpty
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to delete tenant", "err", err)
+		return storageError(err)
+	}
+
+	slog.InfoContext(ctx, "deleted tenant", "id", id)
+	return nil
+}
+
+// Exists isn't authorized, it is asked before the caller is known to
+// belong to the tenant
+func (tenantService *TenantServiceImpl) Exists(ctx context.Context, id string) (bool, error) {
+	_, err := tenantService.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get tenant", "err", err)
+		return false, storageError(err)
+	}
+
+	return true, nil
+}
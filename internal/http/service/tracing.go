@@ -0,0 +1,303 @@
+package service
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tracing"
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracedStudentService records a span for every StudentService call, its
+// statements show up as children
+type tracedStudentService struct {
+	next StudentService
+}
+
+func (s tracedStudentService) Create(ctx context.Context, dto dto.StudentDto) (domain.Student, error) {
+	ctx, span := tracing.Start(ctx, "StudentService.Create")
+	student, err := s.next.Create(ctx, dto)
+	tracing.End(span, err)
+
+	return student, err
+}
+
+func (s tracedStudentService) GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error) {
+	ctx, span := tracing.Start(ctx, "StudentService.GetAll")
+	page, err := s.next.GetAll(ctx, query)
+	tracing.End(span, err)
+
+	return page, err
+}
+
+func (s tracedStudentService) GetById(ctx context.Context, id int64) (domain.Student, error) {
+	ctx, span := tracing.Start(ctx, "StudentService.GetById", attribute.Int64("student.id", id))
+	student, err := s.next.GetById(ctx, id)
+	tracing.End(span, err)
+
+	return student, err
+}
+
+func (s tracedStudentService) Update(ctx context.Context, dto dto.StudentDto) (domain.Student, error) {
+	ctx, span := tracing.Start(ctx, "StudentService.Update", attribute.Int64("student.id", dto.Id))
+	student, err := s.next.Update(ctx, dto)
+	tracing.End(span, err)
+
+	return student, err
+}
+
+func (s tracedStudentService) Patch(ctx context.Context, id int64, patch StudentPatch) (domain.Student, error) {
+	ctx, span := tracing.Start(ctx, "StudentService.Patch", attribute.Int64("student.id", id))
+	student, err := s.next.Patch(ctx, id, patch)
+	tracing.End(span, err)
+
+	return student, err
+}
+
+func (s tracedStudentService) DeleteById(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "StudentService.DeleteById", attribute.Int64("student.id", id))
+	err := s.next.DeleteById(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s tracedStudentService) IsStudentExistsByEmail(ctx context.Context, email string) bool {
+	ctx, span := tracing.Start(ctx, "StudentService.IsStudentExistsByEmail")
+	defer span.End()
+
+	return s.next.IsStudentExistsByEmail(ctx, email)
+}
+
+func (s tracedStudentService) IsStudentExistsById(ctx context.Context, id int64) bool {
+	ctx, span := tracing.Start(ctx, "StudentService.IsStudentExistsById", attribute.Int64("student.id", id))
+	defer span.End()
+
+	return s.next.IsStudentExistsById(ctx, id)
+}
+
+// tracedGroupService is tracedStudentService for groups
+type tracedGroupService struct {
+	next GroupService
+}
+
+func (s tracedGroupService) Create(ctx context.Context, dto dto.GroupDto) (domain.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Create")
+	group, err := s.next.Create(ctx, dto)
+	tracing.End(span, err)
+
+	return group, err
+}
+
+func (s tracedGroupService) GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.GetAll")
+	page, err := s.next.GetAll(ctx, query)
+	tracing.End(span, err)
+
+	return page, err
+}
+
+func (s tracedGroupService) GetById(ctx context.Context, id int64) (domain.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.GetById", attribute.Int64("group.id", id))
+	group, err := s.next.GetById(ctx, id)
+	tracing.End(span, err)
+
+	return group, err
+}
+
+func (s tracedGroupService) GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.GetRoster", attribute.Int64("group.id", id))
+	roster, err := s.next.GetRoster(ctx, id, query)
+	tracing.End(span, err)
+
+	return roster, err
+}
+
+func (s tracedGroupService) Update(ctx context.Context, dto dto.GroupDto) (domain.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Update", attribute.Int64("group.id", dto.Id))
+	group, err := s.next.Update(ctx, dto)
+	tracing.End(span, err)
+
+	return group, err
+}
+
+func (s tracedGroupService) Patch(ctx context.Context, id int64, patch GroupPatch) (domain.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Patch", attribute.Int64("group.id", id))
+	group, err := s.next.Patch(ctx, id, patch)
+	tracing.End(span, err)
+
+	return group, err
+}
+
+func (s tracedGroupService) DeleteById(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "GroupService.DeleteById", attribute.Int64("group.id", id))
+	err := s.next.DeleteById(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s tracedGroupService) IsGroupExistsByNumber(ctx context.Context, groupNumber string) bool {
+	ctx, span := tracing.Start(ctx, "GroupService.IsGroupExistsByNumber", attribute.String("group.number", groupNumber))
+	defer span.End()
+
+	return s.next.IsGroupExistsByNumber(ctx, groupNumber)
+}
+
+func (s tracedGroupService) IsGroupExistsById(ctx context.Context, id int64) bool {
+	ctx, span := tracing.Start(ctx, "GroupService.IsGroupExistsById", attribute.Int64("group.id", id))
+	defer span.End()
+
+	return s.next.IsGroupExistsById(ctx, id)
+}
+
+// tracedStudentManagerService is tracedStudentService for group membership
+type tracedStudentManagerService struct {
+	next StudentManagerService
+}
+
+func (s tracedStudentManagerService) AddStudentToGroup(ctx context.Context,
+	groupId, studentId int64) (dto.GroupRoster, error) {
+	ctx, span := tracing.Start(ctx, "StudentManagerService.AddStudentToGroup",
+		attribute.Int64("group.id", groupId), attribute.Int64("student.id", studentId))
+	roster, err := s.next.AddStudentToGroup(ctx, groupId, studentId)
+	tracing.End(span, err)
+
+	return roster, err
+}
+
+func (s tracedStudentManagerService) RemoveStudentFromGroup(ctx context.Context,
+	groupId, studentId int64) (dto.GroupRoster, error) {
+	ctx, span := tracing.Start(ctx, "StudentManagerService.RemoveStudentFromGroup",
+		attribute.Int64("group.id", groupId), attribute.Int64("student.id", studentId))
+	roster, err := s.next.RemoveStudentFromGroup(ctx, groupId, studentId)
+	tracing.End(span, err)
+
+	return roster, err
+}
+
+func (s tracedStudentManagerService) TransferStudent(ctx context.Context,
+	studentId, fromGroupId, toGroupId int64) (dto.GroupRoster, error) {
+	ctx, span := tracing.Start(ctx, "StudentManagerService.TransferStudent", attribute.Int64("student.id", studentId),
+		attribute.Int64("group.from_id", fromGroupId), attribute.Int64("group.id", toGroupId))
+	roster, err := s.next.TransferStudent(ctx, studentId, fromGroupId, toGroupId)
+	tracing.End(span, err)
+
+	return roster, err
+}
+
+func (s tracedStudentManagerService) GetWaitlist(ctx context.Context, groupId int64) (dto.GroupWaitlist, error) {
+	ctx, span := tracing.Start(ctx, "StudentManagerService.GetWaitlist", attribute.Int64("group.id", groupId))
+	waitlist, err := s.next.GetWaitlist(ctx, groupId)
+	tracing.End(span, err)
+
+	return waitlist, err
+}
+
+func (s tracedStudentManagerService) PutOnWaitlist(ctx context.Context,
+	groupId, studentId int64, priority int) (dto.GroupWaitlist, error) {
+	ctx, span := tracing.Start(ctx, "StudentManagerService.PutOnWaitlist",
+		attribute.Int64("group.id", groupId), attribute.Int64("student.id", studentId),
+		attribute.Int("waitlist.priority", priority))
+	waitlist, err := s.next.PutOnWaitlist(ctx, groupId, studentId, priority)
+	tracing.End(span, err)
+
+	return waitlist, err
+}
+
+func (s tracedStudentManagerService) RemoveFromWaitlist(ctx context.Context,
+	groupId, studentId int64) (dto.GroupWaitlist, error) {
+	ctx, span := tracing.Start(ctx, "StudentManagerService.RemoveFromWaitlist",
+		attribute.Int64("group.id", groupId), attribute.Int64("student.id", studentId))
+	waitlist, err := s.next.RemoveFromWaitlist(ctx, groupId, studentId)
+	tracing.End(span, err)
+
+	return waitlist, err
+}
+
+// tracedCourseService is tracedStudentService for courses
+type tracedCourseService struct {
+	next CourseService
+}
+
+func (s tracedCourseService) Create(ctx context.Context, dto dto.CourseDto) (domain.Course, error) {
+	ctx, span := tracing.Start(ctx, "CourseService.Create")
+	course, err := s.next.Create(ctx, dto)
+	tracing.End(span, err)
+
+	return course, err
+}
+
+func (s tracedCourseService) GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error) {
+	ctx, span := tracing.Start(ctx, "CourseService.GetAll")
+	page, err := s.next.GetAll(ctx, query)
+	tracing.End(span, err)
+
+	return page, err
+}
+
+func (s tracedCourseService) GetById(ctx context.Context, id int64) (domain.Course, error) {
+	ctx, span := tracing.Start(ctx, "CourseService.GetById", attribute.Int64("course.id", id))
+	course, err := s.next.GetById(ctx, id)
+	tracing.End(span, err)
+
+	return course, err
+}
+
+func (s tracedCourseService) Update(ctx context.Context, dto dto.CourseDto) (domain.Course, error) {
+	ctx, span := tracing.Start(ctx, "CourseService.Update", attribute.Int64("course.id", dto.Id))
+	course, err := s.next.Update(ctx, dto)
+	tracing.End(span, err)
+
+	return course, err
+}
+
+func (s tracedCourseService) DeleteById(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "CourseService.DeleteById", attribute.Int64("course.id", id))
+	err := s.next.DeleteById(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+// tracedEnrollmentService is tracedStudentService for enrollments
+type tracedEnrollmentService struct {
+	next EnrollmentService
+}
+
+func (s tracedEnrollmentService) GetAll(ctx context.Context, courseId int64,
+	query dto.EnrollmentQuery) (dto.CourseEnrollments, error) {
+	ctx, span := tracing.Start(ctx, "EnrollmentService.GetAll", attribute.Int64("course.id", courseId))
+	enrollments, err := s.next.GetAll(ctx, courseId, query)
+	tracing.End(span, err)
+
+	return enrollments, err
+}
+
+func (s tracedEnrollmentService) GetById(ctx context.Context, courseId, id int64) (domain.Enrollment, error) {
+	ctx, span := tracing.Start(ctx, "EnrollmentService.GetById",
+		attribute.Int64("course.id", courseId), attribute.Int64("enrollment.id", id))
+	enrollment, err := s.next.GetById(ctx, courseId, id)
+	tracing.End(span, err)
+
+	return enrollment, err
+}
+
+func (s tracedEnrollmentService) Enroll(ctx context.Context, dto dto.EnrollmentDto) (domain.Enrollment, error) {
+	ctx, span := tracing.Start(ctx, "EnrollmentService.Enroll", attribute.Int64("course.id", dto.CourseId),
+		attribute.Int64("group.id", dto.GroupId), attribute.Int64("student.id", dto.StudentId))
+	enrollment, err := s.next.Enroll(ctx, dto)
+	tracing.End(span, err)
+
+	return enrollment, err
+}
+
+func (s tracedEnrollmentService) SetStatus(ctx context.Context, courseId, id int64,
+	status string) (domain.Enrollment, error) {
+	ctx, span := tracing.Start(ctx, "EnrollmentService.SetStatus", attribute.Int64("course.id", courseId),
+		attribute.Int64("enrollment.id", id), attribute.String("enrollment.status", status))
+	enrollment, err := s.next.SetStatus(ctx, courseId, id, status)
+	tracing.End(span, err)
+
+	return enrollment, err
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"StudentManager/internal/apperror"
+	"StudentManager/internal/auth"
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"StudentManager/internal/tenant"
+	"context"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"slices"
+)
+
+var (
+	ErrUserNotFound    = apperror.NotFound("user_not_found", "user doesn't exist")
+	ErrUsernameTaken   = apperror.Conflict("username_taken", "user with this name already exists")
+	ErrCuratorNotFound = apperror.NotFound("curator_not_found", "user doesn't curate this group")
+	ErrUnknownRole     = apperror.Validation("unknown_role", "role doesn't exist",
+		apperror.FieldError{Field: "roles", Message: "must only contain existing roles"})
+	ErrUserStudentNotFound = apperror.Validation("user_student_not_found", "student doesn't exist",
+		apperror.FieldError{Field: "student_id", Message: "student doesn't exist"})
+)
+
+// dummyHash is compared against for unknown users, so that logging in as
+// somebody who doesn't exist takes as long as with a wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type UserServiceImpl struct {
+	accessRepository  repository.AccessRepository
+	studentRepository repository.StudentRepository
+	groupRepository   repository.GroupRepository
+	txManager         repository.TxManager
+	policy            *Policy
+	credentials       *auth.Credentials
+}
+
+func NewUserServiceImpl(accessRepo repository.AccessRepository, studentRepo repository.StudentRepository,
+	groupRepo repository.GroupRepository, txManager repository.TxManager, policy *Policy,
+	credentials *auth.Credentials) *UserServiceImpl {
+	return &UserServiceImpl{
+		accessRepository:  accessRepo,
+		studentRepository: studentRepo,
+		groupRepository:   groupRepo,
+		txManager:         txManager,
+		policy:            policy,
+		credentials:       credentials,
+	}
+}
+
+// CheckPassword accepts the configured user and the users kept in storage
+func (userService *UserServiceImpl) CheckPassword(ctx context.Context, username, password string) bool {
+	if username == userService.credentials.User() {
+		return userService.credentials.Check(username, password)
+	}
+
+	user, err := userService.accessRepository.GetUser(ctx, username)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			slog.WarnContext(ctx, "failed to get user", "err", err)
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func (userService *UserServiceImpl) Create(ctx context.Context, userDto dto.UserDto) (domain.User, error) {
+	repo := userService.accessRepository
+
+	if _, err := userService.policy.authorize(ctx, domain.PermissionUsersManage); err != nil {
+		return domain.User{}, storageError(err)
+	}
+	if userDto.Username == userService.credentials.User() {
+		slog.InfoContext(ctx, "user already exists")
+		return domain.User{}, ErrUsernameTaken
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(userDto.Password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to hash password", "err", err)
+		return domain.User{}, storageError(err)
+	}
+
+	user := domain.User{
+		Username:     userDto.Username,
+		PasswordHash: string(passwordHash),
+		StudentId:    userDto.StudentId,
+		Roles:        userDto.Roles,
+	}
+
+	var createdUser domain.User
+	err = userService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		if err := userService.checkRolesExist(ctx, user.Roles); err != nil {
+			return err
+		}
+		if user.StudentId != nil {
+			_, err := userService.studentRepository.GetById(ctx, *user.StudentId)
+			if errors.Is(err, repository.ErrNotFound) {
+				slog.InfoContext(ctx, "student doesn't exist")
+				return ErrUserStudentNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		err := repo.CreateUser(ctx, user)
+		if errors.Is(err, repository.ErrConflict) {
+			slog.InfoContext(ctx, "user already exists")
+			return ErrUsernameTaken
+		}
+		if err != nil {
+			return err
+		}
+
+		createdUser, err = repo.GetUser(ctx, user.Username)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to create user", "err", err)
+		return domain.User{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "created user", "username", createdUser.Username)
+	return createdUser, nil
+}
+
+func (userService *UserServiceImpl) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	if _, err := userService.policy.authorize(ctx, domain.PermissionUsersManage); err != nil {
+		return domain.User{}, storageError(err)
+	}
+
+	user, err := userService.getUser(ctx, username)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get user", "err", err)
+		return domain.User{}, storageError(err)
+	}
+
+	return user, nil
+}
+
+func (userService *UserServiceImpl) SetRoles(ctx context.Context, username string,
+	roles []string) (domain.User, error) {
+	repo := userService.accessRepository
+
+	if _, err := userService.policy.authorize(ctx, domain.PermissionUsersManage); err != nil {
+		return domain.User{}, storageError(err)
+	}
+
+	var updatedUser domain.User
+	err := userService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		if err := userService.checkRolesExist(ctx, roles); err != nil {
+			return err
+		}
+
+		if _, err := userService.getUser(ctx, username); err != nil {
+			return err
+		}
+
+		if err := repo.SetUserRoles(ctx, username, roles); err != nil {
+			return err
+		}
+
+		var err error
+		updatedUser, err = repo.GetUser(ctx, username)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to set user roles", "err", err)
+		return domain.User{}, storageError(err)
+	}
+
+	slog.InfoContext(ctx, "roles set", "username", username, "roles", updatedUser.Roles)
+	return updatedUser, nil
+}
+
+func (userService *UserServiceImpl) DeleteByUsername(ctx context.Context, username string) error {
+	repo := userService.accessRepository
+
+	if _, err := userService.policy.authorize(ctx, domain.PermissionUsersManage); err != nil {
+		return storageError(err)
+	}
+
+	err := userService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		if _, err := userService.getUser(ctx, username); err != nil {
+			return err
+		}
+
+		return repo.DeleteUser(ctx, username)
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to delete user", "err", err)
+		return storageError(err)
+	}
+
+	slog.InfoContext(ctx, "deleted user", "username", username)
+	return nil
+}
+
+func (userService *UserServiceImpl) AddCurator(ctx context.Context, groupId int64, username string) error {
+	if _, err := userService.policy.authorize(ctx, domain.PermissionGroupsManage); err != nil {
+		return storageError(err)
+	}
+
+	err := userService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		if err := userService.checkCuratorExists(ctx, groupId, username); err != nil {
+			return err
+		}
+
+		return userService.accessRepository.AddCurator(ctx, groupId, username)
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to add curator", "err", err)
+		return storageError(err)
+	}
+
+	slog.InfoContext(ctx, "curator assigned", "username", username, "group_id", groupId)
+	return nil
+}
+
+func (userService *UserServiceImpl) RemoveCurator(ctx context.Context, groupId int64, username string) error {
+	if _, err := userService.policy.authorize(ctx, domain.PermissionGroupsManage); err != nil {
+		return storageError(err)
+	}
+
+	err := userService.txManager.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		if err := userService.checkCuratorExists(ctx, groupId, username); err != nil {
+			return err
+		}
+
+		err := userService.accessRepository.RemoveCurator(ctx, groupId, username)
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.InfoContext(ctx, "curator doesn't exist")
+			return ErrCuratorNotFound
+		}
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to remove curator", "err", err)
+		return storageError(err)
+	}
+
+	slog.InfoContext(ctx, "curator removed", "username", username, "group_id", groupId)
+	return nil
+}
+
+func (userService *UserServiceImpl) checkRolesExist(ctx context.Context, roles []string) error {
+	known, err := userService.accessRepository.RoleNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if !slices.Contains(known, role) {
+			slog.InfoContext(ctx, "role doesn't exist", "role", role)
+			return ErrUnknownRole
+		}
+	}
+
+	return nil
+}
+
+func (userService *UserServiceImpl) checkCuratorExists(ctx context.Context, groupId int64, username string) error {
+	_, err := userService.groupRepository.GetById(ctx, groupId)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "group doesn't exist")
+		return ErrGroupNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = userService.getUser(ctx, username)
+	return err
+}
+
+// getUser looks a user up in the tenant of ctx, users of other tenants don't exist there
+func (userService *UserServiceImpl) getUser(ctx context.Context, username string) (domain.User, error) {
+	user, err := userService.accessRepository.GetUser(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && user.TenantId != tenant.IdFrom(ctx) {
+		slog.InfoContext(ctx, "user doesn't exist")
+		return domain.User{}, ErrUserNotFound
+	}
+
+	return user, err
+}
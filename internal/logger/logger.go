@@ -0,0 +1,69 @@
+// Package logger builds the app's slog logger. Every line logged with a
+// request's context carries its request id, tenant and trace, and personal data of
+// students and users is redacted before it is written.
+package logger
+
+import (
+	"StudentManager/internal/config"
+	"StudentManager/internal/tenant"
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+)
+
+const EnvLocal = "local"
+
+// New returns a logger writing to w, text for the local environment and
+// JSON for the others
+func New(env string, cfg config.Log, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q, use debug, info, warn or error", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if env == EnvLocal {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler adds what the context knows about the request to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		record.AddAttrs(slog.String("tenant_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	// the message is no attribute, ReplaceAttr doesn't see it
+	record.Message = redactString(record.Message)
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
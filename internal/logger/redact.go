@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// keys whose values are personal data whatever they look like
+var redactedKeys = map[string]bool{
+	"full_name": true,
+	"email":     true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// redactAttr hides personal data in attributes, including those nested in
+// groups like a logged domain.Student. Addresses are masked wherever they
+// show up, e.g. in a username or a storage error.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if redactedKeys[a.Key] {
+		if a.Value.Kind() == slog.KindString && a.Value.String() == "" {
+			return a
+		}
+		if a.Key == "email" {
+			return slog.String(a.Key, maskEmail(a.Value.String()))
+		}
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redactString(err.Error()))
+		}
+	}
+
+	return a
+}
+
+func redactString(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+
+	return emailPattern.ReplaceAllStringFunc(s, maskEmail)
+}
+
+// maskEmail keeps the first letter and the domain, enough to tell addresses
+// apart when debugging: "j***@example.com"
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redacted
+	}
+
+	return local[:1] + "***@" + domain
+}
@@ -0,0 +1,263 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// file names look like 0001_create_group_table.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Driver is implemented for every database the migrations can be applied to.
+type Driver interface {
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	EnsureVersionTable(ctx context.Context) error
+	// VersionTableExists only reads, health checks call it on every probe
+	VersionTableExists(ctx context.Context) (bool, error)
+	AppliedVersions(ctx context.Context) (map[int64]time.Time, error)
+	Apply(ctx context.Context, migration Migration) error
+	Revert(ctx context.Context, migration Migration) error
+}
+
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+}
+
+func New(driver Driver, source fs.FS) (*Migrator, error) {
+	migrations, err := load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		driver:     driver,
+		migrations: migrations,
+	}, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.driver.EnsureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.driver.AppliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the number of migrations that are not applied yet. Unlike
+// Status it doesn't create the version table, a database without one lacks
+// every migration.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	exists, err := m.driver.VersionTableExists(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return len(m.migrations), nil
+	}
+
+	applied, err := m.driver.AppliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies all pending migrations in version order.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+
+	err := m.withLock(ctx, func() error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			if status.Applied {
+				continue
+			}
+			if err := m.driver.Apply(ctx, status.Migration); err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", status.Version, status.Name, err)
+			}
+			slog.InfoContext(ctx, "applied migration", "version", status.Version, "migration", status.Name)
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last n applied migrations.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	reverted := 0
+
+	err := m.withLock(ctx, func() error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0 && reverted < n; i-- {
+			status := statuses[i]
+			if !status.Applied {
+				continue
+			}
+			if err := m.driver.Revert(ctx, status.Migration); err != nil {
+				return fmt.Errorf("revert migration %d_%s: %w", status.Version, status.Name, err)
+			}
+			slog.InfoContext(ctx, "reverted migration", "version", status.Version, "migration", status.Name)
+			reverted++
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Redo reverts the last applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0; i-- {
+			status := statuses[i]
+			if !status.Applied {
+				continue
+			}
+			if err := m.driver.Revert(ctx, status.Migration); err != nil {
+				return fmt.Errorf("revert migration %d_%s: %w", status.Version, status.Name, err)
+			}
+			if err := m.driver.Apply(ctx, status.Migration); err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", status.Version, status.Name, err)
+			}
+			slog.InfoContext(ctx, "redone migration", "version", status.Version, "migration", status.Name)
+			return nil
+		}
+
+		return errors.New("no applied migrations")
+	})
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.driver.Lock(ctx); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if err := m.driver.Unlock(context.Background()); err != nil {
+			slog.ErrorContext(ctx, "failed to release migration lock", "err", err)
+		}
+	}()
+
+	return fn()
+}
+
+func load(source fs.FS) ([]Migration, error) {
+	byVersion := make(map[int64]*Migration)
+
+	err := fs.WalkDir(source, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		match := fileNamePattern.FindStringSubmatch(path.Base(p))
+		if match == nil {
+			return fmt.Errorf("unexpected migration file name: %s", p)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration version in %s: %w", p, err)
+		}
+
+		content, err := fs.ReadFile(source, p)
+		if err != nil {
+			return err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
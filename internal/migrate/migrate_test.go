@@ -0,0 +1,212 @@
+package migrate_test
+
+import (
+	"StudentManager/internal/migrate"
+	"StudentManager/migrations"
+	"context"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPairsUpAndDown(t *testing.T) {
+	source := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("create table second (id integer)")},
+		"0002_second.down.sql": {Data: []byte("drop table second")},
+		"0001_first.down.sql":  {Data: []byte("drop table first")},
+		"0001_first.up.sql":    {Data: []byte("create table first (id integer)")},
+	}
+
+	migrator, err := migrate.New(migrate.NewSQLiteDriver(newSQLite(t)), source)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	want := []migrate.Migration{
+		{Version: 1, Name: "first", Up: "create table first (id integer)", Down: "drop table first"},
+		{Version: 2, Name: "second", Up: "create table second (id integer)", Down: "drop table second"},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("Status() returned %d migrations, want %d", len(statuses), len(want))
+	}
+	for i, status := range statuses {
+		if status.Migration != want[i] {
+			t.Errorf("Status()[%d] = %+v, want %+v", i, status.Migration, want[i])
+		}
+		if status.Applied {
+			t.Errorf("Status()[%d].Applied = true before Up", i)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		source fstest.MapFS
+		want   string
+	}{
+		{
+			name: "missing down file",
+			source: fstest.MapFS{
+				"0001_first.up.sql": {Data: []byte("select 1")},
+			},
+			want: "must have both up and down files",
+		},
+		{
+			name: "conflicting names",
+			source: fstest.MapFS{
+				"0001_first.up.sql":   {Data: []byte("select 1")},
+				"0001_other.down.sql": {Data: []byte("select 1")},
+			},
+			want: "conflicting names",
+		},
+		{
+			name: "unexpected file name",
+			source: fstest.MapFS{
+				"first.sql": {Data: []byte("select 1")},
+			},
+			want: "unexpected migration file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrate.New(nil, tt.source)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("New() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// both embedded sets have to load and stay in step with each other
+func TestEmbeddedMigrations(t *testing.T) {
+	// loading doesn't touch the database, so there is none
+	if _, err := migrate.NewSQLite(nil); err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+	if _, err := migrate.NewPostgres(nil); err != nil {
+		t.Fatalf("NewPostgres() error = %v", err)
+	}
+
+	sqliteFiles := fileNames(t, migrations.SQLite, "sqlite")
+	postgresFiles := fileNames(t, migrations.Postgres, "postgres")
+	if !slices.Equal(sqliteFiles, postgresFiles) {
+		t.Fatalf("sqlite migrations %v differ from postgres migrations %v", sqliteFiles, postgresFiles)
+	}
+}
+
+func fileNames(t *testing.T, source fs.FS, dir string) []string {
+	t.Helper()
+
+	entries, err := fs.ReadDir(source, dir)
+	if err != nil {
+		t.Fatalf("read %s migrations: %v", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+// testRoundTrip runs every migration up, redoes the last one, takes them all
+// down again and checks Status and Pending along the way.
+func testRoundTrip(t *testing.T, migrator *migrate.Migrator, versionTableExists func() (bool, error)) {
+	t.Helper()
+	ctx := context.Background()
+
+	// a fresh database lacks every migration
+	total, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if total == 0 {
+		t.Fatal("Pending() = 0 on a fresh database")
+	}
+	if exists, err := versionTableExists(); err != nil || exists {
+		t.Fatalf("version table exists = %v (err %v) after Pending, Pending must not create it", exists, err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if applied != total {
+		t.Fatalf("Up() applied %d migrations, want %d", applied, total)
+	}
+	assertPending(t, ctx, migrator, 0)
+	assertApplied(t, ctx, migrator, total)
+
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Fatalf("second Up() = %d, %v, want nothing to apply", applied, err)
+	}
+
+	if err := migrator.Redo(ctx); err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	assertPending(t, ctx, migrator, 0)
+	assertApplied(t, ctx, migrator, total)
+
+	if reverted, err := migrator.Down(ctx, 1); err != nil || reverted != 1 {
+		t.Fatalf("Down(1) = %d, %v, want 1 reverted", reverted, err)
+	}
+	assertPending(t, ctx, migrator, 1)
+	assertApplied(t, ctx, migrator, total-1)
+
+	// asking for more than is applied stops at the first migration
+	if reverted, err := migrator.Down(ctx, total); err != nil || reverted != total-1 {
+		t.Fatalf("Down(%d) = %d, %v, want %d reverted", total, reverted, err, total-1)
+	}
+	assertPending(t, ctx, migrator, total)
+	assertApplied(t, ctx, migrator, 0)
+
+	if err := migrator.Redo(ctx); err == nil {
+		t.Fatal("Redo() without applied migrations succeeded")
+	}
+
+	// the down files have to leave nothing behind the up files would trip over
+	if applied, err := migrator.Up(ctx); err != nil || applied != total {
+		t.Fatalf("Up() after Down() = %d, %v, want %d applied", applied, err, total)
+	}
+	assertPending(t, ctx, migrator, 0)
+}
+
+func assertPending(t *testing.T, ctx context.Context, migrator *migrate.Migrator, want int) {
+	t.Helper()
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if pending != want {
+		t.Fatalf("Pending() = %d, want %d", pending, want)
+	}
+}
+
+// assertApplied checks that exactly the first n migrations are applied
+func assertApplied(t *testing.T, ctx context.Context, migrator *migrate.Migrator, n int) {
+	t.Helper()
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for i, status := range statuses {
+		if status.Applied != (i < n) {
+			t.Fatalf("migration %d_%s applied = %v, want %v", status.Version, status.Name, status.Applied, i < n)
+		}
+		if status.Applied && status.AppliedAt.IsZero() {
+			t.Fatalf("migration %d_%s is applied without a time", status.Version, status.Name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"StudentManager/migrations"
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// arbitrary key shared by every instance, so only one of them migrates at a time
+const postgresLockKey int64 = 7_406_113_508_126_214
+
+type PostgresDriver struct {
+	db   *pgxpool.Pool
+	conn *pgxpool.Conn
+}
+
+func NewPostgresDriver(db *pgxpool.Pool) *PostgresDriver {
+	return &PostgresDriver{
+		db: db,
+	}
+}
+
+func NewPostgres(db *pgxpool.Pool) (*Migrator, error) {
+	source, err := fs.Sub(migrations.Postgres, "postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	return New(NewPostgresDriver(db), source)
+}
+
+func (d *PostgresDriver) Lock(ctx context.Context) error {
+	conn, err := d.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// advisory locks belong to a session, so the connection is kept until Unlock
+	if _, err := conn.Exec(ctx, "select pg_advisory_lock($1)", postgresLockKey); err != nil {
+		conn.Release()
+		return err
+	}
+
+	d.conn = conn
+	return nil
+}
+
+func (d *PostgresDriver) Unlock(ctx context.Context) error {
+	if d.conn == nil {
+		return errors.New("migration lock is not held")
+	}
+	defer func() {
+		d.conn.Release()
+		d.conn = nil
+	}()
+
+	_, err := d.conn.Exec(ctx, "select pg_advisory_unlock($1)", postgresLockKey)
+	return err
+}
+
+func (d *PostgresDriver) EnsureVersionTable(ctx context.Context) error {
+	_, err := d.db.Exec(ctx,
+		`create table if not exists schema_migrations
+		(
+			version    bigint primary key,
+			name       text        not null,
+			applied_at timestamptz not null default now()
+		)`)
+
+	return err
+}
+
+func (d *PostgresDriver) VersionTableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	// to_regclass looks the table up in the search path like the other statements do
+	err := d.db.QueryRow(ctx, "select to_regclass('schema_migrations') is not null").Scan(&exists)
+
+	return exists, err
+}
+
+func (d *PostgresDriver) AppliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := d.db.Query(ctx, "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (d *PostgresDriver) Apply(ctx context.Context, migration Migration) error {
+	return d.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			"insert into schema_migrations(version, name) values($1, $2)",
+			migration.Version, migration.Name)
+
+		return err
+	})
+}
+
+func (d *PostgresDriver) Revert(ctx context.Context, migration Migration) error {
+	return d.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "delete from schema_migrations where version = $1", migration.Version)
+
+		return err
+	})
+}
+
+func (d *PostgresDriver) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,60 @@
+package migrate_test
+
+import (
+	"StudentManager/internal/migrate"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+	"time"
+)
+
+// postgresDSNEnv is the same variable the repository suite reads, the test
+// is skipped when it isn't set
+const postgresDSNEnv = "STUDENTS_TEST_POSTGRES_DSN"
+
+// the migrations run in a schema of their own, which is dropped afterwards
+func TestPostgresRoundTrip(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skip(postgresDSNEnv + " is not set")
+	}
+
+	ctx := context.Background()
+	admin, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	schema := fmt.Sprintf("migratetest_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "create schema "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(ctx, "drop schema "+schema+" cascade"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse dsn: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	db, err := pgxpool.ConnectConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	migrator, err := migrate.NewPostgres(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	testRoundTrip(t, migrator, func() (bool, error) {
+		return migrate.NewPostgresDriver(db).VersionTableExists(ctx)
+	})
+}
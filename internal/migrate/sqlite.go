@@ -0,0 +1,209 @@
+package migrate
+
+import (
+	"StudentManager/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// SQLiteDriver has no advisory locks to rely on, so Lock opens an immediate
+// transaction that holds the database write lock until Unlock commits it.
+// Every migration runs inside its own savepoint of that transaction.
+//
+// Foreign keys are off while the lock is held, so that migrations can rebuild
+// tables the way SQLite recommends for schema changes ALTER TABLE can't do.
+// Each migration has to leave the foreign keys consistent, though.
+type SQLiteDriver struct {
+	db   *sql.DB
+	conn *sql.Conn
+}
+
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func NewSQLiteDriver(db *sql.DB) *SQLiteDriver {
+	return &SQLiteDriver{
+		db: db,
+	}
+}
+
+func NewSQLite(db *sql.DB) (*Migrator, error) {
+	source, err := fs.Sub(migrations.SQLite, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	return New(NewSQLiteDriver(db), source)
+}
+
+func (d *SQLiteDriver) Lock(ctx context.Context) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the pragma is a no-op inside a transaction, it has to come first
+	if _, err := conn.ExecContext(ctx, "pragma foreign_keys = off"); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "begin immediate"); err != nil {
+		_, _ = conn.ExecContext(ctx, "pragma foreign_keys = on")
+		_ = conn.Close()
+		return err
+	}
+
+	d.conn = conn
+	return nil
+}
+
+func (d *SQLiteDriver) Unlock(ctx context.Context) error {
+	if d.conn == nil {
+		return errors.New("migration lock is not held")
+	}
+	defer func() {
+		_ = d.conn.Close()
+		d.conn = nil
+	}()
+
+	if _, err := d.conn.ExecContext(ctx, "commit"); err != nil {
+		return err
+	}
+
+	// the connection goes back to the pool, which expects foreign keys on
+	_, err := d.conn.ExecContext(ctx, "pragma foreign_keys = on")
+	return err
+}
+
+func (d *SQLiteDriver) EnsureVersionTable(ctx context.Context) error {
+	_, err := d.querier().ExecContext(ctx,
+		`create table if not exists schema_migrations
+		(
+			version    integer primary key,
+			name       text     not null,
+			applied_at datetime not null default current_timestamp
+		)`)
+
+	return err
+}
+
+func (d *SQLiteDriver) VersionTableExists(ctx context.Context) (bool, error) {
+	rows, err := d.querier().QueryContext(ctx,
+		"select 1 from sqlite_master where type = 'table' and name = 'schema_migrations'")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	exists := rows.Next()
+
+	return exists, rows.Err()
+}
+
+func (d *SQLiteDriver) AppliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := d.querier().QueryContext(ctx, "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (d *SQLiteDriver) Apply(ctx context.Context, migration Migration) error {
+	return d.inSavepoint(ctx, func(q sqliteQuerier) error {
+		if _, err := q.ExecContext(ctx, migration.Up); err != nil {
+			return err
+		}
+		if err := checkForeignKeys(ctx, q); err != nil {
+			return err
+		}
+		_, err := q.ExecContext(ctx,
+			"insert into schema_migrations(version, name) values(?, ?)",
+			migration.Version, migration.Name)
+
+		return err
+	})
+}
+
+func (d *SQLiteDriver) Revert(ctx context.Context, migration Migration) error {
+	return d.inSavepoint(ctx, func(q sqliteQuerier) error {
+		if _, err := q.ExecContext(ctx, migration.Down); err != nil {
+			return err
+		}
+		if err := checkForeignKeys(ctx, q); err != nil {
+			return err
+		}
+		_, err := q.ExecContext(ctx, "delete from schema_migrations where version = ?", migration.Version)
+
+		return err
+	})
+}
+
+func (d *SQLiteDriver) inSavepoint(ctx context.Context, fn func(q sqliteQuerier) error) error {
+	if d.conn == nil {
+		return errors.New("migration lock is not held")
+	}
+
+	if _, err := d.conn.ExecContext(ctx, "savepoint migration"); err != nil {
+		return err
+	}
+
+	if err := fn(d.conn); err != nil {
+		if _, rollbackErr := d.conn.ExecContext(ctx, "rollback to migration"); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	_, err := d.conn.ExecContext(ctx, "release migration")
+	return err
+}
+
+// checkForeignKeys fails when a migration left rows pointing nowhere, which
+// foreign keys being off let it do
+func checkForeignKeys(ctx context.Context, q sqliteQuerier) error {
+	rows, err := q.QueryContext(ctx, "pragma foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowId sql.NullInt64
+		var constraint int64
+		if err := rows.Scan(&table, &rowId, &parent, &constraint); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d of %s references a missing row of %s", rowId.Int64, table, parent)
+	}
+
+	return rows.Err()
+}
+
+// querier uses the locked connection when there is one: the pool has a single
+// connection, so going through it while the lock is held would block forever
+func (d *SQLiteDriver) querier() sqliteQuerier {
+	if d.conn != nil {
+		return d.conn
+	}
+
+	return d.db
+}
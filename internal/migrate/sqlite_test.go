@@ -0,0 +1,37 @@
+package migrate_test
+
+import (
+	"StudentManager/internal/config"
+	"StudentManager/internal/migrate"
+	"StudentManager/pkg/database/sqlite"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// newSQLite opens an empty database file for a single test
+func newSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sqlite.New(config.Database{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	db := newSQLite(t)
+
+	migrator, err := migrate.NewSQLite(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	testRoundTrip(t, migrator, func() (bool, error) {
+		return migrate.NewSQLiteDriver(db).VersionTableExists(context.Background())
+	})
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"sort"
+)
+
+// memoryRolePermissions mirrors the roles seeded by migrations 0005_create_access_tables
+// and 0009_create_course_tables
+var memoryRolePermissions = map[string][]domain.Grant{
+	"admin": {
+		{Permission: domain.PermissionStudentsRead, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionStudentsWrite, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionGroupsRead, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionGroupsManage, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionUsersManage, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionCoursesRead, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionCoursesManage, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionEnrollmentsManage, Scope: domain.ScopeAll},
+	},
+	"teacher": {
+		{Permission: domain.PermissionStudentsRead, Scope: domain.ScopeCurated},
+		{Permission: domain.PermissionStudentsWrite, Scope: domain.ScopeCurated},
+		{Permission: domain.PermissionGroupsRead, Scope: domain.ScopeCurated},
+		{Permission: domain.PermissionCoursesRead, Scope: domain.ScopeAll},
+		{Permission: domain.PermissionEnrollmentsManage, Scope: domain.ScopeCurated},
+	},
+	"student": {
+		{Permission: domain.PermissionStudentsRead, Scope: domain.ScopeOwn},
+		{Permission: domain.PermissionGroupsRead, Scope: domain.ScopeOwn},
+		{Permission: domain.PermissionCoursesRead, Scope: domain.ScopeAll},
+	},
+}
+
+type AccessRepoMemory struct {
+	lock   *memoryLock
+	groups *GroupRepoMemory
+	users  map[string]domain.User
+	// group id -> curator user names
+	curators map[int64]map[string]bool
+}
+
+func newAccessRepoMemory(lock *memoryLock, groups *GroupRepoMemory) *AccessRepoMemory {
+	return &AccessRepoMemory{
+		lock:     lock,
+		groups:   groups,
+		users:    make(map[string]domain.User),
+		curators: make(map[int64]map[string]bool),
+	}
+}
+
+func (repo *AccessRepoMemory) CreateUser(ctx context.Context, user domain.User) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, ok := repo.users[user.Username]; ok {
+		return ErrConflict
+	}
+	user.TenantId = tenant.IdFrom(ctx)
+	user.Roles = uniqueSorted(user.Roles)
+	repo.users[user.Username] = user
+
+	return nil
+}
+
+func (repo *AccessRepoMemory) GetUser(ctx context.Context, username string) (domain.User, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer release()
+
+	user, ok := repo.users[username]
+	if !ok {
+		return domain.User{}, ErrNotFound
+	}
+	user.Roles = append([]string{}, user.Roles...)
+
+	return user, nil
+}
+
+func (repo *AccessRepoMemory) DeleteUser(ctx context.Context, username string) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, ok := repo.users[username]; !ok {
+		return ErrNotFound
+	}
+	delete(repo.users, username)
+	for _, curators := range repo.curators {
+		delete(curators, username)
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoMemory) SetUserRoles(ctx context.Context, username string, roles []string) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	user, ok := repo.users[username]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Roles = uniqueSorted(roles)
+	repo.users[username] = user
+
+	return nil
+}
+
+func (repo *AccessRepoMemory) RoleNames(ctx context.Context) ([]string, error) {
+	roles := make([]string, 0, len(memoryRolePermissions))
+	for role := range memoryRolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
+func (repo *AccessRepoMemory) GetGrants(ctx context.Context, username string) ([]domain.Grant, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var grants []domain.Grant
+	for _, role := range repo.users[username].Roles {
+		grants = append(grants, memoryRolePermissions[role]...)
+	}
+
+	return grants, nil
+}
+
+func (repo *AccessRepoMemory) AddCurator(ctx context.Context, groupId int64, username string) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if repo.curators[groupId] == nil {
+		repo.curators[groupId] = make(map[string]bool)
+	}
+	repo.curators[groupId][username] = true
+
+	return nil
+}
+
+func (repo *AccessRepoMemory) RemoveCurator(ctx context.Context, groupId int64, username string) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if !repo.curators[groupId][username] {
+		return ErrNotFound
+	}
+	delete(repo.curators[groupId], username)
+
+	return nil
+}
+
+func (repo *AccessRepoMemory) CuratedGroupNumbers(ctx context.Context, username string) ([]string, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	var groupNumbers []string
+	for groupId, curators := range repo.curators {
+		// the groups repository shares the lock, so its map is read directly;
+		// curators of deleted groups are skipped like the database cascade would drop them
+		group, ok := repo.groups.groups[groupId]
+		if ok && curators[username] && repo.groups.tenants[groupId] == tenantId {
+			groupNumbers = append(groupNumbers, group.GroupNumber)
+		}
+	}
+	sort.Strings(groupNumbers)
+
+	return groupNumbers, nil
+}
+
+func (repo *AccessRepoMemory) snapshot() func() {
+	users := make(map[string]domain.User, len(repo.users))
+	for username, user := range repo.users {
+		users[username] = user
+	}
+	curators := make(map[int64]map[string]bool, len(repo.curators))
+	for groupId, names := range repo.curators {
+		curators[groupId] = make(map[string]bool, len(names))
+		for name := range names {
+			curators[groupId][name] = true
+		}
+	}
+
+	return func() {
+		repo.users = users
+		repo.curators = curators
+	}
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+)
+
+type AccessRepoPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewAccessRepoPostgres(db *pgxpool.Pool) *AccessRepoPostgres {
+	return &AccessRepoPostgres{
+		db: db,
+	}
+}
+
+func (repo *AccessRepoPostgres) CreateUser(ctx context.Context, user domain.User) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	_, err := database.Exec(ctx,
+		"insert into app_user(username, password_hash, student_id, tenant_id) values($1, $2, $3, $4)",
+		user.Username, user.PasswordHash, user.StudentId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in user creation", "err", err)
+		return convertPostgresError(err)
+	}
+
+	return repo.insertRoles(ctx, user.Username, user.Roles)
+}
+
+func (repo *AccessRepoPostgres) GetUser(ctx context.Context, username string) (domain.User, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	var user domain.User
+	err := database.QueryRow(ctx,
+		"select username, password_hash, student_id, tenant_id from app_user where username = $1", username).
+		Scan(&user.Username, &user.PasswordHash, &user.StudentId, &user.TenantId)
+	if err != nil {
+		return domain.User{}, convertPostgresError(err)
+	}
+
+	rows, err := database.Query(ctx, "select role from user_role where username = $1 order by role", username)
+	if err != nil {
+		return domain.User{}, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	user.Roles = []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return domain.User{}, err
+		}
+		user.Roles = append(user.Roles, role)
+	}
+
+	return user, rows.Err()
+}
+
+func (repo *AccessRepoPostgres) DeleteUser(ctx context.Context, username string) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx, "delete from app_user where username = $1", username)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in user deletion", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoPostgres) SetUserRoles(ctx context.Context, username string, roles []string) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	if _, err := database.Exec(ctx, "delete from user_role where username = $1", username); err != nil {
+		return convertPostgresError(err)
+	}
+
+	return repo.insertRoles(ctx, username, roles)
+}
+
+func (repo *AccessRepoPostgres) insertRoles(ctx context.Context, username string, roles []string) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	for _, role := range roles {
+		_, err := database.Exec(ctx,
+			"insert into user_role(username, role) values($1, $2) on conflict do nothing", username, role)
+		if err != nil {
+			slog.ErrorContext(ctx, "query executement in role assignment", "err", err)
+			return convertPostgresError(err)
+		}
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoPostgres) RoleNames(ctx context.Context) ([]string, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	rows, err := database.Query(ctx, "select name from role order by name")
+	if err != nil {
+		return nil, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (repo *AccessRepoPostgres) GetGrants(ctx context.Context, username string) ([]domain.Grant, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	rows, err := database.Query(ctx,
+		`select rp.permission, rp.scope
+		from user_role ur
+		join role_permission rp on rp.role = ur.role
+		where ur.username = $1`, username)
+	if err != nil {
+		return nil, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var grants []domain.Grant
+	for rows.Next() {
+		var grant domain.Grant
+		if err := rows.Scan(&grant.Permission, &grant.Scope); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+func (repo *AccessRepoPostgres) AddCurator(ctx context.Context, groupId int64, username string) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	_, err := database.Exec(ctx,
+		"insert into group_curator(group_id, username) values($1, $2) on conflict do nothing", groupId, username)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in curator assignment", "err", err)
+		return convertPostgresError(err)
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoPostgres) RemoveCurator(ctx context.Context, groupId int64, username string) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx,
+		"delete from group_curator where group_id = $1 and username = $2", groupId, username)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in curator removal", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoPostgres) CuratedGroupNumbers(ctx context.Context, username string) ([]string, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	rows, err := database.Query(ctx,
+		`select g.group_number
+		from group_curator gc
+		join "group" g on g.id = gc.group_id
+		where gc.username = $1 and g.tenant_id = $2
+		order by g.group_number`, username, tenant.IdFrom(ctx))
+	if err != nil {
+		return nil, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var groupNumbers []string
+	for rows.Next() {
+		var groupNumber string
+		if err := rows.Scan(&groupNumber); err != nil {
+			return nil, err
+		}
+		groupNumbers = append(groupNumbers, groupNumber)
+	}
+
+	return groupNumbers, rows.Err()
+}
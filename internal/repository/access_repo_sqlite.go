@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+type AccessRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewAccessRepoSQLite(db *sql.DB) *AccessRepoSQLite {
+	return &AccessRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *AccessRepoSQLite) CreateUser(ctx context.Context, user domain.User) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	_, err := database.ExecContext(ctx,
+		"insert into app_user(username, password_hash, student_id, tenant_id) values(?, ?, ?, ?)",
+		user.Username, user.PasswordHash, user.StudentId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in user creation", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return repo.insertRoles(ctx, user.Username, user.Roles)
+}
+
+func (repo *AccessRepoSQLite) GetUser(ctx context.Context, username string) (domain.User, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	var user domain.User
+	err := database.QueryRowContext(ctx,
+		"select username, password_hash, student_id, tenant_id from app_user where username = ?", username).
+		Scan(&user.Username, &user.PasswordHash, &user.StudentId, &user.TenantId)
+	if err != nil {
+		return domain.User{}, convertSQLiteError(err)
+	}
+
+	rows, err := database.QueryContext(ctx, "select role from user_role where username = ? order by role", username)
+	if err != nil {
+		return domain.User{}, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	user.Roles = []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return domain.User{}, err
+		}
+		user.Roles = append(user.Roles, role)
+	}
+
+	return user, rows.Err()
+}
+
+func (repo *AccessRepoSQLite) DeleteUser(ctx context.Context, username string) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx, "delete from app_user where username = ?", username)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in user deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (repo *AccessRepoSQLite) SetUserRoles(ctx context.Context, username string, roles []string) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	if _, err := database.ExecContext(ctx, "delete from user_role where username = ?", username); err != nil {
+		return convertSQLiteError(err)
+	}
+
+	return repo.insertRoles(ctx, username, roles)
+}
+
+func (repo *AccessRepoSQLite) insertRoles(ctx context.Context, username string, roles []string) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	for _, role := range roles {
+		_, err := database.ExecContext(ctx,
+			"insert into user_role(username, role) values(?, ?) on conflict do nothing", username, role)
+		if err != nil {
+			slog.ErrorContext(ctx, "query executement in role assignment", "err", err)
+			return convertSQLiteError(err)
+		}
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoSQLite) RoleNames(ctx context.Context) ([]string, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	rows, err := database.QueryContext(ctx, "select name from role order by name")
+	if err != nil {
+		return nil, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (repo *AccessRepoSQLite) GetGrants(ctx context.Context, username string) ([]domain.Grant, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	rows, err := database.QueryContext(ctx,
+		`select rp.permission, rp.scope
+		from user_role ur
+		join role_permission rp on rp.role = ur.role
+		where ur.username = ?`, username)
+	if err != nil {
+		return nil, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var grants []domain.Grant
+	for rows.Next() {
+		var grant domain.Grant
+		if err := rows.Scan(&grant.Permission, &grant.Scope); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+func (repo *AccessRepoSQLite) AddCurator(ctx context.Context, groupId int64, username string) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	_, err := database.ExecContext(ctx,
+		"insert into group_curator(group_id, username) values(?, ?) on conflict do nothing", groupId, username)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in curator assignment", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return nil
+}
+
+func (repo *AccessRepoSQLite) RemoveCurator(ctx context.Context, groupId int64, username string) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx,
+		"delete from group_curator where group_id = ? and username = ?", groupId, username)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in curator removal", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (repo *AccessRepoSQLite) CuratedGroupNumbers(ctx context.Context, username string) ([]string, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	rows, err := database.QueryContext(ctx,
+		`select g.group_number
+		from group_curator gc
+		join "group" g on g.id = gc.group_id
+		where gc.username = ? and g.tenant_id = ?
+		order by g.group_number`, username, tenant.IdFrom(ctx))
+	if err != nil {
+		return nil, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var groupNumbers []string
+	for rows.Next() {
+		var groupNumber string
+		if err := rows.Scan(&groupNumber); err != nil {
+			return nil, err
+		}
+		groupNumbers = append(groupNumbers, groupNumber)
+	}
+
+	return groupNumbers, rows.Err()
+}
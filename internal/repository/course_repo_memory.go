@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"maps"
+	"sort"
+	"strings"
+)
+
+type CourseRepoMemory struct {
+	lock    *memoryLock
+	lastId  int64
+	courses map[int64]domain.Course
+	// course id -> tenant id
+	tenants map[int64]string
+	// checked for references and read for course filters, shares the lock
+	enrollments *EnrollmentRepoMemory
+}
+
+// newCourseAndEnrollmentRepoMemory returns repositories that see each other,
+// the way the foreign keys between their tables do
+func newCourseAndEnrollmentRepoMemory(lock *memoryLock, students *StudentRepoMemory,
+	groups *GroupRepoMemory) (*CourseRepoMemory, *EnrollmentRepoMemory) {
+	courses := &CourseRepoMemory{
+		lock:    lock,
+		courses: make(map[int64]domain.Course),
+		tenants: make(map[int64]string),
+	}
+	enrollments := newEnrollmentRepoMemory(lock, courses, students, groups)
+	courses.enrollments = enrollments
+
+	return courses, enrollments
+}
+
+func (repo *CourseRepoMemory) GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+	defer release()
+
+	page, err := checkPage(query.PageRequest, courseSortColumns)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+	after, err := decodeCursor(page, courseSortColumns)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+
+	tenantId := tenant.IdFrom(ctx)
+	var courses []domain.Course
+	for id, course := range repo.courses {
+		if repo.tenants[id] != tenantId || !repo.courseMatches(course, query.CourseFilter) {
+			continue
+		}
+		courses = append(courses, course)
+	}
+	total := int64(len(courses))
+
+	sort.Slice(courses, func(i, j int) bool {
+		order := compareValues(courseSortValue(courses[i], page.SortBy), courseSortValue(courses[j], page.SortBy))
+		if order == 0 {
+			order = compareValues(courses[i].Id, courses[j].Id)
+		}
+		if page.SortDirection == dto.SortDesc {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	courses, nextCursor := pageSlice(courses, page, after, courseSortValue, courseId)
+
+	return dto.CoursePage{
+		Courses:    courses,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *CourseRepoMemory) Create(ctx context.Context, course domain.Course) (domain.Course, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Course{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if repo.codeTaken(tenantId, course.Code, 0) {
+		return domain.Course{}, ErrConflict
+	}
+
+	repo.lastId++
+	course.Id = repo.lastId
+	repo.courses[course.Id] = course
+	repo.tenants[course.Id] = tenantId
+
+	return course, nil
+}
+
+func (repo *CourseRepoMemory) GetById(ctx context.Context, id int64) (domain.Course, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Course{}, err
+	}
+	defer release()
+
+	course, ok := repo.courses[id]
+	if !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return domain.Course{}, ErrNotFound
+	}
+
+	return course, nil
+}
+
+func (repo *CourseRepoMemory) Update(ctx context.Context, course domain.Course) (domain.Course, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Course{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if _, ok := repo.courses[course.Id]; !ok || repo.tenants[course.Id] != tenantId {
+		return domain.Course{}, ErrNotFound
+	}
+	if repo.codeTaken(tenantId, course.Code, course.Id) {
+		return domain.Course{}, ErrConflict
+	}
+	repo.courses[course.Id] = course
+
+	return course, nil
+}
+
+func (repo *CourseRepoMemory) DeleteById(ctx context.Context, id int64) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, ok := repo.courses[id]; !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return ErrNotFound
+	}
+	// the foreign key of the enrollments
+	for _, enrollment := range repo.enrollments.live() {
+		if enrollment.CourseId == id {
+			return ErrConflict
+		}
+	}
+	delete(repo.courses, id)
+	delete(repo.tenants, id)
+
+	return nil
+}
+
+func (repo *CourseRepoMemory) snapshot() func() {
+	lastId := repo.lastId
+	courses := maps.Clone(repo.courses)
+	tenants := maps.Clone(repo.tenants)
+
+	return func() {
+		repo.lastId = lastId
+		repo.courses = courses
+		repo.tenants = tenants
+	}
+}
+
+// codeTaken mirrors the unique constraint on tenant and code
+func (repo *CourseRepoMemory) codeTaken(tenantId, code string, exceptId int64) bool {
+	for id, course := range repo.courses {
+		if id != exceptId && repo.tenants[id] == tenantId && course.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// courseMatches mirrors the filter conditions of newCourseListSQL. The
+// caller holds the lock.
+func (repo *CourseRepoMemory) courseMatches(course domain.Course, filter dto.CourseFilter) bool {
+	if filter.TitleContains != "" &&
+		!strings.Contains(strings.ToLower(course.Title), strings.ToLower(filter.TitleContains)) {
+		return false
+	}
+	if filter.StudentId == 0 && filter.GroupId == 0 {
+		return true
+	}
+
+	byStudent, byGroup := filter.StudentId == 0, filter.GroupId == 0
+	for _, enrollment := range repo.enrollments.live() {
+		if enrollment.CourseId != course.Id || enrollment.Status != domain.EnrollmentActive {
+			continue
+		}
+		if filter.StudentId != 0 && (enrollment.StudentId == filter.StudentId ||
+			enrollment.GroupId != 0 && enrollment.GroupId == filter.StudentGroupId) {
+			byStudent = true
+		}
+		if filter.GroupId != 0 && enrollment.GroupId == filter.GroupId {
+			byGroup = true
+		}
+	}
+
+	return byStudent && byGroup
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+)
+
+const courseColumns = "id, code, title"
+
+type CourseRepoPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewCourseRepoPostgres(db *pgxpool.Pool) *CourseRepoPostgres {
+	return &CourseRepoPostgres{
+		db: db,
+	}
+}
+
+func (repo *CourseRepoPostgres) GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, courseSortColumns)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+	after, err := decodeCursor(page, courseSortColumns)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+
+	builder := newCourseListSQL(tenant.IdFrom(ctx), query.CourseFilter, postgresDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("course")
+	if err := database.QueryRow(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.CoursePage{}, convertPostgresError(err)
+	}
+
+	listSQL, listArgs := builder.page("course", courseColumns, page, after)
+	rows, err := database.Query(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.CoursePage{}, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var courses []domain.Course
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return dto.CoursePage{}, err
+		}
+		courses = append(courses, course)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.CoursePage{}, convertPostgresError(err)
+	}
+
+	courses, nextCursor := trimPage(courses, page, courseSortValue, courseId)
+
+	return dto.CoursePage{
+		Courses:    courses,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *CourseRepoPostgres) Create(ctx context.Context, course domain.Course) (domain.Course, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	created, err := scanCourse(database.QueryRow(ctx,
+		"insert into course(tenant_id, code, title) values($1, $2, $3) returning "+courseColumns,
+		tenant.IdFrom(ctx), course.Code, course.Title))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Course{}, convertPostgresError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *CourseRepoPostgres) GetById(ctx context.Context, id int64) (domain.Course, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	course, err := scanCourse(database.QueryRow(ctx,
+		"select "+courseColumns+" from course where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Course{}, convertPostgresError(err)
+	}
+
+	return course, nil
+}
+
+func (repo *CourseRepoPostgres) Update(ctx context.Context, course domain.Course) (domain.Course, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	updated, err := scanCourse(database.QueryRow(ctx,
+		"update course set code = $1, title = $2 where id = $3 and tenant_id = $4 returning "+courseColumns,
+		course.Code, course.Title, course.Id, tenant.IdFrom(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or course doesn't exists", "err", err)
+		return domain.Course{}, convertPostgresError(err)
+	}
+
+	return updated, nil
+}
+
+func (repo *CourseRepoPostgres) DeleteById(ctx context.Context, id int64) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx, "delete from course where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
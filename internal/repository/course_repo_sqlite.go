@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+type CourseRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewCourseRepoSQLite(db *sql.DB) *CourseRepoSQLite {
+	return &CourseRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *CourseRepoSQLite) GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, courseSortColumns)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+	after, err := decodeCursor(page, courseSortColumns)
+	if err != nil {
+		return dto.CoursePage{}, err
+	}
+
+	builder := newCourseListSQL(tenant.IdFrom(ctx), query.CourseFilter, sqliteDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("course")
+	if err := database.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.CoursePage{}, convertSQLiteError(err)
+	}
+
+	listSQL, listArgs := builder.page("course", courseColumns, page, after)
+	rows, err := database.QueryContext(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.CoursePage{}, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var courses []domain.Course
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return dto.CoursePage{}, err
+		}
+		courses = append(courses, course)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.CoursePage{}, convertSQLiteError(err)
+	}
+
+	courses, nextCursor := trimPage(courses, page, courseSortValue, courseId)
+
+	return dto.CoursePage{
+		Courses:    courses,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *CourseRepoSQLite) Create(ctx context.Context, course domain.Course) (domain.Course, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	created, err := scanCourse(database.QueryRowContext(ctx,
+		"insert into course(tenant_id, code, title) values(?, ?, ?) returning "+courseColumns,
+		tenant.IdFrom(ctx), course.Code, course.Title))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Course{}, convertSQLiteError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *CourseRepoSQLite) GetById(ctx context.Context, id int64) (domain.Course, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	course, err := scanCourse(database.QueryRowContext(ctx,
+		"select "+courseColumns+" from course where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Course{}, convertSQLiteError(err)
+	}
+
+	return course, nil
+}
+
+func (repo *CourseRepoSQLite) Update(ctx context.Context, course domain.Course) (domain.Course, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	updated, err := scanCourse(database.QueryRowContext(ctx,
+		"update course set code = ?, title = ? where id = ? and tenant_id = ? returning "+courseColumns,
+		course.Code, course.Title, course.Id, tenant.IdFrom(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or course doesn't exists", "err", err)
+		return domain.Course{}, convertSQLiteError(err)
+	}
+
+	return updated, nil
+}
+
+func (repo *CourseRepoSQLite) DeleteById(ctx context.Context, id int64) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx, "delete from course where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
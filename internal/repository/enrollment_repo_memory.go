@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+type EnrollmentRepoMemory struct {
+	lock        *memoryLock
+	lastId      int64
+	enrollments map[int64]domain.Enrollment
+	// enrollment id -> tenant id
+	tenants map[int64]string
+	// checked for references and read for group numbers, they share the lock
+	courses  *CourseRepoMemory
+	students *StudentRepoMemory
+	groups   *GroupRepoMemory
+}
+
+func newEnrollmentRepoMemory(lock *memoryLock, courses *CourseRepoMemory, students *StudentRepoMemory,
+	groups *GroupRepoMemory) *EnrollmentRepoMemory {
+	return &EnrollmentRepoMemory{
+		lock:        lock,
+		enrollments: make(map[int64]domain.Enrollment),
+		tenants:     make(map[int64]string),
+		courses:     courses,
+		students:    students,
+		groups:      groups,
+	}
+}
+
+func (repo *EnrollmentRepoMemory) GetAll(ctx context.Context,
+	query dto.EnrollmentQuery) (dto.EnrollmentPage, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+	defer release()
+
+	page, err := checkPage(query.PageRequest, enrollmentSortColumns)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+	after, err := decodeCursor(page, enrollmentSortColumns)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+
+	tenantId := tenant.IdFrom(ctx)
+	var enrollments []domain.Enrollment
+	for id, enrollment := range repo.live() {
+		if repo.tenants[id] != tenantId || !repo.enrollmentMatches(enrollment, query.EnrollmentFilter) {
+			continue
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	total := int64(len(enrollments))
+
+	sort.Slice(enrollments, func(i, j int) bool {
+		if page.SortDirection == dto.SortDesc {
+			return enrollments[i].Id > enrollments[j].Id
+		}
+		return enrollments[i].Id < enrollments[j].Id
+	})
+
+	enrollments, nextCursor := pageSlice(enrollments, page, after, enrollmentSortValue, enrollmentId)
+
+	return dto.EnrollmentPage{
+		Enrollments: enrollments,
+		NextCursor:  nextCursor,
+		Total:       total,
+	}, nil
+}
+
+func (repo *EnrollmentRepoMemory) Create(ctx context.Context,
+	enrollment domain.Enrollment) (domain.Enrollment, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Enrollment{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	// the foreign keys of the enrollment
+	if _, ok := repo.courses.courses[enrollment.CourseId]; !ok || repo.courses.tenants[enrollment.CourseId] != tenantId {
+		return domain.Enrollment{}, ErrConflict
+	}
+	if enrollment.GroupId != 0 && repo.students.groupMissing(tenantId, enrollment.GroupId) {
+		return domain.Enrollment{}, ErrConflict
+	}
+	if _, ok := repo.students.students[enrollment.StudentId]; enrollment.StudentId != 0 &&
+		(!ok || repo.students.tenants[enrollment.StudentId] != tenantId) {
+		return domain.Enrollment{}, ErrConflict
+	}
+	// and the unique constraints on the course with the group or the student
+	for _, existing := range repo.live() {
+		if existing.CourseId == enrollment.CourseId &&
+			(enrollment.GroupId != 0 && existing.GroupId == enrollment.GroupId ||
+				enrollment.StudentId != 0 && existing.StudentId == enrollment.StudentId) {
+			return domain.Enrollment{}, ErrConflict
+		}
+	}
+
+	repo.lastId++
+	enrollment.Id = repo.lastId
+	repo.enrollments[enrollment.Id] = enrollment
+	repo.tenants[enrollment.Id] = tenantId
+
+	return enrollment, nil
+}
+
+func (repo *EnrollmentRepoMemory) GetById(ctx context.Context, id int64) (domain.Enrollment, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Enrollment{}, err
+	}
+	defer release()
+
+	enrollment, ok := repo.live()[id]
+	if !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return domain.Enrollment{}, ErrNotFound
+	}
+
+	return enrollment, nil
+}
+
+func (repo *EnrollmentRepoMemory) UpdateStatus(ctx context.Context, id int64, status string,
+	at time.Time) (domain.Enrollment, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Enrollment{}, err
+	}
+	defer release()
+
+	enrollment, ok := repo.live()[id]
+	if !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return domain.Enrollment{}, ErrNotFound
+	}
+	enrollment.Status = status
+	enrollment.UpdatedAt = at
+	repo.enrollments[id] = enrollment
+
+	return enrollment, nil
+}
+
+func (repo *EnrollmentRepoMemory) snapshot() func() {
+	lastId := repo.lastId
+	enrollments := maps.Clone(repo.enrollments)
+	tenants := maps.Clone(repo.tenants)
+
+	return func() {
+		repo.lastId = lastId
+		repo.enrollments = enrollments
+		repo.tenants = tenants
+	}
+}
+
+// live returns the enrollments whose group or student still exists, the way
+// the database cascade drops the others. The caller holds the lock.
+func (repo *EnrollmentRepoMemory) live() map[int64]domain.Enrollment {
+	maps.DeleteFunc(repo.enrollments, func(id int64, enrollment domain.Enrollment) bool {
+		if enrollment.GroupId != 0 {
+			_, ok := repo.groups.groups[enrollment.GroupId]
+			return !ok
+		}
+		_, ok := repo.students.students[enrollment.StudentId]
+		return !ok
+	})
+	maps.DeleteFunc(repo.tenants, func(id int64, _ string) bool {
+		_, ok := repo.enrollments[id]
+		return !ok
+	})
+
+	return repo.enrollments
+}
+
+// groupNumber mirrors the group_number column of enrollment_view
+func (repo *EnrollmentRepoMemory) groupNumber(enrollment domain.Enrollment) string {
+	if enrollment.GroupId != 0 {
+		return repo.groups.groups[enrollment.GroupId].GroupNumber
+	}
+
+	return repo.students.withGroup(repo.students.students[enrollment.StudentId]).GroupNumber
+}
+
+// enrollmentMatches mirrors the filter conditions of newEnrollmentListSQL
+func (repo *EnrollmentRepoMemory) enrollmentMatches(enrollment domain.Enrollment,
+	filter dto.EnrollmentFilter) bool {
+	if filter.CourseId != 0 && enrollment.CourseId != filter.CourseId {
+		return false
+	}
+	if filter.Status != "" && enrollment.Status != filter.Status {
+		return false
+	}
+	if filter.GroupId != 0 && enrollment.GroupId != filter.GroupId {
+		return false
+	}
+	if filter.StudentId != 0 && enrollment.StudentId != filter.StudentId {
+		return false
+	}
+	if len(filter.GroupNumberIn) > 0 && !slices.Contains(filter.GroupNumberIn, repo.groupNumber(enrollment)) {
+		return false
+	}
+	if filter.ElectivesOf != 0 && enrollment.StudentId != 0 && enrollment.StudentId != filter.ElectivesOf {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+	"time"
+)
+
+const enrollmentColumns = "id, course_id, group_id, student_id, status, enrolled_at, updated_at"
+
+type EnrollmentRepoPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewEnrollmentRepoPostgres(db *pgxpool.Pool) *EnrollmentRepoPostgres {
+	return &EnrollmentRepoPostgres{
+		db: db,
+	}
+}
+
+func (repo *EnrollmentRepoPostgres) GetAll(ctx context.Context,
+	query dto.EnrollmentQuery) (dto.EnrollmentPage, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, enrollmentSortColumns)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+	after, err := decodeCursor(page, enrollmentSortColumns)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+
+	builder := newEnrollmentListSQL(tenant.IdFrom(ctx), query.EnrollmentFilter, postgresDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("enrollment_view")
+	if err := database.QueryRow(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.EnrollmentPage{}, convertPostgresError(err)
+	}
+
+	listSQL, listArgs := builder.page("enrollment_view", enrollmentColumns, page, after)
+	rows, err := database.Query(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.EnrollmentPage{}, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var enrollments []domain.Enrollment
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return dto.EnrollmentPage{}, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.EnrollmentPage{}, convertPostgresError(err)
+	}
+
+	enrollments, nextCursor := trimPage(enrollments, page, enrollmentSortValue, enrollmentId)
+
+	return dto.EnrollmentPage{
+		Enrollments: enrollments,
+		NextCursor:  nextCursor,
+		Total:       total,
+	}, nil
+}
+
+func (repo *EnrollmentRepoPostgres) Create(ctx context.Context,
+	enrollment domain.Enrollment) (domain.Enrollment, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	created, err := scanEnrollment(database.QueryRow(ctx,
+		"insert into enrollment(tenant_id, course_id, group_id, student_id, status, enrolled_at, updated_at) "+
+			"values($1, $2, $3, $4, $5, $6, $7) returning "+enrollmentColumns,
+		tenant.IdFrom(ctx), enrollment.CourseId, nullableId(enrollment.GroupId), nullableId(enrollment.StudentId),
+		enrollment.Status, enrollment.EnrolledAt, enrollment.UpdatedAt))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Enrollment{}, convertPostgresError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *EnrollmentRepoPostgres) GetById(ctx context.Context, id int64) (domain.Enrollment, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	enrollment, err := scanEnrollment(database.QueryRow(ctx,
+		"select "+enrollmentColumns+" from enrollment where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Enrollment{}, convertPostgresError(err)
+	}
+
+	return enrollment, nil
+}
+
+func (repo *EnrollmentRepoPostgres) UpdateStatus(ctx context.Context, id int64, status string,
+	at time.Time) (domain.Enrollment, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	updated, err := scanEnrollment(database.QueryRow(ctx,
+		"update enrollment set status = $1, updated_at = $2 where id = $3 and tenant_id = $4 returning "+
+			enrollmentColumns,
+		status, at, id, tenant.IdFrom(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or enrollment doesn't exists", "err", err)
+		return domain.Enrollment{}, convertPostgresError(err)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+type EnrollmentRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewEnrollmentRepoSQLite(db *sql.DB) *EnrollmentRepoSQLite {
+	return &EnrollmentRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *EnrollmentRepoSQLite) GetAll(ctx context.Context,
+	query dto.EnrollmentQuery) (dto.EnrollmentPage, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, enrollmentSortColumns)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+	after, err := decodeCursor(page, enrollmentSortColumns)
+	if err != nil {
+		return dto.EnrollmentPage{}, err
+	}
+
+	builder := newEnrollmentListSQL(tenant.IdFrom(ctx), query.EnrollmentFilter, sqliteDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("enrollment_view")
+	if err := database.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.EnrollmentPage{}, convertSQLiteError(err)
+	}
+
+	listSQL, listArgs := builder.page("enrollment_view", enrollmentColumns, page, after)
+	rows, err := database.QueryContext(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.EnrollmentPage{}, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var enrollments []domain.Enrollment
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return dto.EnrollmentPage{}, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.EnrollmentPage{}, convertSQLiteError(err)
+	}
+
+	enrollments, nextCursor := trimPage(enrollments, page, enrollmentSortValue, enrollmentId)
+
+	return dto.EnrollmentPage{
+		Enrollments: enrollments,
+		NextCursor:  nextCursor,
+		Total:       total,
+	}, nil
+}
+
+func (repo *EnrollmentRepoSQLite) Create(ctx context.Context,
+	enrollment domain.Enrollment) (domain.Enrollment, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	created, err := scanEnrollment(database.QueryRowContext(ctx,
+		"insert into enrollment(tenant_id, course_id, group_id, student_id, status, enrolled_at, updated_at) "+
+			"values(?, ?, ?, ?, ?, ?, ?) returning "+enrollmentColumns,
+		tenant.IdFrom(ctx), enrollment.CourseId, nullableId(enrollment.GroupId), nullableId(enrollment.StudentId),
+		enrollment.Status, enrollment.EnrolledAt.UTC(), enrollment.UpdatedAt.UTC()))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Enrollment{}, convertSQLiteError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *EnrollmentRepoSQLite) GetById(ctx context.Context, id int64) (domain.Enrollment, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	enrollment, err := scanEnrollment(database.QueryRowContext(ctx,
+		"select "+enrollmentColumns+" from enrollment where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Enrollment{}, convertSQLiteError(err)
+	}
+
+	return enrollment, nil
+}
+
+func (repo *EnrollmentRepoSQLite) UpdateStatus(ctx context.Context, id int64, status string,
+	at time.Time) (domain.Enrollment, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	updated, err := scanEnrollment(database.QueryRowContext(ctx,
+		"update enrollment set status = ?, updated_at = ? where id = ? and tenant_id = ? returning "+
+			enrollmentColumns,
+		status, at.UTC(), id, tenant.IdFrom(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or enrollment doesn't exists", "err", err)
+		return domain.Enrollment{}, convertSQLiteError(err)
+	}
+
+	return updated, nil
+}
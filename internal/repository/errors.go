@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	ErrConflict = errors.New("record already exists")
+	// ErrReferenceNotFound is returned by writes of rows that refer to a
+	// record that doesn't exist, e.g. a student to a deleted group
+	ErrReferenceNotFound = errors.New("referenced record not found")
+)
+
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// convertPostgresError translates driver errors into the repository errors,
+// so callers don't have to know anything about pgx
+func convertPostgresError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == pgUniqueViolation || pgErr.Code == pgForeignKeyViolation) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+// convertPostgresWriteError is convertPostgresError for inserts and updates of
+// rows with foreign keys, a violated one means the referenced row is missing
+func convertPostgresWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return ErrReferenceNotFound
+	}
+
+	return convertPostgresError(err)
+}
+
+func convertSQLiteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var sqliteErr *sqlite.Error
+	// text primary keys, unlike integer ones, report their own constraint code
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+		sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY ||
+		sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+// convertSQLiteWriteError is the SQLite counterpart of convertPostgresWriteError
+func convertSQLiteWriteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return ErrReferenceNotFound
+	}
+
+	return convertSQLiteError(err)
+}
+
+func checkRowsAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
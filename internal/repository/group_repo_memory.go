@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type GroupRepoMemory struct {
+	lock   *memoryLock
+	lastId int64
+	groups map[int64]domain.Group
+	// group id -> tenant id
+	tenants map[int64]string
+	// checked for references and read for rosters, shares the lock
+	students *StudentRepoMemory
+}
+
+func NewGroupRepoMemory() *GroupRepoMemory {
+	_, groups := newStudentAndGroupRepoMemory(&memoryLock{})
+	return groups
+}
+
+func newGroupRepoMemory(lock *memoryLock, students *StudentRepoMemory) *GroupRepoMemory {
+	return &GroupRepoMemory{
+		lock:     lock,
+		groups:   make(map[int64]domain.Group),
+		tenants:  make(map[int64]string),
+		students: students,
+	}
+}
+
+func (repo *GroupRepoMemory) GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+	defer release()
+
+	page, err := checkPage(query.PageRequest, groupSortColumns)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+	after, err := decodeCursor(page, groupSortColumns)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+
+	tenantId := tenant.IdFrom(ctx)
+	var groups []domain.Group
+	for id, group := range repo.groups {
+		if repo.tenants[id] != tenantId || !groupMatches(group, query.GroupFilter) {
+			continue
+		}
+		groups = append(groups, group)
+	}
+	total := int64(len(groups))
+
+	sort.Slice(groups, func(i, j int) bool {
+		order := compareValues(groupSortValue(groups[i], page.SortBy), groupSortValue(groups[j], page.SortBy))
+		if order == 0 {
+			order = compareValues(groups[i].Id, groups[j].Id)
+		}
+		if page.SortDirection == dto.SortDesc {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	groups, nextCursor := pageSlice(groups, page, after, groupSortValue, groupId)
+
+	return dto.GroupPage{
+		Groups:     groups,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *GroupRepoMemory) Create(ctx context.Context, group domain.Group) (domain.Group, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if repo.groupNumberTaken(tenantId, group.GroupNumber, 0) {
+		return domain.Group{}, ErrConflict
+	}
+
+	repo.lastId++
+	group.Id = repo.lastId
+	repo.groups[group.Id] = group
+	repo.tenants[group.Id] = tenantId
+
+	return group, nil
+}
+
+func (repo *GroupRepoMemory) GetById(ctx context.Context, id int64) (domain.Group, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	defer release()
+
+	group, ok := repo.groups[id]
+	if !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return domain.Group{}, ErrNotFound
+	}
+
+	return group, nil
+}
+
+func (repo *GroupRepoMemory) Update(ctx context.Context, group domain.Group) (domain.Group, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if _, ok := repo.groups[group.Id]; !ok || repo.tenants[group.Id] != tenantId {
+		return domain.Group{}, ErrNotFound
+	}
+	if repo.groupNumberTaken(tenantId, group.GroupNumber, group.Id) {
+		return domain.Group{}, ErrConflict
+	}
+
+	repo.groups[group.Id] = group
+
+	return group, nil
+}
+
+func (repo *GroupRepoMemory) DeleteById(ctx context.Context, id int64) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, ok := repo.groups[id]; !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return ErrNotFound
+	}
+	// the foreign key of the students
+	for _, student := range repo.students.students {
+		if student.GroupId == id {
+			return ErrConflict
+		}
+	}
+	delete(repo.groups, id)
+	delete(repo.tenants, id)
+
+	return nil
+}
+
+func (repo *GroupRepoMemory) GetByGroupNumber(ctx context.Context, groupNumber string) (domain.Group, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Group{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	for id, group := range repo.groups {
+		if repo.tenants[id] == tenantId && group.GroupNumber == groupNumber {
+			return group, nil
+		}
+	}
+
+	return domain.Group{}, ErrNotFound
+}
+
+func (repo *GroupRepoMemory) snapshot() func() {
+	lastId := repo.lastId
+	groups := make(map[int64]domain.Group, len(repo.groups))
+	for id, group := range repo.groups {
+		groups[id] = group
+	}
+	tenants := maps.Clone(repo.tenants)
+
+	return func() {
+		repo.lastId = lastId
+		repo.groups = groups
+		repo.tenants = tenants
+	}
+}
+
+func (repo *GroupRepoMemory) GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	group, ok := repo.groups[id]
+	if !ok || repo.tenants[id] != tenantId {
+		return dto.GroupRoster{}, ErrNotFound
+	}
+
+	page, err := repo.students.list(tenantId, query, func(student domain.Student) bool {
+		return student.GroupId == group.Id
+	})
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+	if page.Students == nil {
+		page.Students = []domain.Student{}
+	}
+
+	return dto.GroupRoster{
+		Group:      group,
+		Students:   page.Students,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}, nil
+}
+
+// groupNumberTaken mirrors the unique constraint on tenant and group number
+func (repo *GroupRepoMemory) groupNumberTaken(tenantId, groupNumber string, exceptId int64) bool {
+	for id, group := range repo.groups {
+		if id != exceptId && repo.tenants[id] == tenantId && group.GroupNumber == groupNumber {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupMatches mirrors the filter conditions of newGroupListSQL
+func groupMatches(group domain.Group, filter dto.GroupFilter) bool {
+	if filter.NumberContains != "" &&
+		!strings.Contains(strings.ToLower(group.GroupNumber), strings.ToLower(filter.NumberContains)) {
+		return false
+	}
+	if len(filter.GroupNumberIn) > 0 && !slices.Contains(filter.GroupNumberIn, group.GroupNumber) {
+		return false
+	}
+
+	return true
+}
@@ -2,13 +2,15 @@ package repository
 
 import (
 	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
 	"context"
-	"fmt"
-	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
+	"log/slog"
 )
 
+const groupColumns = "id, group_number, capacity, waitlist"
+
 type GroupRepoPostgres struct {
 	db *pgxpool.Pool
 }
@@ -19,70 +21,167 @@ func NewGroupRepoPostgres(db *pgxpool.Pool) *GroupRepoPostgres {
 	}
 }
 
-func (repo *GroupRepoPostgres) GetAll(ctx context.Context) (pgx.Rows, error) {
-	database := repo.db
+func (repo *GroupRepoPostgres) GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, groupSortColumns)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+	after, err := decodeCursor(page, groupSortColumns)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+
+	builder := newGroupListSQL(tenant.IdFrom(ctx), query.GroupFilter, postgresDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("\"group\"")
+	if err := database.QueryRow(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.GroupPage{}, convertPostgresError(err)
+	}
 
-	groups, err := database.Query(ctx,
-		"select * from \"group\" order by id")
+	listSQL, listArgs := builder.page("\"group\"", groupColumns, page, after)
+	rows, err := database.Query(ctx, listSQL, listArgs...)
 	if err != nil {
-		log.Printf("%s: query executement", err)
-		return nil, err
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.GroupPage{}, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var groups []domain.Group
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return dto.GroupPage{}, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.GroupPage{}, convertPostgresError(err)
 	}
 
-	return groups, err
+	groups, nextCursor := trimPage(groups, page, groupSortValue, groupId)
 
+	return dto.GroupPage{
+		Groups:     groups,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
 }
 
-func (repo *GroupRepoPostgres) Create(ctx context.Context, group domain.Group) (pgx.Rows, error) {
-	database := repo.db
+func (repo *GroupRepoPostgres) Create(ctx context.Context, group domain.Group) (domain.Group, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
 
-	_, err := database.Query(ctx,
-		"insert into \"group\"(group_number) values($1)",
-		group.GroupNumber)
+	created, err := scanGroup(database.QueryRow(ctx,
+		"insert into \"group\"(tenant_id, group_number, capacity, waitlist) values($1, $2, $3, $4) returning "+
+			groupColumns,
+		tenant.IdFrom(ctx), group.GroupNumber, group.Capacity, group.Waitlist))
 	if err != nil {
-		log.Printf("%s: query executement", err)
-		return nil, err
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Group{}, convertPostgresError(err)
 	}
-	groupRows, err := database.Query(ctx, "select * from \"group\" where group_number = $1", group.GroupNumber)
 
-	return groupRows, err
+	return created, nil
 }
-func (repo *GroupRepoPostgres) GetById(ctx context.Context, id int64) pgx.Row {
-	database := repo.db
 
-	group := database.QueryRow(ctx,
-		"select * from \"group\" where id = $1", id)
+func (repo *GroupRepoPostgres) GetById(ctx context.Context, id int64) (domain.Group, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	group, err := scanGroup(database.QueryRow(ctx,
+		"select "+groupColumns+" from \"group\" where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Group{}, convertPostgresError(err)
+	}
 
-	return group
+	return group, nil
 }
-func (repo *GroupRepoPostgres) Update(ctx context.Context, group domain.Group) (pgx.Rows, error) {
-	database := repo.db
 
-	_, err := database.Query(ctx,
-		"update \"group\" set group_number = $1", group.GroupNumber)
+func (repo *GroupRepoPostgres) Update(ctx context.Context, group domain.Group) (domain.Group, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	updated, err := scanGroup(database.QueryRow(ctx,
+		"update \"group\" set group_number = $1, capacity = $2, waitlist = $3 where id = $4 and tenant_id = $5 returning "+
+			groupColumns,
+		group.GroupNumber, group.Capacity, group.Waitlist, group.Id, tenant.IdFrom(ctx)))
 	if err != nil {
-		log.Printf("%s: query executement or group doesn't exists", err)
-		return nil, err
+		slog.ErrorContext(ctx, "query executement or group doesn't exists", "err", err)
+		return domain.Group{}, convertPostgresError(err)
 	}
-	groupRows, err := database.Query(ctx, "select * from \"group\" where id = $1", group.Id)
 
-	return groupRows, err
+	return updated, nil
 }
+
 func (repo *GroupRepoPostgres) DeleteById(ctx context.Context, id int64) error {
-	database := repo.db
-	_, err := database.Exec(ctx, "delete from \"group\" where id = $1", id)
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx, "delete from \"group\" where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *GroupRepoPostgres) GetByGroupNumber(ctx context.Context, groupNumber string) (domain.Group, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	group, err := scanGroup(database.QueryRow(ctx,
+		"select "+groupColumns+" from \"group\" where group_number = $1 and tenant_id = $2",
+		groupNumber, tenant.IdFrom(ctx)))
 	if err != nil {
-		fmt.Errorf("%s: query executement in deletion", err)
-		return err
+		return domain.Group{}, convertPostgresError(err)
 	}
-	return err
+
+	return group, nil
 }
 
-func (repo *GroupRepoPostgres) GetByGroupNumber(ctx context.Context, groupNumber string) pgx.Row {
-	database := repo.db
+func (repo *GroupRepoPostgres) GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, studentSortColumns)
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+	after, err := decodeCursor(page, studentSortColumns)
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+
+	statement, args := rosterSQL(tenant.IdFrom(ctx), id, query.StudentFilter, page, after, postgresDialect)
+	rows, err := database.Query(ctx, statement, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.GroupRoster{}, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var roster dto.GroupRoster
+	found := false
+	students := []domain.Student{}
+	for rows.Next() {
+		group, total, student, err := scanRosterRow(rows)
+		if err != nil {
+			return dto.GroupRoster{}, err
+		}
+		roster.Group, roster.Total, found = group, total, true
+		if student != nil {
+			students = append(students, *student)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return dto.GroupRoster{}, convertPostgresError(err)
+	}
+	if !found {
+		return dto.GroupRoster{}, ErrNotFound
+	}
 
-	group := database.QueryRow(ctx,
-		"select * from \"group\" where group_number = $1", groupNumber)
+	roster.Students, roster.NextCursor = trimPage(students, page, studentSortValue, studentId)
 
-	return group
+	return roster, nil
 }
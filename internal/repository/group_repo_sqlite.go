@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+type GroupRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewGroupRepoSQLite(db *sql.DB) *GroupRepoSQLite {
+	return &GroupRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *GroupRepoSQLite) GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, groupSortColumns)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+	after, err := decodeCursor(page, groupSortColumns)
+	if err != nil {
+		return dto.GroupPage{}, err
+	}
+
+	builder := newGroupListSQL(tenant.IdFrom(ctx), query.GroupFilter, sqliteDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("\"group\"")
+	if err := database.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.GroupPage{}, convertSQLiteError(err)
+	}
+
+	listSQL, listArgs := builder.page("\"group\"", groupColumns, page, after)
+	rows, err := database.QueryContext(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.GroupPage{}, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var groups []domain.Group
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return dto.GroupPage{}, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.GroupPage{}, convertSQLiteError(err)
+	}
+
+	groups, nextCursor := trimPage(groups, page, groupSortValue, groupId)
+
+	return dto.GroupPage{
+		Groups:     groups,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *GroupRepoSQLite) Create(ctx context.Context, group domain.Group) (domain.Group, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	created, err := scanGroup(database.QueryRowContext(ctx,
+		"insert into \"group\"(tenant_id, group_number, capacity, waitlist) values(?, ?, ?, ?) returning "+
+			groupColumns,
+		tenant.IdFrom(ctx), group.GroupNumber, group.Capacity, group.Waitlist))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Group{}, convertSQLiteError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *GroupRepoSQLite) GetById(ctx context.Context, id int64) (domain.Group, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	group, err := scanGroup(database.QueryRowContext(ctx,
+		"select "+groupColumns+" from \"group\" where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Group{}, convertSQLiteError(err)
+	}
+
+	return group, nil
+}
+
+func (repo *GroupRepoSQLite) Update(ctx context.Context, group domain.Group) (domain.Group, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	updated, err := scanGroup(database.QueryRowContext(ctx,
+		"update \"group\" set group_number = ?, capacity = ?, waitlist = ? where id = ? and tenant_id = ? returning "+
+			groupColumns,
+		group.GroupNumber, group.Capacity, group.Waitlist, group.Id, tenant.IdFrom(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or group doesn't exists", "err", err)
+		return domain.Group{}, convertSQLiteError(err)
+	}
+
+	return updated, nil
+}
+
+func (repo *GroupRepoSQLite) DeleteById(ctx context.Context, id int64) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx, "delete from \"group\" where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (repo *GroupRepoSQLite) GetByGroupNumber(ctx context.Context, groupNumber string) (domain.Group, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	group, err := scanGroup(database.QueryRowContext(ctx,
+		"select "+groupColumns+" from \"group\" where group_number = ? and tenant_id = ?",
+		groupNumber, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Group{}, convertSQLiteError(err)
+	}
+
+	return group, nil
+}
+
+func (repo *GroupRepoSQLite) GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, studentSortColumns)
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+	after, err := decodeCursor(page, studentSortColumns)
+	if err != nil {
+		return dto.GroupRoster{}, err
+	}
+
+	statement, args := rosterSQL(tenant.IdFrom(ctx), id, query.StudentFilter, page, after, sqliteNumberedDialect)
+	rows, err := database.QueryContext(ctx, statement, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.GroupRoster{}, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var roster dto.GroupRoster
+	found := false
+	students := []domain.Student{}
+	for rows.Next() {
+		group, total, student, err := scanRosterRow(rows)
+		if err != nil {
+			return dto.GroupRoster{}, err
+		}
+		roster.Group, roster.Total, found = group, total, true
+		if student != nil {
+			students = append(students, *student)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return dto.GroupRoster{}, convertSQLiteError(err)
+	}
+	if !found {
+		return dto.GroupRoster{}, ErrNotFound
+	}
+
+	roster.Students, roster.NextCursor = trimPage(students, page, studentSortValue, studentId)
+
+	return roster, nil
+}
@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"StudentManager/internal/repository"
+	"StudentManager/internal/repository/repotest"
+	"testing"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		return repository.NewMemoryRepositories()
+	})
+}
@@ -0,0 +1,508 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+type columnKind int
+
+const (
+	intColumn columnKind = iota
+	textColumn
+)
+
+var studentSortColumns = map[string]columnKind{
+	"id":           intColumn,
+	"full_name":    textColumn,
+	"age":          intColumn,
+	"group_number": textColumn,
+	"email":        textColumn,
+}
+
+var groupSortColumns = map[string]columnKind{
+	"id":           intColumn,
+	"group_number": textColumn,
+}
+
+var courseSortColumns = map[string]columnKind{
+	"id":    intColumn,
+	"code":  textColumn,
+	"title": textColumn,
+}
+
+var enrollmentSortColumns = map[string]columnKind{
+	"id": intColumn,
+}
+
+// cursor points right after the last row of a page. It remembers the sort it
+// was made for, so it can't be reused with a different one.
+type cursor struct {
+	SortBy    string `json:"s"`
+	Direction string `json:"d"`
+	Value     any    `json:"v"`
+	Id        int64  `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor returns nil when page has no cursor
+func decodeCursor(page dto.PageRequest, columns map[string]columnKind) (*cursor, error) {
+	if page.Cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if c.SortBy != page.SortBy || c.Direction != page.SortDirection {
+		return nil, fmt.Errorf("%w: cursor was made for a different sort", ErrInvalidQuery)
+	}
+
+	switch columns[c.SortBy] {
+	case intColumn:
+		number, ok := c.Value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+		value, err := number.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+		c.Value = value
+	case textColumn:
+		if _, ok := c.Value.(string); !ok {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+	}
+
+	return &c, nil
+}
+
+// checkPage fills in the default sort and rejects anything that can't be turned into SQL safely
+func checkPage(page dto.PageRequest, columns map[string]columnKind) (dto.PageRequest, error) {
+	if page.SortBy == "" {
+		page.SortBy = "id"
+	}
+	if page.SortDirection == "" {
+		page.SortDirection = dto.SortAsc
+	}
+
+	if _, ok := columns[page.SortBy]; !ok {
+		return page, fmt.Errorf("%w: can't sort by %q", ErrInvalidQuery, page.SortBy)
+	}
+	if page.SortDirection != dto.SortAsc && page.SortDirection != dto.SortDesc {
+		return page, fmt.Errorf("%w: unknown sort direction %q", ErrInvalidQuery, page.SortDirection)
+	}
+	if page.Limit < 0 || page.Offset < 0 {
+		return page, fmt.Errorf("%w: limit and offset can't be negative", ErrInvalidQuery)
+	}
+	if page.Cursor != "" && page.Offset != 0 {
+		return page, fmt.Errorf("%w: cursor and offset can't be combined", ErrInvalidQuery)
+	}
+
+	return page, nil
+}
+
+func studentSortValue(student domain.Student, column string) any {
+	switch column {
+	case "full_name":
+		return student.FullName
+	case "age":
+		return int64(student.Age)
+	case "group_number":
+		return student.GroupNumber
+	case "email":
+		return student.Email
+	default:
+		return student.Id
+	}
+}
+
+func groupSortValue(group domain.Group, column string) any {
+	if column == "group_number" {
+		return group.GroupNumber
+	}
+
+	return group.Id
+}
+
+func courseSortValue(course domain.Course, column string) any {
+	switch column {
+	case "code":
+		return course.Code
+	case "title":
+		return course.Title
+	default:
+		return course.Id
+	}
+}
+
+func enrollmentSortValue(enrollment domain.Enrollment, _ string) any {
+	return enrollment.Id
+}
+
+// escapeLike makes s match literally inside a like pattern with escape '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// listSQL builds the select and count statements of a paginated list. The
+// same builder serves Postgres and SQLite, the dialect covers what differs.
+type listSQL struct {
+	dialect
+	conditions []string
+	args       []any
+}
+
+type dialect struct {
+	placeholder func(n int) string
+	// offsetNeedsLimit is set for SQLite, which doesn't accept an offset
+	// without a limit. Postgres rejects the negative limit SQLite wants there.
+	offsetNeedsLimit bool
+}
+
+var (
+	postgresDialect = dialect{placeholder: postgresPlaceholder}
+	sqliteDialect   = dialect{placeholder: sqlitePlaceholder, offsetNeedsLimit: true}
+	// sqliteNumberedDialect lets a statement use the same value in several places
+	sqliteNumberedDialect = dialect{placeholder: sqliteNumberedPlaceholder, offsetNeedsLimit: true}
+)
+
+func postgresPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func sqlitePlaceholder(int) string {
+	return "?"
+}
+
+func sqliteNumberedPlaceholder(n int) string {
+	return fmt.Sprintf("?%d", n)
+}
+
+// where adds a condition, every %s in it is replaced with a placeholder for the next value
+func (b *listSQL) where(condition string, values ...any) {
+	placeholders := make([]any, 0, len(values))
+	for _, value := range values {
+		b.args = append(b.args, value)
+		placeholders = append(placeholders, b.placeholder(len(b.args)))
+	}
+
+	b.conditions = append(b.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+func (b *listSQL) whereIn(column string, values []string) {
+	placeholders := strings.TrimSuffix(strings.Repeat("%s, ", len(values)), ", ")
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	b.where(column+" in ("+placeholders+")", args...)
+}
+
+func (b *listSQL) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return " where " + strings.Join(b.conditions, " and ")
+}
+
+// count has to be called before page, which adds the keyset condition
+func (b *listSQL) count(table string) (string, []any) {
+	return "select count(*) from " + table + b.whereClause(), append([]any(nil), b.args...)
+}
+
+// page fetches one row more than the limit to find out whether there is a next page
+func (b *listSQL) page(table, columns string, page dto.PageRequest, after *cursor) (string, []any) {
+	comparison := ">"
+	if page.SortDirection == dto.SortDesc {
+		comparison = "<"
+	}
+
+	if after != nil {
+		if page.SortBy == "id" {
+			b.where("id "+comparison+" %s", after.Id)
+		} else {
+			b.where("("+page.SortBy+" "+comparison+" %s or ("+page.SortBy+" = %s and id "+comparison+" %s))",
+				after.Value, after.Value, after.Id)
+		}
+	}
+
+	query := "select " + columns + " from " + table + b.whereClause() +
+		" order by " + page.SortBy + " " + page.SortDirection
+	if page.SortBy != "id" {
+		query += ", id " + page.SortDirection
+	}
+	if page.Limit > 0 {
+		b.args = append(b.args, page.Limit+1)
+		query += " limit " + b.placeholder(len(b.args))
+	}
+	if page.Offset > 0 {
+		if page.Limit == 0 && b.offsetNeedsLimit {
+			query += " limit -1"
+		}
+		b.args = append(b.args, page.Offset)
+		query += " offset " + b.placeholder(len(b.args))
+	}
+
+	return query, b.args
+}
+
+// studentSetClause returns the "set" part of an update for the changed
+// columns, numbering placeholders from 1
+func studentSetClause(changes dto.StudentChanges, placeholder func(n int) string) (string, []any) {
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, column+" = "+placeholder(len(args)))
+	}
+
+	if changes.FullName != nil {
+		set("full_name", *changes.FullName)
+	}
+	if changes.Age != nil {
+		set("age", *changes.Age)
+	}
+	if changes.GroupId != nil {
+		set("group_id", nullableId(*changes.GroupId))
+	}
+	if changes.Email != nil {
+		set("email", *changes.Email)
+	}
+
+	return strings.Join(sets, ", "), args
+}
+
+func newStudentListSQL(tenantId string, filter dto.StudentFilter, d dialect) *listSQL {
+	b := &listSQL{dialect: d}
+	b.where("tenant_id = %s", tenantId)
+
+	if filter.GroupNumber != "" {
+		b.where("group_number = %s", filter.GroupNumber)
+	}
+	if filter.AgeMin > 0 {
+		b.where("age >= %s", filter.AgeMin)
+	}
+	if filter.AgeMax > 0 {
+		b.where("age <= %s", filter.AgeMax)
+	}
+	if filter.EmailDomain != "" {
+		b.where(`lower(email) like %s escape '\'`, "%@"+escapeLike(strings.ToLower(filter.EmailDomain)))
+	}
+	if filter.NameContains != "" {
+		// note that SQLite lower() only folds ASCII letters
+		b.where(`lower(full_name) like %s escape '\'`, "%"+escapeLike(strings.ToLower(filter.NameContains))+"%")
+	}
+	if len(filter.GroupNumberIn) > 0 {
+		b.whereIn("group_number", filter.GroupNumberIn)
+	}
+	if filter.Id != 0 {
+		b.where("id = %s", filter.Id)
+	}
+
+	return b
+}
+
+func newGroupListSQL(tenantId string, filter dto.GroupFilter, d dialect) *listSQL {
+	b := &listSQL{dialect: d}
+	b.where("tenant_id = %s", tenantId)
+
+	if filter.NumberContains != "" {
+		b.where(`lower(group_number) like %s escape '\'`, "%"+escapeLike(strings.ToLower(filter.NumberContains))+"%")
+	}
+	if len(filter.GroupNumberIn) > 0 {
+		b.whereIn("group_number", filter.GroupNumberIn)
+	}
+
+	return b
+}
+
+// newCourseListSQL selects the courses taken by a student or a group through
+// their active enrollments, a student takes those of its group too
+func newCourseListSQL(tenantId string, filter dto.CourseFilter, d dialect) *listSQL {
+	b := &listSQL{dialect: d}
+	b.where("tenant_id = %s", tenantId)
+
+	if filter.TitleContains != "" {
+		b.where(`lower(title) like %s escape '\'`, "%"+escapeLike(strings.ToLower(filter.TitleContains))+"%")
+	}
+	if filter.StudentId != 0 {
+		b.where("id in (select course_id from enrollment where tenant_id = %s and status = 'active'"+
+			" and (student_id = %s or group_id = %s))", tenantId, filter.StudentId, filter.StudentGroupId)
+	}
+	if filter.GroupId != 0 {
+		b.where("id in (select course_id from enrollment where tenant_id = %s and status = 'active'"+
+			" and group_id = %s)", tenantId, filter.GroupId)
+	}
+
+	return b
+}
+
+// newEnrollmentListSQL selects from enrollment_view, which knows the group
+// number every enrollment belongs to
+func newEnrollmentListSQL(tenantId string, filter dto.EnrollmentFilter, d dialect) *listSQL {
+	b := &listSQL{dialect: d}
+	b.where("tenant_id = %s", tenantId)
+
+	if filter.CourseId != 0 {
+		b.where("course_id = %s", filter.CourseId)
+	}
+	if filter.Status != "" {
+		b.where("status = %s", filter.Status)
+	}
+	if filter.GroupId != 0 {
+		b.where("group_id = %s", filter.GroupId)
+	}
+	if filter.StudentId != 0 {
+		b.where("student_id = %s", filter.StudentId)
+	}
+	if len(filter.GroupNumberIn) > 0 {
+		b.whereIn("group_number", filter.GroupNumberIn)
+	}
+	if filter.ElectivesOf != 0 {
+		b.where("(student_id is null or student_id = %s)", filter.ElectivesOf)
+	}
+
+	return b
+}
+
+// rosterSQL selects a group with one page of its students matching filter
+// and their total in a single statement. The group comes back once per
+// student on the page, or once with null student columns when the page is
+// empty. No rows at all means there is no such group. The placeholders of d
+// have to number their values, the count reuses those of the page.
+func rosterSQL(tenantId string, groupId int64, filter dto.StudentFilter, page dto.PageRequest, after *cursor,
+	d dialect) (string, []any) {
+	b := newStudentListSQL(tenantId, filter, d)
+	b.where("group_id = %s", groupId)
+
+	countSQL, _ := b.count("student_view")
+	pageSQL, args := b.page("student_view", studentColumns, page, after)
+	args = append(args, groupId, tenantId)
+
+	query := `select g.id, g.group_number, g.capacity, g.waitlist, (` + countSQL + `), s.id, s.full_name, s.age, s.group_id, s.group_number, s.email` +
+		` from "group" g left join (` + pageSQL + `) s on true` +
+		` where g.id = ` + d.placeholder(len(args)-1) + ` and g.tenant_id = ` + d.placeholder(len(args)) +
+		` order by s.` + page.SortBy + ` ` + page.SortDirection
+	if page.SortBy != "id" {
+		query += `, s.id ` + page.SortDirection
+	}
+
+	return query, args
+}
+
+// afterCursor reports whether a row with the given sort value and id comes after c
+func afterCursor(c *cursor, direction string, value any, id int64) bool {
+	order := compareValues(value, c.Value)
+	if order == 0 {
+		order = compareValues(id, c.Id)
+	}
+	if direction == dto.SortDesc {
+		return order < 0
+	}
+
+	return order > 0
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		return compareOrdered(a, b.(int64))
+	case string:
+		return compareOrdered(a, b.(string))
+	}
+
+	return 0
+}
+
+func compareOrdered[T int64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// pageSlice applies offset, cursor and limit to sorted items the same way the SQL does
+func pageSlice[T any](items []T, page dto.PageRequest, after *cursor,
+	sortValue func(T, string) any, id func(T) int64) ([]T, string) {
+	if after != nil {
+		start := len(items)
+		for i, item := range items {
+			if afterCursor(after, page.SortDirection, sortValue(item, page.SortBy), id(item)) {
+				start = i
+				break
+			}
+		}
+		items = items[start:]
+	}
+
+	if page.Offset >= len(items) {
+		items = nil
+	} else {
+		items = items[page.Offset:]
+	}
+
+	return trimPage(items, page, sortValue, id)
+}
+
+// trimPage cuts the extra row fetched to detect the next page and builds its cursor
+func trimPage[T any](items []T, page dto.PageRequest,
+	sortValue func(T, string) any, id func(T) int64) ([]T, string) {
+	if page.Limit == 0 || len(items) <= page.Limit {
+		return items, ""
+	}
+
+	items = items[:page.Limit]
+	last := items[len(items)-1]
+
+	return items, encodeCursor(cursor{
+		SortBy:    page.SortBy,
+		Direction: page.SortDirection,
+		Value:     sortValue(last, page.SortBy),
+		Id:        id(last),
+	})
+}
+
+func studentId(student domain.Student) int64 {
+	return student.Id
+}
+
+func groupId(group domain.Group) int64 {
+	return group.Id
+}
+
+func courseId(course domain.Course) int64 {
+	return course.Id
+}
+
+func enrollmentId(enrollment domain.Enrollment) int64 {
+	return enrollment.Id
+}
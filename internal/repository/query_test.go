@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"StudentManager/internal/dto"
+	"strings"
+	"testing"
+)
+
+func TestListSQLPageLimitClause(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		page    dto.PageRequest
+		want    string
+	}{
+		{"postgres offset", postgresDialect, dto.PageRequest{Offset: 5}, " offset $1"},
+		{"postgres limit and offset", postgresDialect, dto.PageRequest{Limit: 2, Offset: 5}, " limit $1 offset $2"},
+		{"sqlite offset", sqliteDialect, dto.PageRequest{Offset: 5}, " limit -1 offset ?"},
+		{"sqlite limit and offset", sqliteDialect, dto.PageRequest{Limit: 2, Offset: 5}, " limit ? offset ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.page.SortBy, tt.page.SortDirection = "id", dto.SortAsc
+			b := &listSQL{dialect: tt.dialect}
+
+			query, _ := b.page("student_view", studentColumns, tt.page, nil)
+			if !strings.HasSuffix(query, " order by id asc"+tt.want) {
+				t.Fatalf("page() = %q, want it to end with %q", query, tt.want)
+			}
+		})
+	}
+}
+
+// rosterSQL nests the page, Postgres must not get SQLite's limit there either
+func TestRosterSQLOffsetWithoutLimit(t *testing.T) {
+	page := dto.PageRequest{Offset: 5, SortBy: "id", SortDirection: dto.SortAsc}
+
+	postgres, _ := rosterSQL("tenant", 1, dto.StudentFilter{}, page, nil, postgresDialect)
+	if strings.Contains(postgres, "limit") {
+		t.Fatalf("postgres roster has a limit: %q", postgres)
+	}
+
+	sqlite, _ := rosterSQL("tenant", 1, dto.StudentFilter{}, page, nil, sqliteNumberedDialect)
+	if !strings.Contains(sqlite, " limit -1 offset ?") {
+		t.Fatalf("sqlite roster lacks limit -1: %q", sqlite)
+	}
+}
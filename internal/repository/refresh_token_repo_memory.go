@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"context"
+	"time"
+)
+
+type RefreshTokenRepoMemory struct {
+	lock   *memoryLock
+	tokens map[string]domain.RefreshToken
+}
+
+func NewRefreshTokenRepoMemory() *RefreshTokenRepoMemory {
+	return newRefreshTokenRepoMemory(&memoryLock{})
+}
+
+func newRefreshTokenRepoMemory(lock *memoryLock) *RefreshTokenRepoMemory {
+	return &RefreshTokenRepoMemory{
+		lock:   lock,
+		tokens: make(map[string]domain.RefreshToken),
+	}
+}
+
+func (repo *RefreshTokenRepoMemory) Create(ctx context.Context, token domain.RefreshToken) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, ok := repo.tokens[token.TokenHash]; ok {
+		return ErrConflict
+	}
+	repo.tokens[token.TokenHash] = token
+
+	return nil
+}
+
+func (repo *RefreshTokenRepoMemory) GetByHash(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	defer release()
+
+	token, ok := repo.tokens[tokenHash]
+	if !ok {
+		return domain.RefreshToken{}, ErrNotFound
+	}
+
+	return token, nil
+}
+
+func (repo *RefreshTokenRepoMemory) Revoke(ctx context.Context, tokenHash string, at time.Time) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	token, ok := repo.tokens[tokenHash]
+	if !ok || token.RevokedAt != nil {
+		return ErrNotFound
+	}
+	token.RevokedAt = &at
+	repo.tokens[tokenHash] = token
+
+	return nil
+}
+
+func (repo *RefreshTokenRepoMemory) RevokeFamily(ctx context.Context, familyId string, at time.Time) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for hash, token := range repo.tokens {
+		if token.FamilyId == familyId && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			repo.tokens[hash] = token
+		}
+	}
+
+	return nil
+}
+
+func (repo *RefreshTokenRepoMemory) snapshot() func() {
+	tokens := make(map[string]domain.RefreshToken, len(repo.tokens))
+	for hash, token := range repo.tokens {
+		tokens[hash] = token
+	}
+
+	return func() {
+		repo.tokens = tokens
+	}
+}
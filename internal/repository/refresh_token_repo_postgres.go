@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+	"time"
+)
+
+// tokens not bound to a tenant store null, which reads back as ""
+const refreshTokenColumns = "token_hash, subject, family_id, expires_at, revoked_at, created_at, coalesce(tenant_id, '')"
+
+type RefreshTokenRepoPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepoPostgres(db *pgxpool.Pool) *RefreshTokenRepoPostgres {
+	return &RefreshTokenRepoPostgres{
+		db: db,
+	}
+}
+
+func (repo *RefreshTokenRepoPostgres) Create(ctx context.Context, token domain.RefreshToken) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	_, err := database.Exec(ctx,
+		"insert into refresh_token(token_hash, subject, family_id, expires_at, created_at, tenant_id) "+
+			"values($1, $2, $3, $4, $5, nullif($6, ''))",
+		token.TokenHash, token.Subject, token.FamilyId, token.ExpiresAt, token.CreatedAt, token.TenantId)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in refresh token creation", "err", err)
+		return convertPostgresError(err)
+	}
+
+	return nil
+}
+
+func (repo *RefreshTokenRepoPostgres) GetByHash(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	token, err := scanRefreshToken(database.QueryRow(ctx,
+		"select "+refreshTokenColumns+" from refresh_token where token_hash = $1", tokenHash))
+	if err != nil {
+		return domain.RefreshToken{}, convertPostgresError(err)
+	}
+
+	return token, nil
+}
+
+func (repo *RefreshTokenRepoPostgres) Revoke(ctx context.Context, tokenHash string, at time.Time) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx,
+		"update refresh_token set revoked_at = $1 where token_hash = $2 and revoked_at is null", at, tokenHash)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in refresh token revocation", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *RefreshTokenRepoPostgres) RevokeFamily(ctx context.Context, familyId string, at time.Time) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	_, err := database.Exec(ctx,
+		"update refresh_token set revoked_at = $1 where family_id = $2 and revoked_at is null", at, familyId)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in refresh token family revocation", "err", err)
+		return convertPostgresError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+type RefreshTokenRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepoSQLite(db *sql.DB) *RefreshTokenRepoSQLite {
+	return &RefreshTokenRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *RefreshTokenRepoSQLite) Create(ctx context.Context, token domain.RefreshToken) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	_, err := database.ExecContext(ctx,
+		"insert into refresh_token(token_hash, subject, family_id, expires_at, created_at, tenant_id) "+
+			"values(?, ?, ?, ?, ?, nullif(?, ''))",
+		token.TokenHash, token.Subject, token.FamilyId, token.ExpiresAt.UTC(), token.CreatedAt.UTC(), token.TenantId)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in refresh token creation", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return nil
+}
+
+func (repo *RefreshTokenRepoSQLite) GetByHash(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	token, err := scanRefreshToken(database.QueryRowContext(ctx,
+		"select "+refreshTokenColumns+" from refresh_token where token_hash = ?", tokenHash))
+	if err != nil {
+		return domain.RefreshToken{}, convertSQLiteError(err)
+	}
+
+	return token, nil
+}
+
+func (repo *RefreshTokenRepoSQLite) Revoke(ctx context.Context, tokenHash string, at time.Time) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx,
+		"update refresh_token set revoked_at = ? where token_hash = ? and revoked_at is null", at.UTC(), tokenHash)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in refresh token revocation", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (repo *RefreshTokenRepoSQLite) RevokeFamily(ctx context.Context, familyId string, at time.Time) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	_, err := database.ExecContext(ctx,
+		"update refresh_token set revoked_at = ? where family_id = ? and revoked_at is null", at.UTC(), familyId)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in refresh token family revocation", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return nil
+}
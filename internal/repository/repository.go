@@ -1,40 +1,310 @@
 package repository
 
 import (
+	"StudentManager/internal/config"
 	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/metrics"
+	"StudentManager/internal/migrate"
+	"StudentManager/pkg/database/postgres"
+	"StudentManager/pkg/database/sqlite"
 	"context"
-	"github.com/jackc/pgx/v4"
+	"database/sql"
+	"fmt"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"log/slog"
+	"time"
 )
 
+// Implementations return ErrNotFound when the requested record is missing
+// and ErrConflict when a unique column (email, group number) is already taken.
+// Writing a student that refers to a group that doesn't exist or is being
+// deleted returns ErrReferenceNotFound.
+
 type StudentRepository interface {
-	Create(ctx context.Context, student domain.Student) (pgx.Rows, error)
-	GetById(ctx context.Context, id int64) pgx.Row
-	Update(ctx context.Context, student domain.Student) (pgx.Rows, error)
+	Create(ctx context.Context, student domain.Student) (domain.Student, error)
+	GetById(ctx context.Context, id int64) (domain.Student, error)
+	Update(ctx context.Context, student domain.Student) (domain.Student, error)
+	// Patch writes only the changed columns, changes must not be empty
+	Patch(ctx context.Context, id int64, changes dto.StudentChanges) (domain.Student, error)
 	DeleteById(ctx context.Context, id int64) error
-	GetAll(ctx context.Context) (pgx.Rows, error)
-	GetByEmail(ctx context.Context, email string) pgx.Row
+	GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error)
+	GetByEmail(ctx context.Context, email string) (domain.Student, error)
+	CountByGroupId(ctx context.Context, groupId int64) (int64, error)
+	// MoveGroup moves every student of one group to another and returns how
+	// many there were, a toGroupId of 0 leaves them without a group
+	MoveGroup(ctx context.Context, fromGroupId, toGroupId int64) (int64, error)
 }
 
 type GroupRepository interface {
-	Create(ctx context.Context, group domain.Group) (pgx.Rows, error)
-	GetById(ctx context.Context, id int64) pgx.Row
-	Update(ctx context.Context, group domain.Group) (pgx.Rows, error)
+	Create(ctx context.Context, group domain.Group) (domain.Group, error)
+	GetById(ctx context.Context, id int64) (domain.Group, error)
+	Update(ctx context.Context, group domain.Group) (domain.Group, error)
 	DeleteById(ctx context.Context, id int64) error
-	GetAll(ctx context.Context) (pgx.Rows, error)
-	GetByGroupNumber(ctx context.Context, name string) pgx.Row
+	GetAll(ctx context.Context, query dto.GroupQuery) (dto.GroupPage, error)
+	GetByGroupNumber(ctx context.Context, name string) (domain.Group, error)
+	// GetRoster reads the group and a page of its students matching the
+	// filter at once, Total counts all of them
+	GetRoster(ctx context.Context, id int64, query dto.StudentQuery) (dto.GroupRoster, error)
+}
+
+// WaitlistRepository keeps the students waiting for a seat in a group, a
+// student waits at most once per group. Put returns ErrConflict when the
+// group or the student doesn't exist. Entries go away with their group or
+// student.
+type WaitlistRepository interface {
+	// Put adds the student to the end of the queue, or changes the priority
+	// of a student already waiting there, which keeps its place
+	Put(ctx context.Context, entry domain.WaitlistEntry) error
+	// GetByGroupId returns the queue in the order of the waitlist mode, with
+	// positions set
+	GetByGroupId(ctx context.Context, groupId int64, mode string) ([]domain.WaitlistEntry, error)
+	Remove(ctx context.Context, groupId, studentId int64) error
+	// RemoveByGroupId empties the queue of the group
+	RemoveByGroupId(ctx context.Context, groupId int64) error
+}
+
+// CourseRepository.DeleteById returns ErrConflict while the course has
+// enrollments, whatever their status
+type CourseRepository interface {
+	Create(ctx context.Context, course domain.Course) (domain.Course, error)
+	GetById(ctx context.Context, id int64) (domain.Course, error)
+	Update(ctx context.Context, course domain.Course) (domain.Course, error)
+	DeleteById(ctx context.Context, id int64) error
+	GetAll(ctx context.Context, query dto.CourseQuery) (dto.CoursePage, error)
+}
+
+// EnrollmentRepository keeps who takes which course. A course has at most one
+// enrollment per group and per student, Create returns ErrConflict for a
+// second one and when the course, group or student doesn't exist.
+// Enrollments go away with their group or student.
+type EnrollmentRepository interface {
+	Create(ctx context.Context, enrollment domain.Enrollment) (domain.Enrollment, error)
+	GetById(ctx context.Context, id int64) (domain.Enrollment, error)
+	// UpdateStatus sets the status, at becomes UpdatedAt
+	UpdateStatus(ctx context.Context, id int64, status string, at time.Time) (domain.Enrollment, error)
+	GetAll(ctx context.Context, query dto.EnrollmentQuery) (dto.EnrollmentPage, error)
+}
+
+// RefreshTokenRepository.Revoke returns ErrNotFound when the token is
+// unknown or already revoked, so only one of two concurrent refreshes wins
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token domain.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (domain.RefreshToken, error)
+	Revoke(ctx context.Context, tokenHash string, at time.Time) error
+	RevokeFamily(ctx context.Context, familyId string, at time.Time) error
+}
+
+// AccessRepository keeps users, their roles and the groups they curate.
+// Roles and their permissions are seeded by migrations. Users are created in
+// the tenant of the context, but are looked up by name in every tenant.
+type AccessRepository interface {
+	CreateUser(ctx context.Context, user domain.User) error
+	GetUser(ctx context.Context, username string) (domain.User, error)
+	DeleteUser(ctx context.Context, username string) error
+	SetUserRoles(ctx context.Context, username string, roles []string) error
+	RoleNames(ctx context.Context) ([]string, error)
+	// GetGrants returns the permissions of all roles of the user
+	GetGrants(ctx context.Context, username string) ([]domain.Grant, error)
+	// AddCurator does nothing when the user already curates the group
+	AddCurator(ctx context.Context, groupId int64, username string) error
+	RemoveCurator(ctx context.Context, groupId int64, username string) error
+	CuratedGroupNumbers(ctx context.Context, username string) ([]string, error)
+}
+
+// TenantRepository.DeleteById returns ErrConflict while the tenant still has
+// groups, students, users or courses
+type TenantRepository interface {
+	Create(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error)
+	GetById(ctx context.Context, id string) (domain.Tenant, error)
+	GetAll(ctx context.Context) ([]domain.Tenant, error)
+	Update(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error)
+	DeleteById(ctx context.Context, id string) error
 }
 
 type Repositories struct {
-	Students StudentRepository
-	Groups   GroupRepository
+	Students      StudentRepository
+	Groups        GroupRepository
+	Waitlists     WaitlistRepository
+	Courses       CourseRepository
+	Enrollments   EnrollmentRepository
+	RefreshTokens RefreshTokenRepository
+	Access        AccessRepository
+	Tenants       TenantRepository
+	Tx            TxManager
+	close         func()
+	// nil for memory storage, which is always there and has no schema
+	ping    func(ctx context.Context) error
+	pending func(ctx context.Context) (int, error)
+	// connection pool statistics
+	collectors []prometheus.Collector
+}
+
+// NewRepositories opens the storage selected in the config and applies
+// pending migrations when database.auto_migrate is set.
+func NewRepositories(cfg *config.Config) (*Repositories, error) {
+	if cfg.Storage == config.StorageMemory {
+		return NewMemoryRepositories(), nil
+	}
+	if cfg.Storage != config.StorageDatabase {
+		return nil, fmt.Errorf("unknown storage: %s", cfg.Storage)
+	}
+
+	switch cfg.Database.Driver {
+	case config.DriverPostgres:
+		db, err := postgres.New(cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Database.AutoMigrate {
+			if err := autoMigrate(migrate.NewPostgres(db)); err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+		return NewPostgresRepositories(db), nil
+	case config.DriverSQLite:
+		db, err := sqlite.New(cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Database.AutoMigrate {
+			if err := autoMigrate(migrate.NewSQLite(db)); err != nil {
+				_ = db.Close()
+				return nil, err
+			}
+		}
+		return NewSQLiteRepositories(db), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.Database.Driver)
+	}
+}
+
+func NewPostgresRepositories(db *pgxpool.Pool) *Repositories {
+	slog.Info("Repositories are created")
+	return &Repositories{
+		Students:      NewStudentRepoPostgres(db),
+		Groups:        NewGroupRepoPostgres(db),
+		Waitlists:     NewWaitlistRepoPostgres(db),
+		Courses:       NewCourseRepoPostgres(db),
+		Enrollments:   NewEnrollmentRepoPostgres(db),
+		RefreshTokens: NewRefreshTokenRepoPostgres(db),
+		Access:        NewAccessRepoPostgres(db),
+		Tenants:       NewTenantRepoPostgres(db),
+		Tx:            NewTxManagerPostgres(db),
+		close:         db.Close,
+		ping:          db.Ping,
+		collectors:    []prometheus.Collector{metrics.NewPoolCollector(db)},
+		pending:       pendingMigrations(migrate.NewPostgres(db)),
+	}
+}
+
+func NewSQLiteRepositories(db *sql.DB) *Repositories {
+	slog.Info("SQLite repositories are created")
+	return &Repositories{
+		Students:      NewStudentRepoSQLite(db),
+		Groups:        NewGroupRepoSQLite(db),
+		Waitlists:     NewWaitlistRepoSQLite(db),
+		Courses:       NewCourseRepoSQLite(db),
+		Enrollments:   NewEnrollmentRepoSQLite(db),
+		RefreshTokens: NewRefreshTokenRepoSQLite(db),
+		Access:        NewAccessRepoSQLite(db),
+		Tenants:       NewTenantRepoSQLite(db),
+		Tx:            NewTxManagerSQLite(db),
+		close: func() {
+			if err := db.Close(); err != nil {
+				slog.Error("failed to close sqlite database", "err", err)
+			}
+		},
+		ping:       db.PingContext,
+		collectors: []prometheus.Collector{collectors.NewDBStatsCollector(db, "sqlite")},
+		pending:    pendingMigrations(migrate.NewSQLite(db)),
+	}
 }
 
-func NewRepositories(db *pgxpool.Pool) *Repositories {
-	log.Printf("Repositories are created")
+func NewMemoryRepositories() *Repositories {
+	slog.Info("In-memory repositories are created")
+	lock := &memoryLock{}
+	students, groups := newStudentAndGroupRepoMemory(lock)
+	waitlists := newWaitlistRepoMemory(lock, students, groups)
+	courses, enrollments := newCourseAndEnrollmentRepoMemory(lock, students, groups)
+	refreshTokens := newRefreshTokenRepoMemory(lock)
+	access := newAccessRepoMemory(lock, groups)
+	tenants := newTenantRepoMemory(lock, students, groups, access, courses)
+
 	return &Repositories{
-		Students: NewStudentRepoPostgres(db),
-		Groups:   NewGroupRepoPostgres(db),
+		Students:      students,
+		Groups:        groups,
+		Waitlists:     waitlists,
+		Courses:       courses,
+		Enrollments:   enrollments,
+		RefreshTokens: refreshTokens,
+		Access:        access,
+		Tenants:       tenants,
+		Tx: &TxManagerMemory{
+			lock: lock,
+			repos: []memorySnapshotter{students, groups, waitlists, courses, enrollments, refreshTokens, access,
+				tenants},
+		},
+		close: func() {},
+	}
+}
+
+// Close releases the underlying database connections.
+func (r *Repositories) Close() {
+	if r.close != nil {
+		r.close()
+	}
+}
+
+// Collectors returns the metrics of the storage, to be registered once
+func (r *Repositories) Collectors() []prometheus.Collector {
+	return r.collectors
+}
+
+// Ping checks that the database answers
+func (r *Repositories) Ping(ctx context.Context) error {
+	if r.ping == nil {
+		return nil
+	}
+
+	return r.ping(ctx)
+}
+
+// PendingMigrations returns the number of migrations the database lacks
+func (r *Repositories) PendingMigrations(ctx context.Context) (int, error) {
+	if r.pending == nil {
+		return 0, nil
+	}
+
+	return r.pending(ctx)
+}
+
+// pendingMigrations counts the migrations the database lacks, they are parsed
+// once when the repositories are created and every probe only reads the
+// version table
+func pendingMigrations(migrator *migrate.Migrator, err error) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to load migrations: %w", err)
+		}
+
+		return migrator.Pending(ctx)
 	}
 }
+
+func autoMigrate(migrator *migrate.Migrator, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	slog.Info("applied migrations", "count", applied)
+	return nil
+}
@@ -0,0 +1,1714 @@
+// Package repotest holds the conformance suite every repository
+// implementation has to pass, so the in-memory and database backends
+// behave the same way.
+package repotest
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/repository"
+	"StudentManager/internal/tenant"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Factory must return repositories backed by empty storage.
+type Factory func(t *testing.T) *repository.Repositories
+
+func Run(t *testing.T, newRepositories Factory) {
+	t.Run("Students", func(t *testing.T) {
+		RunStudents(t, newRepositories)
+	})
+	t.Run("Groups", func(t *testing.T) {
+		RunGroups(t, newRepositories)
+	})
+	t.Run("Lists", func(t *testing.T) {
+		RunLists(t, newRepositories)
+	})
+	t.Run("Waitlists", func(t *testing.T) {
+		RunWaitlists(t, newRepositories)
+	})
+	t.Run("Courses", func(t *testing.T) {
+		RunCourses(t, newRepositories)
+	})
+	t.Run("Enrollments", func(t *testing.T) {
+		RunEnrollments(t, newRepositories)
+	})
+	t.Run("Transactions", func(t *testing.T) {
+		RunTransactions(t, newRepositories)
+	})
+	t.Run("Access", func(t *testing.T) {
+		RunAccess(t, newRepositories)
+	})
+	t.Run("Tenants", func(t *testing.T) {
+		RunTenants(t, newRepositories)
+	})
+	t.Run("RefreshTokens", func(t *testing.T) {
+		RunRefreshTokens(t, newRepositories)
+	})
+}
+
+func RunStudents(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	// students refer to groups, every test gets two of them
+	setup := func(t *testing.T) (repository.StudentRepository, []domain.Group) {
+		repos := newRepositories(t)
+
+		var groups []domain.Group
+		for _, number := range []string{"A-101", "B-202"} {
+			group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: number})
+			if err != nil {
+				t.Fatalf("create group: %v", err)
+			}
+			groups = append(groups, group)
+		}
+
+		return repos.Students, groups
+	}
+
+	newStudent := func(email string, group domain.Group) domain.Student {
+		return domain.Student{
+			FullName: "Ivan Ivanov",
+			Age:      20,
+			GroupId:  group.Id,
+			Email:    email,
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		created, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0]))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if created.Id == 0 {
+			t.Fatal("create: id is not assigned")
+		}
+		if created.GroupId != groups[0].Id || created.GroupNumber != groups[0].GroupNumber {
+			t.Fatalf("create: got %+v, want it in group %+v", created, groups[0])
+		}
+
+		byId, err := repo.GetById(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if byId != created {
+			t.Fatalf("get by id: got %+v, want %+v", byId, created)
+		}
+
+		byEmail, err := repo.GetByEmail(ctx, created.Email)
+		if err != nil {
+			t.Fatalf("get by email: %v", err)
+		}
+		if byEmail != created {
+			t.Fatalf("get by email: got %+v, want %+v", byEmail, created)
+		}
+	})
+
+	t.Run("CreateDuplicateEmail", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		if _, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0])); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		_, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0]))
+		if !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate: got %v, want ErrConflict", err)
+		}
+
+		// unique constraint is case-sensitive
+		if _, err := repo.Create(ctx, newStudent("IVAN@example.com", groups[0])); err != nil {
+			t.Fatalf("create with different case: %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		if _, err := repo.GetById(ctx, 42); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get by id: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetByEmail(ctx, "nobody@example.com"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get by email: got %v, want ErrNotFound", err)
+		}
+		missing := newStudent("nobody@example.com", groups[0])
+		missing.Id = 42
+		if _, err := repo.Update(ctx, missing); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("update: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteById(ctx, 42); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("delete: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		created, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0]))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		created.FullName = "Petr Petrov"
+		created.Age = 21
+		created.GroupId = groups[1].Id
+		created.GroupNumber = groups[1].GroupNumber
+		updated, err := repo.Update(ctx, created)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if updated != created {
+			t.Fatalf("update: got %+v, want %+v", updated, created)
+		}
+
+		stored, err := repo.GetById(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if stored != created {
+			t.Fatalf("get by id after update: got %+v, want %+v", stored, created)
+		}
+	})
+
+	t.Run("UpdateDuplicateEmail", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		if _, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0])); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		petr, err := repo.Create(ctx, newStudent("petr@example.com", groups[0]))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		petr.Email = "ivan@example.com"
+		if _, err := repo.Update(ctx, petr); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("update: got %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		created, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0]))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		age := 30
+		patched, err := repo.Patch(ctx, created.Id, dto.StudentChanges{Age: &age})
+		if err != nil {
+			t.Fatalf("patch: %v", err)
+		}
+		want := created
+		want.Age = age
+		if patched != want {
+			t.Fatalf("patch: got %+v, want %+v", patched, want)
+		}
+
+		missing := "x@example.com"
+		if _, err := repo.Patch(ctx, 42, dto.StudentChanges{Email: &missing}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("patch missing: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("PatchDuplicateEmail", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		if _, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0])); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		petr, err := repo.Create(ctx, newStudent("petr@example.com", groups[0]))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		email := "ivan@example.com"
+		if _, err := repo.Patch(ctx, petr.Id, dto.StudentChanges{Email: &email}); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("patch: got %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("CountByGroupId", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		for _, email := range []string{"a@example.com", "b@example.com"} {
+			if _, err := repo.Create(ctx, newStudent(email, groups[0])); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+		}
+
+		count, err := repo.CountByGroupId(ctx, groups[0].Id)
+		if err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if count != 2 {
+			t.Fatalf("count: got %d, want 2", count)
+		}
+
+		count, err = repo.CountByGroupId(ctx, groups[1].Id)
+		if err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if count != 0 {
+			t.Fatalf("count of empty group: got %d, want 0", count)
+		}
+	})
+
+	t.Run("GroupReference", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		if _, err := repo.Create(ctx, newStudent("ghost@example.com", domain.Group{Id: 42})); !errors.Is(err, repository.ErrReferenceNotFound) {
+			t.Fatalf("create in a missing group: got %v, want ErrReferenceNotFound", err)
+		}
+
+		created, err := repo.Create(ctx, newStudent("ivan@example.com", groups[0]))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		missing := int64(42)
+		if _, err := repo.Patch(ctx, created.Id, dto.StudentChanges{GroupId: &missing}); !errors.Is(err, repository.ErrReferenceNotFound) {
+			t.Fatalf("patch into a missing group: got %v, want ErrReferenceNotFound", err)
+		}
+		moved := created
+		moved.GroupId = missing
+		if _, err := repo.Update(ctx, moved); !errors.Is(err, repository.ErrReferenceNotFound) {
+			t.Fatalf("update into a missing group: got %v, want ErrReferenceNotFound", err)
+		}
+
+		none := int64(0)
+		unassigned, err := repo.Patch(ctx, created.Id, dto.StudentChanges{GroupId: &none})
+		if err != nil {
+			t.Fatalf("patch: %v", err)
+		}
+		if unassigned.GroupId != 0 || unassigned.GroupNumber != "" {
+			t.Fatalf("patch out of the group: got %+v", unassigned)
+		}
+	})
+
+	t.Run("MoveGroup", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		var created []domain.Student
+		for _, email := range []string{"a@example.com", "b@example.com"} {
+			student, err := repo.Create(ctx, newStudent(email, groups[0]))
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			created = append(created, student)
+		}
+
+		moved, err := repo.MoveGroup(ctx, groups[0].Id, groups[1].Id)
+		if err != nil || moved != 2 {
+			t.Fatalf("move: got %d, %v, want 2 students moved", moved, err)
+		}
+		stored, err := repo.GetById(ctx, created[0].Id)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if stored.GroupId != groups[1].Id || stored.GroupNumber != groups[1].GroupNumber {
+			t.Fatalf("moved student: got %+v, want it in group %+v", stored, groups[1])
+		}
+
+		if _, err := repo.MoveGroup(ctx, groups[1].Id, 0); err != nil {
+			t.Fatalf("move out of groups: %v", err)
+		}
+		if count, err := repo.CountByGroupId(ctx, groups[1].Id); err != nil || count != 0 {
+			t.Fatalf("count after moving out: got %d, %v", count, err)
+		}
+	})
+
+	t.Run("DeleteAndGetAll", func(t *testing.T) {
+		repo, groups := setup(t)
+
+		var created []domain.Student
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			student, err := repo.Create(ctx, newStudent(email, groups[0]))
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			created = append(created, student)
+		}
+
+		if err := repo.DeleteById(ctx, created[1].Id); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+
+		page, err := repo.GetAll(ctx, dto.StudentQuery{})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		all := page.Students
+		if len(all) != 2 || all[0] != created[0] || all[1] != created[2] || page.Total != 2 {
+			t.Fatalf("get all: got %+v, want students ordered by id without the deleted one", page)
+		}
+	})
+}
+
+func RunGroups(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepositories(t).Groups
+
+		created, err := repo.Create(ctx, domain.Group{GroupNumber: "A-101", Capacity: 25,
+			Waitlist: domain.WaitlistFIFO})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if created.Id == 0 {
+			t.Fatal("create: id is not assigned")
+		}
+		if created.Capacity != 25 || created.Waitlist != domain.WaitlistFIFO {
+			t.Fatalf("create: got %+v, want the capacity and waitlist stored", created)
+		}
+
+		byId, err := repo.GetById(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if byId != created {
+			t.Fatalf("get by id: got %+v, want %+v", byId, created)
+		}
+
+		byNumber, err := repo.GetByGroupNumber(ctx, created.GroupNumber)
+		if err != nil {
+			t.Fatalf("get by group number: %v", err)
+		}
+		if byNumber != created {
+			t.Fatalf("get by group number: got %+v, want %+v", byNumber, created)
+		}
+	})
+
+	t.Run("CreateDuplicateNumber", func(t *testing.T) {
+		repo := newRepositories(t).Groups
+
+		if _, err := repo.Create(ctx, domain.Group{GroupNumber: "A-101"}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		_, err := repo.Create(ctx, domain.Group{GroupNumber: "A-101"})
+		if !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate: got %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepositories(t).Groups
+
+		if _, err := repo.GetById(ctx, 42); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get by id: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetByGroupNumber(ctx, "Z-999"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get by group number: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.Update(ctx, domain.Group{Id: 42, GroupNumber: "Z-999"}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("update: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteById(ctx, 42); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("delete: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("UpdateOnlyTouchesOneGroup", func(t *testing.T) {
+		repo := newRepositories(t).Groups
+
+		first, err := repo.Create(ctx, domain.Group{GroupNumber: "A-101"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		second, err := repo.Create(ctx, domain.Group{GroupNumber: "B-202"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		first.GroupNumber = "C-303"
+		updated, err := repo.Update(ctx, first)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if updated != first {
+			t.Fatalf("update: got %+v, want %+v", updated, first)
+		}
+
+		stored, err := repo.GetById(ctx, second.Id)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if stored != second {
+			t.Fatalf("untouched group changed: got %+v, want %+v", stored, second)
+		}
+
+		second.GroupNumber = "C-303"
+		if _, err := repo.Update(ctx, second); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("update duplicate: got %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("StudentsFollowRename", func(t *testing.T) {
+		repos := newRepositories(t)
+
+		group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		student, err := repos.Students.Create(ctx, domain.Student{
+			FullName: "Ivan Ivanov", Age: 20, GroupId: group.Id, Email: "ivan@example.com",
+		})
+		if err != nil {
+			t.Fatalf("create student: %v", err)
+		}
+
+		group.GroupNumber = "B-202"
+		if _, err := repos.Groups.Update(ctx, group); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		stored, err := repos.Students.GetById(ctx, student.Id)
+		if err != nil {
+			t.Fatalf("get student: %v", err)
+		}
+		if stored.GroupId != group.Id || stored.GroupNumber != "B-202" {
+			t.Fatalf("student after rename: got %+v, want it in %+v", stored, group)
+		}
+
+		if err := repos.Groups.DeleteById(ctx, group.Id); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("delete group with students: got %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("DeleteAndGetAll", func(t *testing.T) {
+		repo := newRepositories(t).Groups
+
+		var created []domain.Group
+		for _, number := range []string{"A-101", "B-202", "C-303"} {
+			group, err := repo.Create(ctx, domain.Group{GroupNumber: number})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			created = append(created, group)
+		}
+
+		if err := repo.DeleteById(ctx, created[1].Id); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+
+		page, err := repo.GetAll(ctx, dto.GroupQuery{})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		all := page.Groups
+		if len(all) != 2 || all[0] != created[0] || all[1] != created[2] || page.Total != 2 {
+			t.Fatalf("get all: got %+v, want groups ordered by id without the deleted one", page)
+		}
+	})
+}
+
+func RunLists(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	// names and emails are lowercase ASCII, so every backend sorts them the same way
+	seedIn := func(t *testing.T, repos *repository.Repositories) []domain.Student {
+		groups := map[string]int64{}
+		for _, number := range []string{"a-101", "b-202"} {
+			group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: number})
+			if err != nil {
+				t.Fatalf("create group: %v", err)
+			}
+			groups[number] = group.Id
+		}
+
+		var created []domain.Student
+		for _, student := range []domain.Student{
+			{FullName: "anna smirnova", Age: 19, GroupId: groups["a-101"], Email: "anna@uni.edu"},
+			{FullName: "boris ivanov", Age: 21, GroupId: groups["a-101"], Email: "boris@mail.com"},
+			{FullName: "vera ivanova", Age: 21, GroupId: groups["b-202"], Email: "vera@uni.edu"},
+			{FullName: "gleb petrov", Age: 23, GroupId: groups["b-202"], Email: "gleb@uni.edu"},
+			{FullName: "dina sidorova", Age: 21, GroupId: groups["a-101"], Email: "dina@mail.com"},
+		} {
+			student, err := repos.Students.Create(ctx, student)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			created = append(created, student)
+		}
+
+		return created
+	}
+	seed := func(t *testing.T) (repository.StudentRepository, []domain.Student) {
+		repos := newRepositories(t)
+		return repos.Students, seedIn(t, repos)
+	}
+
+	ids := func(students []domain.Student) []int64 {
+		result := make([]int64, 0, len(students))
+		for _, student := range students {
+			result = append(result, student.Id)
+		}
+		return result
+	}
+
+	expect := func(t *testing.T, got []domain.Student, want ...domain.Student) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("got ids %v, want %v", ids(got), ids(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got ids %v, want %v", ids(got), ids(want))
+			}
+		}
+	}
+
+	t.Run("Filters", func(t *testing.T) {
+		repo, s := seed(t)
+
+		cases := []struct {
+			name   string
+			filter dto.StudentFilter
+			want   []domain.Student
+		}{
+			{"GroupNumber", dto.StudentFilter{GroupNumber: "b-202"}, []domain.Student{s[2], s[3]}},
+			{"AgeRange", dto.StudentFilter{AgeMin: 20, AgeMax: 22}, []domain.Student{s[1], s[2], s[4]}},
+			{"EmailDomain", dto.StudentFilter{EmailDomain: "UNI.edu"}, []domain.Student{s[0], s[2], s[3]}},
+			{"NameContains", dto.StudentFilter{NameContains: "Ivanov"}, []domain.Student{s[1], s[2]}},
+			{"LikeWildcardsAreLiteral", dto.StudentFilter{NameContains: "%"}, nil},
+			{"Combined", dto.StudentFilter{GroupNumber: "a-101", AgeMin: 21, EmailDomain: "mail.com"}, []domain.Student{s[1], s[4]}},
+			{"GroupNumberIn", dto.StudentFilter{GroupNumberIn: []string{"b-202", "c-303"}}, []domain.Student{s[2], s[3]}},
+			{"Id", dto.StudentFilter{Id: s[3].Id}, []domain.Student{s[3]}},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				page, err := repo.GetAll(ctx, dto.StudentQuery{StudentFilter: c.filter})
+				if err != nil {
+					t.Fatalf("get all: %v", err)
+				}
+				expect(t, page.Students, c.want...)
+				if page.Total != int64(len(c.want)) {
+					t.Fatalf("total: got %d, want %d", page.Total, len(c.want))
+				}
+			})
+		}
+	})
+
+	t.Run("SortAndOffset", func(t *testing.T) {
+		repo, s := seed(t)
+
+		page, err := repo.GetAll(ctx, dto.StudentQuery{PageRequest: dto.PageRequest{
+			SortBy: "age", SortDirection: dto.SortDesc, Limit: 2, Offset: 1,
+		}})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		// ties on age are ordered by id in the same direction
+		expect(t, page.Students, s[4], s[2])
+		if page.Total != 5 || page.NextCursor == "" {
+			t.Fatalf("got total %d and cursor %q, want 5 and a cursor", page.Total, page.NextCursor)
+		}
+
+		page, err = repo.GetAll(ctx, dto.StudentQuery{PageRequest: dto.PageRequest{SortBy: "full_name"}})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		expect(t, page.Students, s[0], s[1], s[4], s[3], s[2])
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		repo, s := seed(t)
+
+		query := dto.StudentQuery{PageRequest: dto.PageRequest{SortBy: "age", SortDirection: dto.SortAsc, Limit: 2}}
+
+		var got []domain.Student
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatal("pagination doesn't stop")
+			}
+			page, err := repo.GetAll(ctx, query)
+			if err != nil {
+				t.Fatalf("get all: %v", err)
+			}
+			got = append(got, page.Students...)
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		expect(t, got, s[0], s[1], s[2], s[4], s[3])
+
+		query.SortDirection = dto.SortDesc
+		if _, err := repo.GetAll(ctx, query); !errors.Is(err, repository.ErrInvalidQuery) {
+			t.Fatalf("cursor reused with another sort: got %v, want ErrInvalidQuery", err)
+		}
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		repo, _ := seed(t)
+
+		for _, page := range []dto.PageRequest{
+			{SortBy: "password"},
+			{SortBy: "id; drop table student"},
+			{SortDirection: "sideways"},
+			{Cursor: "not a cursor"},
+			{Cursor: "eyJzIjoiaWQifQ", Offset: 1},
+		} {
+			if _, err := repo.GetAll(ctx, dto.StudentQuery{PageRequest: page}); !errors.Is(err, repository.ErrInvalidQuery) {
+				t.Fatalf("%+v: got %v, want ErrInvalidQuery", page, err)
+			}
+		}
+	})
+
+	t.Run("Groups", func(t *testing.T) {
+		repo := newRepositories(t).Groups
+
+		var created []domain.Group
+		for _, number := range []string{"b-202", "a-101", "a-102"} {
+			group, err := repo.Create(ctx, domain.Group{GroupNumber: number})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			created = append(created, group)
+		}
+
+		page, err := repo.GetAll(ctx, dto.GroupQuery{
+			GroupFilter: dto.GroupFilter{NumberContains: "A-"},
+			PageRequest: dto.PageRequest{SortBy: "group_number", Limit: 1},
+		})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		if len(page.Groups) != 1 || page.Groups[0] != created[1] || page.Total != 2 || page.NextCursor == "" {
+			t.Fatalf("first page: got %+v", page)
+		}
+
+		page, err = repo.GetAll(ctx, dto.GroupQuery{
+			GroupFilter: dto.GroupFilter{NumberContains: "A-"},
+			PageRequest: dto.PageRequest{SortBy: "group_number", Limit: 1, Cursor: page.NextCursor},
+		})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		if len(page.Groups) != 1 || page.Groups[0] != created[2] || page.NextCursor != "" {
+			t.Fatalf("second page: got %+v", page)
+		}
+	})
+
+	t.Run("Roster", func(t *testing.T) {
+		repos := newRepositories(t)
+		students := seedIn(t, repos)
+		group, err := repos.Groups.GetByGroupNumber(ctx, "a-101")
+		if err != nil {
+			t.Fatalf("get group: %v", err)
+		}
+		empty, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "c-303"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		query := dto.StudentQuery{PageRequest: dto.PageRequest{SortBy: "full_name", SortDirection: dto.SortDesc, Limit: 2}}
+		roster, err := repos.Groups.GetRoster(ctx, group.Id, query)
+		if err != nil {
+			t.Fatalf("get roster: %v", err)
+		}
+		if roster.Group != group || roster.Total != 3 || roster.NextCursor == "" {
+			t.Fatalf("first page: got %+v", roster)
+		}
+		expect(t, roster.Students, students[4], students[1])
+
+		query.Cursor = roster.NextCursor
+		roster, err = repos.Groups.GetRoster(ctx, group.Id, query)
+		if err != nil {
+			t.Fatalf("get roster: %v", err)
+		}
+		if roster.Total != 3 || roster.NextCursor != "" {
+			t.Fatalf("second page: got %+v", roster)
+		}
+		expect(t, roster.Students, students[0])
+
+		roster, err = repos.Groups.GetRoster(ctx, group.Id, dto.StudentQuery{
+			StudentFilter: dto.StudentFilter{AgeMin: 21},
+			PageRequest:   dto.PageRequest{Offset: 5},
+		})
+		if err != nil {
+			t.Fatalf("get roster past the end: %v", err)
+		}
+		if roster.Group != group || roster.Total != 2 || len(roster.Students) != 0 {
+			t.Fatalf("past the end: got %+v", roster)
+		}
+
+		roster, err = repos.Groups.GetRoster(ctx, empty.Id, dto.StudentQuery{})
+		if err != nil {
+			t.Fatalf("get empty roster: %v", err)
+		}
+		if roster.Group != empty || roster.Total != 0 || len(roster.Students) != 0 {
+			t.Fatalf("empty group: got %+v", roster)
+		}
+
+		if _, err := repos.Groups.GetRoster(ctx, 42, dto.StudentQuery{}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("missing group: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func RunWaitlists(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+	// whole seconds, so every backend returns exactly what was stored
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// a group with three students to queue
+	setup := func(t *testing.T) (*repository.Repositories, domain.Group, []domain.Student) {
+		repos := newRepositories(t)
+
+		group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101", Capacity: 1,
+			Waitlist: domain.WaitlistPriority})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		var students []domain.Student
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			student, err := repos.Students.Create(ctx, domain.Student{FullName: "Ivan Ivanov", Age: 20, Email: email})
+			if err != nil {
+				t.Fatalf("create student: %v", err)
+			}
+			students = append(students, student)
+		}
+
+		return repos, group, students
+	}
+
+	// studentIds also checks the positions count from 1 in the order of the queue
+	studentIds := func(t *testing.T, entries []domain.WaitlistEntry) []int64 {
+		var ids []int64
+		for i, entry := range entries {
+			if entry.Position != i+1 {
+				t.Fatalf("entry %d: got position %d", i, entry.Position)
+			}
+			ids = append(ids, entry.StudentId)
+		}
+		return ids
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		repos, group, students := setup(t)
+		repo := repos.Waitlists
+
+		for i, priority := range []int{0, 5, 0} {
+			entry := domain.WaitlistEntry{GroupId: group.Id, StudentId: students[i].Id, Priority: priority, QueuedAt: now}
+			if err := repo.Put(ctx, entry); err != nil {
+				t.Fatalf("put: %v", err)
+			}
+		}
+
+		fifo, err := repo.GetByGroupId(ctx, group.Id, domain.WaitlistFIFO)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if want := []int64{students[0].Id, students[1].Id, students[2].Id}; !slices.Equal(studentIds(t, fifo), want) {
+			t.Fatalf("fifo order: got %v, want %v", studentIds(t, fifo), want)
+		}
+		if !fifo[0].QueuedAt.Equal(now) || fifo[1].Priority != 5 || fifo[0].GroupId != group.Id {
+			t.Fatalf("entry: got %+v", fifo[1])
+		}
+
+		byPriority, err := repo.GetByGroupId(ctx, group.Id, domain.WaitlistPriority)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if want := []int64{students[1].Id, students[0].Id, students[2].Id}; !slices.Equal(studentIds(t, byPriority), want) {
+			t.Fatalf("priority order: got %v, want %v", studentIds(t, byPriority), want)
+		}
+
+		// putting a waiting student again changes its priority, not its place
+		entry := domain.WaitlistEntry{GroupId: group.Id, StudentId: students[0].Id, Priority: 9, QueuedAt: now}
+		if err := repo.Put(ctx, entry); err != nil {
+			t.Fatalf("put again: %v", err)
+		}
+		fifo, err = repo.GetByGroupId(ctx, group.Id, domain.WaitlistFIFO)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if len(fifo) != 3 || fifo[0].StudentId != students[0].Id || fifo[0].Priority != 9 {
+			t.Fatalf("after changing priority: got %+v", fifo)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		repos, group, students := setup(t)
+		repo := repos.Waitlists
+
+		for _, student := range students {
+			entry := domain.WaitlistEntry{GroupId: group.Id, StudentId: student.Id, QueuedAt: now}
+			if err := repo.Put(ctx, entry); err != nil {
+				t.Fatalf("put: %v", err)
+			}
+		}
+
+		if err := repo.Remove(ctx, group.Id, students[0].Id); err != nil {
+			t.Fatalf("remove: %v", err)
+		}
+		if err := repo.Remove(ctx, group.Id, students[0].Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("remove twice: got %v, want ErrNotFound", err)
+		}
+		// entries go away with their student
+		if err := repos.Students.DeleteById(ctx, students[1].Id); err != nil {
+			t.Fatalf("delete student: %v", err)
+		}
+		entries, err := repo.GetByGroupId(ctx, group.Id, domain.WaitlistFIFO)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if want := []int64{students[2].Id}; !slices.Equal(studentIds(t, entries), want) {
+			t.Fatalf("after removing: got %v, want %v", studentIds(t, entries), want)
+		}
+
+		if err := repo.RemoveByGroupId(ctx, group.Id); err != nil {
+			t.Fatalf("remove by group: %v", err)
+		}
+		if entries, err := repo.GetByGroupId(ctx, group.Id, domain.WaitlistFIFO); err != nil || len(entries) != 0 {
+			t.Fatalf("after emptying: got %v, %v", entries, err)
+		}
+	})
+
+	t.Run("References", func(t *testing.T) {
+		repos, group, students := setup(t)
+		repo := repos.Waitlists
+
+		missing := []domain.WaitlistEntry{
+			{GroupId: group.Id + 100, StudentId: students[0].Id, QueuedAt: now},
+			{GroupId: group.Id, StudentId: students[2].Id + 100, QueuedAt: now},
+		}
+		for _, entry := range missing {
+			if err := repo.Put(ctx, entry); !errors.Is(err, repository.ErrConflict) {
+				t.Fatalf("put %+v: got %v, want ErrConflict", entry, err)
+			}
+		}
+
+		// entries go away with their group too
+		entry := domain.WaitlistEntry{GroupId: group.Id, StudentId: students[0].Id, QueuedAt: now}
+		if err := repo.Put(ctx, entry); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+		if err := repos.Groups.DeleteById(ctx, group.Id); err != nil {
+			t.Fatalf("delete group: %v", err)
+		}
+		if entries, err := repo.GetByGroupId(ctx, group.Id, domain.WaitlistFIFO); err != nil || len(entries) != 0 {
+			t.Fatalf("after deleting the group: got %v, %v", entries, err)
+		}
+	})
+}
+
+func RunCourses(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepositories(t).Courses
+
+		created, err := repo.Create(ctx, domain.Course{Code: "CS-101", Title: "Programming"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if created.Id == 0 {
+			t.Fatalf("create: id is not set")
+		}
+
+		stored, err := repo.GetById(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if stored != created {
+			t.Fatalf("get: got %+v, want %+v", stored, created)
+		}
+
+		if _, err := repo.Create(ctx, domain.Course{Code: "CS-101", Title: "Other"}); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate code: got %v, want ErrConflict", err)
+		}
+		if _, err := repo.GetById(ctx, created.Id+100); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get missing: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepositories(t).Courses
+
+		course, err := repo.Create(ctx, domain.Course{Code: "CS-101", Title: "Programming"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		other, err := repo.Create(ctx, domain.Course{Code: "MA-101", Title: "Calculus"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		updated, err := repo.Update(ctx, domain.Course{Id: course.Id, Code: "CS-102", Title: "Programming II"})
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if updated.Code != "CS-102" || updated.Title != "Programming II" {
+			t.Fatalf("update: got %+v", updated)
+		}
+		if _, err := repo.Update(ctx, domain.Course{Id: other.Id, Code: "CS-102", Title: "x"}); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("update to taken code: got %v, want ErrConflict", err)
+		}
+		if _, err := repo.Update(ctx, domain.Course{Id: other.Id + 100, Code: "X", Title: "x"}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("update missing: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("DeleteOnlyWithoutEnrollments", func(t *testing.T) {
+		repos := newRepositories(t)
+		repo := repos.Courses
+
+		course, err := repo.Create(ctx, domain.Course{Code: "CS-101", Title: "Programming"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101"})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		enrollment, err := repos.Enrollments.Create(ctx, domain.Enrollment{CourseId: course.Id, GroupId: group.Id,
+			Status: domain.EnrollmentCompleted})
+		if err != nil {
+			t.Fatalf("enroll: %v", err)
+		}
+		if err := repo.DeleteById(ctx, course.Id); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("delete with enrollments: got %v, want ErrConflict", err)
+		}
+
+		// the enrollment goes away with its group, and the course can go then
+		if err := repos.Groups.DeleteById(ctx, group.Id); err != nil {
+			t.Fatalf("delete group: %v", err)
+		}
+		if _, err := repos.Enrollments.GetById(ctx, enrollment.Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("enrollment of deleted group: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteById(ctx, course.Id); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		if err := repo.DeleteById(ctx, course.Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("delete deleted: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		repos := newRepositories(t)
+		repo := repos.Courses
+
+		var courses []domain.Course
+		for _, course := range []domain.Course{
+			{Code: "CS-101", Title: "Programming"},
+			{Code: "MA-101", Title: "Calculus"},
+			{Code: "PH-101", Title: "Physics"},
+		} {
+			created, err := repo.Create(ctx, course)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			courses = append(courses, created)
+		}
+		group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101"})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		student, err := repos.Students.Create(ctx, domain.Student{FullName: "Ivan Ivanov", Age: 20,
+			GroupId: group.Id, Email: "ivan@example.com"})
+		if err != nil {
+			t.Fatalf("create student: %v", err)
+		}
+
+		// the group takes programming, the student calculus on top, physics was dropped
+		for _, enrollment := range []domain.Enrollment{
+			{CourseId: courses[0].Id, GroupId: group.Id, Status: domain.EnrollmentActive},
+			{CourseId: courses[1].Id, StudentId: student.Id, Status: domain.EnrollmentActive},
+			{CourseId: courses[2].Id, StudentId: student.Id, Status: domain.EnrollmentDropped},
+		} {
+			if _, err := repos.Enrollments.Create(ctx, enrollment); err != nil {
+				t.Fatalf("enroll: %v", err)
+			}
+		}
+
+		codes := func(t *testing.T, filter dto.CourseFilter) []string {
+			page, err := repo.GetAll(ctx, dto.CourseQuery{CourseFilter: filter,
+				PageRequest: dto.PageRequest{SortBy: "code"}})
+			if err != nil {
+				t.Fatalf("get all: %v", err)
+			}
+			if page.Total != int64(len(page.Courses)) {
+				t.Fatalf("total %d, got %d courses", page.Total, len(page.Courses))
+			}
+			var codes []string
+			for _, course := range page.Courses {
+				codes = append(codes, course.Code)
+			}
+			return codes
+		}
+
+		if got := codes(t, dto.CourseFilter{TitleContains: "CALC"}); !slices.Equal(got, []string{"MA-101"}) {
+			t.Fatalf("title contains: got %v", got)
+		}
+		if got := codes(t, dto.CourseFilter{GroupId: group.Id}); !slices.Equal(got, []string{"CS-101"}) {
+			t.Fatalf("taken by group: got %v", got)
+		}
+		byStudent := dto.CourseFilter{StudentId: student.Id, StudentGroupId: group.Id}
+		if got := codes(t, byStudent); !slices.Equal(got, []string{"CS-101", "MA-101"}) {
+			t.Fatalf("taken by student: got %v", got)
+		}
+		byStudent.StudentGroupId = 0
+		if got := codes(t, byStudent); !slices.Equal(got, []string{"MA-101"}) {
+			t.Fatalf("taken by student without group: got %v", got)
+		}
+	})
+}
+
+func RunEnrollments(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+	// whole seconds, so every backend returns exactly what was stored
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// a course, two groups and a student in each of them
+	setup := func(t *testing.T) (*repository.Repositories, domain.Course, []domain.Group, []domain.Student) {
+		repos := newRepositories(t)
+
+		course, err := repos.Courses.Create(ctx, domain.Course{Code: "CS-101", Title: "Programming"})
+		if err != nil {
+			t.Fatalf("create course: %v", err)
+		}
+		var groups []domain.Group
+		var students []domain.Student
+		for i, number := range []string{"A-101", "B-202"} {
+			group, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: number})
+			if err != nil {
+				t.Fatalf("create group: %v", err)
+			}
+			student, err := repos.Students.Create(ctx, domain.Student{FullName: "Ivan Ivanov", Age: 20,
+				GroupId: group.Id, Email: string(rune('a'+i)) + "@example.com"})
+			if err != nil {
+				t.Fatalf("create student: %v", err)
+			}
+			groups, students = append(groups, group), append(students, student)
+		}
+
+		return repos, course, groups, students
+	}
+
+	enroll := func(t *testing.T, repos *repository.Repositories, enrollment domain.Enrollment) domain.Enrollment {
+		enrollment.Status, enrollment.EnrolledAt, enrollment.UpdatedAt = domain.EnrollmentActive, now, now
+		created, err := repos.Enrollments.Create(ctx, enrollment)
+		if err != nil {
+			t.Fatalf("enroll %+v: %v", enrollment, err)
+		}
+		return created
+	}
+
+	ids := func(t *testing.T, repos *repository.Repositories, filter dto.EnrollmentFilter) []int64 {
+		page, err := repos.Enrollments.GetAll(ctx, dto.EnrollmentQuery{EnrollmentFilter: filter})
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		if page.Total != int64(len(page.Enrollments)) {
+			t.Fatalf("total %d, got %d enrollments", page.Total, len(page.Enrollments))
+		}
+		var ids []int64
+		for _, enrollment := range page.Enrollments {
+			ids = append(ids, enrollment.Id)
+		}
+		return ids
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos, course, groups, students := setup(t)
+
+		group := enroll(t, repos, domain.Enrollment{CourseId: course.Id, GroupId: groups[0].Id})
+		elective := enroll(t, repos, domain.Enrollment{CourseId: course.Id, StudentId: students[1].Id})
+		if group.Id == 0 || elective.Id == 0 {
+			t.Fatalf("create: id is not set")
+		}
+
+		stored, err := repos.Enrollments.GetById(ctx, elective.Id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if stored.CourseId != course.Id || stored.StudentId != students[1].Id || stored.GroupId != 0 ||
+			stored.Status != domain.EnrollmentActive || !stored.EnrolledAt.Equal(now) || !stored.UpdatedAt.Equal(now) {
+			t.Fatalf("get: got %+v", stored)
+		}
+		if _, err := repos.Enrollments.GetById(ctx, elective.Id+100); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get missing: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		repos, course, groups, students := setup(t)
+		enroll(t, repos, domain.Enrollment{CourseId: course.Id, GroupId: groups[0].Id})
+		enroll(t, repos, domain.Enrollment{CourseId: course.Id, StudentId: students[0].Id})
+
+		for _, enrollment := range []domain.Enrollment{
+			// once per group and per student
+			{CourseId: course.Id, GroupId: groups[0].Id},
+			{CourseId: course.Id, StudentId: students[0].Id},
+			// and only with what exists
+			{CourseId: course.Id + 100, GroupId: groups[1].Id},
+			{CourseId: course.Id, GroupId: groups[1].Id + 100},
+			{CourseId: course.Id, StudentId: students[1].Id + 100},
+		} {
+			enrollment.Status, enrollment.EnrolledAt, enrollment.UpdatedAt = domain.EnrollmentActive, now, now
+			if _, err := repos.Enrollments.Create(ctx, enrollment); !errors.Is(err, repository.ErrConflict) {
+				t.Fatalf("enroll %+v: got %v, want ErrConflict", enrollment, err)
+			}
+		}
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		repos, course, groups, _ := setup(t)
+		enrollment := enroll(t, repos, domain.Enrollment{CourseId: course.Id, GroupId: groups[0].Id})
+
+		later := now.Add(time.Hour)
+		updated, err := repos.Enrollments.UpdateStatus(ctx, enrollment.Id, domain.EnrollmentCompleted, later)
+		if err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+		if updated.Status != domain.EnrollmentCompleted || !updated.UpdatedAt.Equal(later) ||
+			!updated.EnrolledAt.Equal(now) || updated.GroupId != groups[0].Id {
+			t.Fatalf("update status: got %+v", updated)
+		}
+		if _, err := repos.Enrollments.UpdateStatus(ctx, enrollment.Id+100, domain.EnrollmentDropped, later); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("update missing: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		repos, course, groups, students := setup(t)
+		other, err := repos.Courses.Create(ctx, domain.Course{Code: "MA-101", Title: "Calculus"})
+		if err != nil {
+			t.Fatalf("create course: %v", err)
+		}
+
+		group := enroll(t, repos, domain.Enrollment{CourseId: course.Id, GroupId: groups[0].Id})
+		own := enroll(t, repos, domain.Enrollment{CourseId: course.Id, StudentId: students[0].Id})
+		classmate := enroll(t, repos, domain.Enrollment{CourseId: course.Id, StudentId: students[1].Id})
+		otherCourse := enroll(t, repos, domain.Enrollment{CourseId: other.Id, GroupId: groups[1].Id})
+		if _, err := repos.Enrollments.UpdateStatus(ctx, classmate.Id, domain.EnrollmentDropped, now); err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter dto.EnrollmentFilter
+			want   []int64
+		}{
+			{"course", dto.EnrollmentFilter{CourseId: course.Id}, []int64{group.Id, own.Id, classmate.Id}},
+			{"status", dto.EnrollmentFilter{Status: domain.EnrollmentActive},
+				[]int64{group.Id, own.Id, otherCourse.Id}},
+			{"group", dto.EnrollmentFilter{GroupId: groups[0].Id}, []int64{group.Id}},
+			{"student", dto.EnrollmentFilter{StudentId: students[1].Id}, []int64{classmate.Id}},
+			// electives belong to the group of their student
+			{"group numbers", dto.EnrollmentFilter{GroupNumberIn: []string{"B-202"}},
+				[]int64{classmate.Id, otherCourse.Id}},
+			{"electives of", dto.EnrollmentFilter{GroupNumberIn: []string{"A-101"}, ElectivesOf: students[0].Id},
+				[]int64{group.Id, own.Id}},
+		}
+		for _, test := range tests {
+			if got := ids(t, repos, test.filter); !slices.Equal(got, test.want) {
+				t.Fatalf("%s: got %v, want %v", test.name, got, test.want)
+			}
+		}
+
+		// electives move with their student
+		if _, err := repos.Students.Patch(ctx, students[1].Id, dto.StudentChanges{GroupId: &groups[0].Id}); err != nil {
+			t.Fatalf("move student: %v", err)
+		}
+		if got := ids(t, repos, dto.EnrollmentFilter{GroupNumberIn: []string{"B-202"}}); !slices.Equal(got, []int64{otherCourse.Id}) {
+			t.Fatalf("after moving the student: got %v", got)
+		}
+
+		page, err := repos.Enrollments.GetAll(ctx, dto.EnrollmentQuery{
+			EnrollmentFilter: dto.EnrollmentFilter{CourseId: course.Id},
+			PageRequest:      dto.PageRequest{Limit: 2, SortDirection: dto.SortDesc},
+		})
+		if err != nil || page.Total != 3 || len(page.Enrollments) != 2 || page.Enrollments[0].Id != classmate.Id ||
+			page.NextCursor == "" {
+			t.Fatalf("first page: got %+v, %v", page, err)
+		}
+	})
+
+	t.Run("Cascade", func(t *testing.T) {
+		repos, course, groups, students := setup(t)
+		group := enroll(t, repos, domain.Enrollment{CourseId: course.Id, GroupId: groups[1].Id})
+		elective := enroll(t, repos, domain.Enrollment{CourseId: course.Id, StudentId: students[0].Id})
+
+		if err := repos.Students.DeleteById(ctx, students[0].Id); err != nil {
+			t.Fatalf("delete student: %v", err)
+		}
+		if _, err := repos.Enrollments.GetById(ctx, elective.Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("elective of deleted student: got %v, want ErrNotFound", err)
+		}
+		if got := ids(t, repos, dto.EnrollmentFilter{CourseId: course.Id}); !slices.Equal(got, []int64{group.Id}) {
+			t.Fatalf("after deleting the student: got %v", got)
+		}
+
+		// the group enrollment stays while the group has students, and goes with it
+		if err := repos.Students.DeleteById(ctx, students[1].Id); err != nil {
+			t.Fatalf("delete student: %v", err)
+		}
+		if err := repos.Groups.DeleteById(ctx, groups[1].Id); err != nil {
+			t.Fatalf("delete group: %v", err)
+		}
+		if got := ids(t, repos, dto.EnrollmentFilter{CourseId: course.Id}); len(got) != 0 {
+			t.Fatalf("after deleting the group: got %v", got)
+		}
+	})
+}
+
+func RunRefreshTokens(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+	// whole seconds, so every backend returns exactly what was stored
+	now := time.Now().UTC().Truncate(time.Second)
+
+	newToken := func(hash, family string) domain.RefreshToken {
+		return domain.RefreshToken{
+			TokenHash: hash,
+			Subject:   "admin",
+			FamilyId:  family,
+			ExpiresAt: now.Add(time.Hour),
+			CreatedAt: now,
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepositories(t).RefreshTokens
+
+		if err := repo.Create(ctx, newToken("a", "f1")); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		stored, err := repo.GetByHash(ctx, "a")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if stored.Subject != "admin" || stored.FamilyId != "f1" || stored.RevokedAt != nil || stored.TenantId != "" ||
+			!stored.ExpiresAt.Equal(now.Add(time.Hour)) {
+			t.Fatalf("get: got %+v", stored)
+		}
+
+		if _, err := repo.GetByHash(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get missing: got %v, want ErrNotFound", err)
+		}
+
+		bound := newToken("b", "f2")
+		bound.TenantId = "math"
+		if err := repo.Create(ctx, bound); err != nil {
+			t.Fatalf("create bound: %v", err)
+		}
+		if stored, _ := repo.GetByHash(ctx, "b"); stored.TenantId != "math" {
+			t.Fatalf("get bound: got tenant %q", stored.TenantId)
+		}
+	})
+
+	t.Run("RevokeOnce", func(t *testing.T) {
+		repo := newRepositories(t).RefreshTokens
+
+		if err := repo.Create(ctx, newToken("a", "f1")); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if err := repo.Revoke(ctx, "a", now); err != nil {
+			t.Fatalf("revoke: %v", err)
+		}
+		if err := repo.Revoke(ctx, "a", now); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("second revoke: got %v, want ErrNotFound", err)
+		}
+
+		stored, err := repo.GetByHash(ctx, "a")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if stored.RevokedAt == nil || !stored.RevokedAt.Equal(now) {
+			t.Fatalf("revoked at: got %v, want %v", stored.RevokedAt, now)
+		}
+	})
+
+	t.Run("RevokeFamily", func(t *testing.T) {
+		repo := newRepositories(t).RefreshTokens
+
+		for _, token := range []domain.RefreshToken{newToken("a", "f1"), newToken("b", "f1"), newToken("c", "f2")} {
+			if err := repo.Create(ctx, token); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+		}
+		if err := repo.RevokeFamily(ctx, "f1", now); err != nil {
+			t.Fatalf("revoke family: %v", err)
+		}
+
+		for hash, wantRevoked := range map[string]bool{"a": true, "b": true, "c": false} {
+			stored, err := repo.GetByHash(ctx, hash)
+			if err != nil {
+				t.Fatalf("get %s: %v", hash, err)
+			}
+			if (stored.RevokedAt != nil) != wantRevoked {
+				t.Fatalf("token %s: revoked %v, want %v", hash, stored.RevokedAt != nil, wantRevoked)
+			}
+		}
+	})
+}
+
+func RunAccess(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("UsersAndRoles", func(t *testing.T) {
+		repo := newRepositories(t).Access
+
+		user := domain.User{Username: "teacher1", PasswordHash: "hash", Roles: []string{"teacher"}}
+		if err := repo.CreateUser(ctx, user); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if err := repo.CreateUser(ctx, user); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate: got %v, want ErrConflict", err)
+		}
+
+		stored, err := repo.GetUser(ctx, "teacher1")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if stored.PasswordHash != "hash" || stored.StudentId != nil || !slices.Equal(stored.Roles, []string{"teacher"}) {
+			t.Fatalf("get: got %+v", stored)
+		}
+
+		if err := repo.SetUserRoles(ctx, "teacher1", []string{"student", "admin"}); err != nil {
+			t.Fatalf("set roles: %v", err)
+		}
+		stored, _ = repo.GetUser(ctx, "teacher1")
+		if !slices.Equal(stored.Roles, []string{"admin", "student"}) {
+			t.Fatalf("set roles: got %v", stored.Roles)
+		}
+
+		if err := repo.DeleteUser(ctx, "teacher1"); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		if _, err := repo.GetUser(ctx, "teacher1"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get deleted: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteUser(ctx, "teacher1"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("delete deleted: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Grants", func(t *testing.T) {
+		repo := newRepositories(t).Access
+
+		roles, err := repo.RoleNames(ctx)
+		if err != nil {
+			t.Fatalf("role names: %v", err)
+		}
+		if !slices.Equal(roles, []string{"admin", "student", "teacher"}) {
+			t.Fatalf("role names: got %v", roles)
+		}
+
+		if err := repo.CreateUser(ctx, domain.User{Username: "t", PasswordHash: "hash",
+			Roles: []string{"teacher"}}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		grants, err := repo.GetGrants(ctx, "t")
+		if err != nil {
+			t.Fatalf("grants: %v", err)
+		}
+		want := domain.Grant{Permission: domain.PermissionStudentsWrite, Scope: domain.ScopeCurated}
+		if !slices.Contains(grants, want) || len(grants) != 5 {
+			t.Fatalf("grants: got %v", grants)
+		}
+
+		if grants, err := repo.GetGrants(ctx, "missing"); err != nil || len(grants) != 0 {
+			t.Fatalf("grants of missing user: got %v, %v", grants, err)
+		}
+	})
+
+	t.Run("Curators", func(t *testing.T) {
+		repos := newRepositories(t)
+		repo := repos.Access
+
+		first, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-1"})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		second, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "B-2"})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		if err := repo.CreateUser(ctx, domain.User{Username: "t", PasswordHash: "hash"}); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+
+		for _, id := range []int64{second.Id, first.Id, first.Id} {
+			if err := repo.AddCurator(ctx, id, "t"); err != nil {
+				t.Fatalf("add curator: %v", err)
+			}
+		}
+		groupNumbers, err := repo.CuratedGroupNumbers(ctx, "t")
+		if err != nil {
+			t.Fatalf("curated groups: %v", err)
+		}
+		if !slices.Equal(groupNumbers, []string{"A-1", "B-2"}) {
+			t.Fatalf("curated groups: got %v", groupNumbers)
+		}
+
+		if err := repo.RemoveCurator(ctx, first.Id, "t"); err != nil {
+			t.Fatalf("remove curator: %v", err)
+		}
+		if err := repo.RemoveCurator(ctx, first.Id, "t"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("remove curator twice: got %v, want ErrNotFound", err)
+		}
+
+		// curators go away with their group
+		if err := repos.Groups.DeleteById(ctx, second.Id); err != nil {
+			t.Fatalf("delete group: %v", err)
+		}
+		groupNumbers, _ = repo.CuratedGroupNumbers(ctx, "t")
+		if len(groupNumbers) != 0 {
+			t.Fatalf("curated groups after delete: got %v", groupNumbers)
+		}
+	})
+}
+
+func RunTenants(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+	// whole seconds, so every backend returns exactly what was stored
+	now := time.Now().UTC().Truncate(time.Second)
+	math := tenant.WithId(ctx, "math")
+
+	newTenant := func(t *testing.T, repos *repository.Repositories) {
+		if _, err := repos.Tenants.Create(ctx, domain.Tenant{Id: "math", Name: "Maths", CreatedAt: now}); err != nil {
+			t.Fatalf("create tenant: %v", err)
+		}
+	}
+
+	t.Run("CreateGetUpdate", func(t *testing.T) {
+		repos := newRepositories(t)
+		repo := repos.Tenants
+		newTenant(t, repos)
+
+		if _, err := repo.Create(ctx, domain.Tenant{Id: "math", Name: "Other", CreatedAt: now}); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate: got %v, want ErrConflict", err)
+		}
+
+		stored, err := repo.GetById(ctx, "math")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if stored.Name != "Maths" || !stored.CreatedAt.Equal(now) {
+			t.Fatalf("get: got %+v", stored)
+		}
+
+		updated, err := repo.Update(ctx, domain.Tenant{Id: "math", Name: "Mathematics"})
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if updated.Name != "Mathematics" || !updated.CreatedAt.Equal(now) {
+			t.Fatalf("update: got %+v", updated)
+		}
+		if _, err := repo.Update(ctx, domain.Tenant{Id: "missing", Name: "x"}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("update missing: got %v, want ErrNotFound", err)
+		}
+
+		tenants, err := repo.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("get all: %v", err)
+		}
+		if len(tenants) != 2 || tenants[0].Id != tenant.DefaultId || tenants[1].Id != "math" {
+			t.Fatalf("get all: got %+v", tenants)
+		}
+	})
+
+	t.Run("DeleteOnlyWhenEmpty", func(t *testing.T) {
+		repos := newRepositories(t)
+		repo := repos.Tenants
+		newTenant(t, repos)
+
+		group, err := repos.Groups.Create(math, domain.Group{GroupNumber: "A-1"})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		if err := repo.DeleteById(ctx, "math"); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("delete with groups: got %v, want ErrConflict", err)
+		}
+
+		if err := repos.Groups.DeleteById(math, group.Id); err != nil {
+			t.Fatalf("delete group: %v", err)
+		}
+		course, err := repos.Courses.Create(math, domain.Course{Code: "CS-101", Title: "Programming"})
+		if err != nil {
+			t.Fatalf("create course: %v", err)
+		}
+		if err := repo.DeleteById(ctx, "math"); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("delete with courses: got %v, want ErrConflict", err)
+		}
+
+		if err := repos.Courses.DeleteById(math, course.Id); err != nil {
+			t.Fatalf("delete course: %v", err)
+		}
+		if err := repo.DeleteById(ctx, "math"); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		if err := repo.DeleteById(ctx, "math"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("delete deleted: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		repos := newRepositories(t)
+		newTenant(t, repos)
+
+		// the same group number and email are fine in different tenants
+		group, err := repos.Groups.Create(math, domain.Group{GroupNumber: "A-1"})
+		if err != nil {
+			t.Fatalf("create group: %v", err)
+		}
+		if _, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-1"}); err != nil {
+			t.Fatalf("create group in another tenant: %v", err)
+		}
+		if _, err := repos.Groups.Create(math, domain.Group{GroupNumber: "A-1"}); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate group: got %v, want ErrConflict", err)
+		}
+
+		student := domain.Student{FullName: "Ann Lee", Age: 20, GroupId: group.Id, Email: "ann@example.com"}
+		created, err := repos.Students.Create(math, student)
+		if err != nil {
+			t.Fatalf("create student: %v", err)
+		}
+		// a student can't refer to the group of another tenant
+		if _, err := repos.Students.Create(ctx, student); !errors.Is(err, repository.ErrReferenceNotFound) {
+			t.Fatalf("create student in a group of another tenant: got %v, want ErrReferenceNotFound", err)
+		}
+		if _, err := repos.Students.Create(ctx, domain.Student{FullName: "Ann Lee", Age: 20, Email: "ann@example.com"}); err != nil {
+			t.Fatalf("create student in another tenant: %v", err)
+		}
+		if _, err := repos.Students.Create(math, student); !errors.Is(err, repository.ErrConflict) {
+			t.Fatalf("create duplicate student: got %v, want ErrConflict", err)
+		}
+
+		if _, err := repos.Students.GetById(ctx, created.Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get student of another tenant: got %v, want ErrNotFound", err)
+		}
+		if _, err := repos.Groups.GetById(ctx, group.Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("get group of another tenant: got %v, want ErrNotFound", err)
+		}
+		if err := repos.Students.DeleteById(ctx, created.Id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("delete student of another tenant: got %v, want ErrNotFound", err)
+		}
+		if _, err := repos.Groups.Update(ctx, domain.Group{Id: group.Id, GroupNumber: "B-2"}); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("update group of another tenant: got %v, want ErrNotFound", err)
+		}
+
+		found, err := repos.Students.GetByEmail(math, student.Email)
+		if err != nil || found.Id != created.Id {
+			t.Fatalf("get by email: got %+v, %v", found, err)
+		}
+		if count, err := repos.Students.CountByGroupId(math, group.Id); err != nil || count != 1 {
+			t.Fatalf("count by group id: got %d, %v", count, err)
+		}
+
+		students, err := repos.Students.GetAll(math, dto.StudentQuery{})
+		if err != nil || students.Total != 1 || students.Students[0].Id != created.Id {
+			t.Fatalf("list students: got %+v, %v", students, err)
+		}
+		groups, err := repos.Groups.GetAll(math, dto.GroupQuery{})
+		if err != nil || groups.Total != 1 || groups.Groups[0].Id != group.Id {
+			t.Fatalf("list groups: got %+v, %v", groups, err)
+		}
+
+		// users are looked up in every tenant, curated groups only in the current one
+		if err := repos.Access.CreateUser(math, domain.User{Username: "t", PasswordHash: "hash"}); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		user, err := repos.Access.GetUser(ctx, "t")
+		if err != nil || user.TenantId != "math" {
+			t.Fatalf("get user: got %+v, %v", user, err)
+		}
+		if err := repos.Access.AddCurator(math, group.Id, "t"); err != nil {
+			t.Fatalf("add curator: %v", err)
+		}
+		if groupNumbers, _ := repos.Access.CuratedGroupNumbers(ctx, "t"); len(groupNumbers) != 0 {
+			t.Fatalf("curated groups in another tenant: got %v", groupNumbers)
+		}
+		if groupNumbers, _ := repos.Access.CuratedGroupNumbers(math, "t"); !slices.Equal(groupNumbers, []string{"A-1"}) {
+			t.Fatalf("curated groups: got %v", groupNumbers)
+		}
+	})
+}
+
+func RunTransactions(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	t.Run("Commit", func(t *testing.T) {
+		repos := newRepositories(t)
+
+		var group domain.Group
+		var student domain.Student
+		err := repos.Tx.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+			var err error
+			group, err = repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101"})
+			if err != nil {
+				return err
+			}
+			student, err = repos.Students.Create(ctx, domain.Student{
+				FullName: "Ivan Ivanov", Age: 20, GroupId: group.Id, Email: "ivan@example.com",
+			})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("transaction: %v", err)
+		}
+
+		if _, err := repos.Groups.GetById(ctx, group.Id); err != nil {
+			t.Fatalf("group is not committed: %v", err)
+		}
+		if _, err := repos.Students.GetById(ctx, student.Id); err != nil {
+			t.Fatalf("student is not committed: %v", err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		repos := newRepositories(t)
+
+		existing, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101"})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		err = repos.Tx.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+			if _, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "B-202"}); err != nil {
+				return err
+			}
+			if err := repos.Groups.DeleteById(ctx, existing.Id); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("transaction: got %v, want the error returned by fn", err)
+		}
+
+		if _, err := repos.Groups.GetByGroupNumber(ctx, "B-202"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("created group survived rollback: %v", err)
+		}
+		if _, err := repos.Groups.GetById(ctx, existing.Id); err != nil {
+			t.Fatalf("deleted group is not restored by rollback: %v", err)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		repos := newRepositories(t)
+
+		err := repos.Tx.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+			if _, err := repos.Groups.Create(ctx, domain.Group{GroupNumber: "A-101"}); err != nil {
+				return err
+			}
+			return repos.Tx.WithinTransaction(ctx, repository.SerializableTx, func(ctx context.Context) error {
+				_, err := repos.Groups.GetByGroupNumber(ctx, "A-101")
+				return err
+			})
+		})
+		if err != nil {
+			t.Fatalf("nested transaction does not see the outer one: %v", err)
+		}
+	})
+}
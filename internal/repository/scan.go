@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+)
+
+// nullableId stores 0 as null, for references that may be missing
+func nullableId(id int64) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// rowScanner is satisfied by pgx.Row, pgx.Rows, *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanStudent(row rowScanner) (domain.Student, error) {
+	var student domain.Student
+	var groupId *int64
+
+	err := row.Scan(&student.Id, &student.FullName, &student.Age, &groupId, &student.GroupNumber, &student.Email)
+	if groupId != nil {
+		student.GroupId = *groupId
+	}
+
+	return student, err
+}
+
+func scanGroup(row rowScanner) (domain.Group, error) {
+	var group domain.Group
+
+	err := row.Scan(&group.Id, &group.GroupNumber, &group.Capacity, &group.Waitlist)
+
+	return group, err
+}
+
+// scanRosterRow reads a row of rosterSQL, student is nil when the row only
+// carries the group
+func scanRosterRow(row rowScanner) (group domain.Group, total int64, student *domain.Student, err error) {
+	var id, groupId *int64
+	var fullName, groupNumber, email *string
+	var age *int
+
+	err = row.Scan(&group.Id, &group.GroupNumber, &group.Capacity, &group.Waitlist, &total, &id, &fullName, &age, &groupId, &groupNumber, &email)
+	if err != nil || id == nil {
+		return group, total, nil, err
+	}
+
+	return group, total, &domain.Student{
+		Id:          *id,
+		FullName:    *fullName,
+		Age:         *age,
+		GroupId:     *groupId,
+		GroupNumber: *groupNumber,
+		Email:       *email,
+	}, nil
+}
+
+func scanWaitlistEntry(row rowScanner) (domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+
+	err := row.Scan(&entry.GroupId, &entry.StudentId, &entry.Priority, &entry.QueuedAt)
+
+	return entry, err
+}
+
+func scanCourse(row rowScanner) (domain.Course, error) {
+	var course domain.Course
+
+	err := row.Scan(&course.Id, &course.Code, &course.Title)
+
+	return course, err
+}
+
+func scanEnrollment(row rowScanner) (domain.Enrollment, error) {
+	var enrollment domain.Enrollment
+	var groupId, studentId *int64
+
+	err := row.Scan(&enrollment.Id, &enrollment.CourseId, &groupId, &studentId, &enrollment.Status,
+		&enrollment.EnrolledAt, &enrollment.UpdatedAt)
+	if groupId != nil {
+		enrollment.GroupId = *groupId
+	}
+	if studentId != nil {
+		enrollment.StudentId = *studentId
+	}
+
+	return enrollment, err
+}
+
+func scanRefreshToken(row rowScanner) (domain.RefreshToken, error) {
+	var token domain.RefreshToken
+
+	err := row.Scan(&token.TokenHash, &token.Subject, &token.FamilyId, &token.ExpiresAt, &token.RevokedAt,
+		&token.CreatedAt, &token.TenantId)
+
+	return token, err
+}
+
+func scanTenant(row rowScanner) (domain.Tenant, error) {
+	var tenant domain.Tenant
+
+	err := row.Scan(&tenant.Id, &tenant.Name, &tenant.CreatedAt)
+
+	return tenant, err
+}
@@ -0,0 +1,33 @@
+package repository_test
+
+import (
+	"StudentManager/internal/config"
+	"StudentManager/internal/migrate"
+	"StudentManager/internal/repository"
+	"StudentManager/internal/repository/repotest"
+	"StudentManager/pkg/database/sqlite"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// every test gets its own database file, migrated from scratch
+func TestSQLiteRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		db, err := sqlite.New(config.Database{Path: filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := migrate.NewSQLite(db)
+		if err != nil {
+			t.Fatalf("load migrations: %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatalf("apply migrations: %v", err)
+		}
+
+		return repository.NewSQLiteRepositories(db)
+	})
+}
@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type StudentRepoMemory struct {
+	lock     *memoryLock
+	lastId   int64
+	students map[int64]domain.Student
+	// student id -> tenant id
+	tenants map[int64]string
+	// group numbers are read from there, it shares the lock
+	groups *GroupRepoMemory
+}
+
+func NewStudentRepoMemory() *StudentRepoMemory {
+	students, _ := newStudentAndGroupRepoMemory(&memoryLock{})
+	return students
+}
+
+// newStudentAndGroupRepoMemory links the two repositories, they refer to
+// each other like the student and group tables do
+func newStudentAndGroupRepoMemory(lock *memoryLock) (*StudentRepoMemory, *GroupRepoMemory) {
+	students := newStudentRepoMemory(lock)
+	groups := newGroupRepoMemory(lock, students)
+	students.groups = groups
+
+	return students, groups
+}
+
+func newStudentRepoMemory(lock *memoryLock) *StudentRepoMemory {
+	return &StudentRepoMemory{
+		lock:     lock,
+		students: make(map[int64]domain.Student),
+		tenants:  make(map[int64]string),
+	}
+}
+
+func (repo *StudentRepoMemory) GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return dto.StudentPage{}, err
+	}
+	defer release()
+
+	return repo.list(tenant.IdFrom(ctx), query, nil)
+}
+
+// list mirrors the student list query, in narrows it further when set. The
+// caller holds the lock.
+func (repo *StudentRepoMemory) list(tenantId string, query dto.StudentQuery,
+	in func(domain.Student) bool) (dto.StudentPage, error) {
+	page, err := checkPage(query.PageRequest, studentSortColumns)
+	if err != nil {
+		return dto.StudentPage{}, err
+	}
+	after, err := decodeCursor(page, studentSortColumns)
+	if err != nil {
+		return dto.StudentPage{}, err
+	}
+
+	var students []domain.Student
+	for id, student := range repo.students {
+		student = repo.withGroup(student)
+		if repo.tenants[id] != tenantId || !studentMatches(student, query.StudentFilter) || in != nil && !in(student) {
+			continue
+		}
+		students = append(students, student)
+	}
+	total := int64(len(students))
+
+	sort.Slice(students, func(i, j int) bool {
+		order := compareValues(studentSortValue(students[i], page.SortBy), studentSortValue(students[j], page.SortBy))
+		if order == 0 {
+			order = compareValues(students[i].Id, students[j].Id)
+		}
+		if page.SortDirection == dto.SortDesc {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	students, nextCursor := pageSlice(students, page, after, studentSortValue, studentId)
+
+	return dto.StudentPage{
+		Students:   students,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *StudentRepoMemory) Create(ctx context.Context, student domain.Student) (domain.Student, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Student{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if repo.emailTaken(tenantId, student.Email, 0) {
+		return domain.Student{}, ErrConflict
+	}
+	if repo.groupMissing(tenantId, student.GroupId) {
+		return domain.Student{}, ErrReferenceNotFound
+	}
+
+	repo.lastId++
+	student.Id = repo.lastId
+	student.GroupNumber = ""
+	repo.students[student.Id] = student
+	repo.tenants[student.Id] = tenantId
+
+	return repo.withGroup(student), nil
+}
+
+func (repo *StudentRepoMemory) GetById(ctx context.Context, id int64) (domain.Student, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Student{}, err
+	}
+	defer release()
+
+	student, ok := repo.students[id]
+	if !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return domain.Student{}, ErrNotFound
+	}
+
+	return repo.withGroup(student), nil
+}
+
+func (repo *StudentRepoMemory) Update(ctx context.Context, student domain.Student) (domain.Student, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Student{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if _, ok := repo.students[student.Id]; !ok || repo.tenants[student.Id] != tenantId {
+		return domain.Student{}, ErrNotFound
+	}
+	if repo.emailTaken(tenantId, student.Email, student.Id) {
+		return domain.Student{}, ErrConflict
+	}
+	if repo.groupMissing(tenantId, student.GroupId) {
+		return domain.Student{}, ErrReferenceNotFound
+	}
+
+	student.GroupNumber = ""
+	repo.students[student.Id] = student
+
+	return repo.withGroup(student), nil
+}
+
+func (repo *StudentRepoMemory) Patch(ctx context.Context, id int64, changes dto.StudentChanges) (domain.Student, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Student{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	student, ok := repo.students[id]
+	if !ok || repo.tenants[id] != tenantId {
+		return domain.Student{}, ErrNotFound
+	}
+
+	if changes.FullName != nil {
+		student.FullName = *changes.FullName
+	}
+	if changes.Age != nil {
+		student.Age = *changes.Age
+	}
+	if changes.GroupId != nil {
+		if repo.groupMissing(tenantId, *changes.GroupId) {
+			return domain.Student{}, ErrReferenceNotFound
+		}
+		student.GroupId = *changes.GroupId
+	}
+	if changes.Email != nil {
+		if repo.emailTaken(tenantId, *changes.Email, id) {
+			return domain.Student{}, ErrConflict
+		}
+		student.Email = *changes.Email
+	}
+
+	repo.students[id] = student
+
+	return repo.withGroup(student), nil
+}
+
+func (repo *StudentRepoMemory) DeleteById(ctx context.Context, id int64) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, ok := repo.students[id]; !ok || repo.tenants[id] != tenant.IdFrom(ctx) {
+		return ErrNotFound
+	}
+	delete(repo.students, id)
+	delete(repo.tenants, id)
+
+	return nil
+}
+
+func (repo *StudentRepoMemory) GetByEmail(ctx context.Context, email string) (domain.Student, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Student{}, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	for id, student := range repo.students {
+		if repo.tenants[id] == tenantId && student.Email == email {
+			return repo.withGroup(student), nil
+		}
+	}
+
+	return domain.Student{}, ErrNotFound
+}
+
+func (repo *StudentRepoMemory) CountByGroupId(ctx context.Context, groupId int64) (int64, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	var count int64
+	for id, student := range repo.students {
+		if repo.tenants[id] == tenantId && student.GroupId == groupId {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (repo *StudentRepoMemory) MoveGroup(ctx context.Context, fromGroupId, toGroupId int64) (int64, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	if repo.groupMissing(tenantId, toGroupId) {
+		return 0, ErrConflict
+	}
+
+	var moved int64
+	for id, student := range repo.students {
+		if repo.tenants[id] == tenantId && student.GroupId == fromGroupId {
+			student.GroupId = toGroupId
+			repo.students[id] = student
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+func (repo *StudentRepoMemory) snapshot() func() {
+	lastId := repo.lastId
+	students := make(map[int64]domain.Student, len(repo.students))
+	for id, student := range repo.students {
+		students[id] = student
+	}
+	tenants := maps.Clone(repo.tenants)
+
+	return func() {
+		repo.lastId = lastId
+		repo.students = students
+		repo.tenants = tenants
+	}
+}
+
+// emailTaken mirrors the unique constraint on tenant and email: comparison is
+// case-sensitive, the same way Postgres compares text values.
+func (repo *StudentRepoMemory) emailTaken(tenantId, email string, exceptId int64) bool {
+	for id, student := range repo.students {
+		if id != exceptId && repo.tenants[id] == tenantId && student.Email == email {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withGroup fills in the number of the student's group the way student_view does
+func (repo *StudentRepoMemory) withGroup(student domain.Student) domain.Student {
+	student.GroupNumber = repo.groups.groups[student.GroupId].GroupNumber
+
+	return student
+}
+
+// groupMissing mirrors the foreign key of the student's group
+func (repo *StudentRepoMemory) groupMissing(tenantId string, groupId int64) bool {
+	if groupId == 0 {
+		return false
+	}
+	_, ok := repo.groups.groups[groupId]
+
+	return !ok || repo.groups.tenants[groupId] != tenantId
+}
+
+// studentMatches mirrors the filter conditions of newStudentListSQL
+func studentMatches(student domain.Student, filter dto.StudentFilter) bool {
+	if filter.GroupNumber != "" && student.GroupNumber != filter.GroupNumber {
+		return false
+	}
+	if filter.AgeMin > 0 && student.Age < filter.AgeMin {
+		return false
+	}
+	if filter.AgeMax > 0 && student.Age > filter.AgeMax {
+		return false
+	}
+	if filter.EmailDomain != "" &&
+		!strings.HasSuffix(strings.ToLower(student.Email), "@"+strings.ToLower(filter.EmailDomain)) {
+		return false
+	}
+	if filter.NameContains != "" &&
+		!strings.Contains(strings.ToLower(student.FullName), strings.ToLower(filter.NameContains)) {
+		return false
+	}
+	if len(filter.GroupNumberIn) > 0 && !slices.Contains(filter.GroupNumberIn, student.GroupNumber) {
+		return false
+	}
+	if filter.Id != 0 && student.Id != filter.Id {
+		return false
+	}
+
+	return true
+}
@@ -2,13 +2,24 @@ package repository
 
 import (
 	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
 	"context"
-	"fmt"
-	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
+	"log/slog"
 )
 
+// students are read from student_view, which adds the number of their group
+const studentColumns = "id, full_name, age, group_id, group_number, email"
+
+// studentWriteSQL wraps an insert or an update of one student, so the written
+// row comes back with the number of its group in the same statement
+func studentWriteSQL(write string) string {
+	return "with s as (" + write + " returning id, full_name, age, group_id, email) " +
+		"select s.id, s.full_name, s.age, s.group_id, coalesce(g.group_number, ''), s.email " +
+		`from s left join "group" g on g.id = s.group_id`
+}
+
 type StudentRepoPostgres struct {
 	db *pgxpool.Pool
 }
@@ -19,71 +30,164 @@ func NewStudentRepoPostgres(db *pgxpool.Pool) *StudentRepoPostgres {
 	}
 }
 
-func (repo *StudentRepoPostgres) GetAll(ctx context.Context) (pgx.Rows, error) {
-	database := repo.db
+func (repo *StudentRepoPostgres) GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
 
-	students, err := database.Query(ctx,
-		"select * from student order by id")
+	page, err := checkPage(query.PageRequest, studentSortColumns)
 	if err != nil {
-		log.Printf("%s: query executement", err)
-		return nil, err
+		return dto.StudentPage{}, err
+	}
+	after, err := decodeCursor(page, studentSortColumns)
+	if err != nil {
+		return dto.StudentPage{}, err
 	}
 
-	return students, err
+	builder := newStudentListSQL(tenant.IdFrom(ctx), query.StudentFilter, postgresDialect)
 
+	var total int64
+	countSQL, countArgs := builder.count("student_view")
+	if err := database.QueryRow(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.StudentPage{}, convertPostgresError(err)
+	}
+
+	listSQL, listArgs := builder.page("student_view", studentColumns, page, after)
+	rows, err := database.Query(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.StudentPage{}, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	var students []domain.Student
+	for rows.Next() {
+		student, err := scanStudent(rows)
+		if err != nil {
+			return dto.StudentPage{}, err
+		}
+		students = append(students, student)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.StudentPage{}, convertPostgresError(err)
+	}
+
+	students, nextCursor := trimPage(students, page, studentSortValue, studentId)
+
+	return dto.StudentPage{
+		Students:   students,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *StudentRepoPostgres) Create(ctx context.Context, student domain.Student) (domain.Student, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	createdStudent, err := scanStudent(database.QueryRow(ctx,
+		studentWriteSQL("insert into student(tenant_id, full_name, age, group_id, email) values($1, $2, $3, $4, $5)"),
+		tenant.IdFrom(ctx), student.FullName, student.Age, nullableId(student.GroupId), student.Email))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Student{}, convertPostgresWriteError(err)
+	}
+
+	return createdStudent, nil
 }
 
-func (repo *StudentRepoPostgres) Create(ctx context.Context, student domain.Student) (pgx.Rows, error) {
-	database := repo.db
+func (repo *StudentRepoPostgres) GetById(ctx context.Context, id int64) (domain.Student, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
 
-	_, err := database.Query(ctx,
-		"insert into student(full_name, age, group_number, email) values($1, $2, $3, $4)",
-		student.FullName, student.Age, student.GroupNumber, student.Email)
+	student, err := scanStudent(database.QueryRow(ctx,
+		"select "+studentColumns+" from student_view where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx)))
 	if err != nil {
-		log.Printf("%s: query executement", err)
-		return nil, err
+		return domain.Student{}, convertPostgresError(err)
 	}
-	studentRows, err := database.Query(ctx, "select * from student where email = $1", student.Email)
 
-	return studentRows, err
+	return student, nil
 }
-func (repo *StudentRepoPostgres) GetById(ctx context.Context, id int64) pgx.Row {
-	database := repo.db
 
-	student := database.QueryRow(ctx,
-		"select * from student where id = $1", id)
+func (repo *StudentRepoPostgres) Update(ctx context.Context, student domain.Student) (domain.Student, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	updatedStudent, err := scanStudent(database.QueryRow(ctx,
+		studentWriteSQL("update student set full_name = $1, age = $2, group_id = $3, email = $4 where id = $5 and tenant_id = $6"),
+		student.FullName, student.Age, nullableId(student.GroupId), student.Email, student.Id, tenant.IdFrom(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or user doesn't exists", "err", err)
+		return domain.Student{}, convertPostgresWriteError(err)
+	}
 
-	return student
+	return updatedStudent, nil
 }
-func (repo *StudentRepoPostgres) Update(ctx context.Context, student domain.Student) (pgx.Rows, error) {
-	database := repo.db
 
-	_, err := database.Query(ctx,
-		"update student set full_name = $1, age = $2, group_number = $3, email = $4 where id = $5",
-		student.FullName, student.Age, student.GroupNumber, student.Email, student.Id)
+func (repo *StudentRepoPostgres) Patch(ctx context.Context, id int64, changes dto.StudentChanges) (domain.Student, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	set, args := studentSetClause(changes, postgresPlaceholder)
+	args = append(args, id, tenant.IdFrom(ctx))
+
+	patchedStudent, err := scanStudent(database.QueryRow(ctx,
+		studentWriteSQL("update student set "+set+" where id = "+postgresPlaceholder(len(args)-1)+
+			" and tenant_id = "+postgresPlaceholder(len(args))),
+		args...))
 	if err != nil {
-		log.Printf("%s: query executement or user doesn't exists", err)
-		return nil, err
+		slog.ErrorContext(ctx, "query executement or user doesn't exists", "err", err)
+		return domain.Student{}, convertPostgresWriteError(err)
 	}
-	studentRows, err := database.Query(ctx, "select * from student where id = $1", student.Id)
 
-	return studentRows, err
+	return patchedStudent, nil
 }
+
 func (repo *StudentRepoPostgres) DeleteById(ctx context.Context, id int64) error {
-	database := repo.db
-	_, err := database.Exec(ctx, "delete from student where id = $1", id)
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx, "delete from student where id = $1 and tenant_id = $2", id, tenant.IdFrom(ctx))
 	if err != nil {
-		fmt.Errorf("%s: query executement in deletion", err)
-		return err
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
 	}
-	return err
+
+	return nil
 }
 
-func (repo *StudentRepoPostgres) GetByEmail(ctx context.Context, email string) pgx.Row {
-	database := repo.db
+func (repo *StudentRepoPostgres) GetByEmail(ctx context.Context, email string) (domain.Student, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
 
-	student := database.QueryRow(ctx,
-		"select * from student where email = $1", email)
+	student, err := scanStudent(database.QueryRow(ctx,
+		"select "+studentColumns+" from student_view where email = $1 and tenant_id = $2", email, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Student{}, convertPostgresError(err)
+	}
+
+	return student, nil
+}
+
+func (repo *StudentRepoPostgres) CountByGroupId(ctx context.Context, groupId int64) (int64, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	var count int64
+	err := database.QueryRow(ctx,
+		"select count(*) from student where group_id = $1 and tenant_id = $2", groupId, tenant.IdFrom(ctx)).Scan(&count)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return 0, convertPostgresError(err)
+	}
+
+	return count, nil
+}
+
+func (repo *StudentRepoPostgres) MoveGroup(ctx context.Context, fromGroupId, toGroupId int64) (int64, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx, "update student set group_id = $1 where group_id = $2 and tenant_id = $3",
+		nullableId(toGroupId), fromGroupId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return 0, convertPostgresError(err)
+	}
 
-	return student
+	return tag.RowsAffected(), nil
 }
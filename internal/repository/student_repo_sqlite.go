@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/dto"
+	"StudentManager/internal/tenant"
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+type StudentRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewStudentRepoSQLite(db *sql.DB) *StudentRepoSQLite {
+	return &StudentRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *StudentRepoSQLite) GetAll(ctx context.Context, query dto.StudentQuery) (dto.StudentPage, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	page, err := checkPage(query.PageRequest, studentSortColumns)
+	if err != nil {
+		return dto.StudentPage{}, err
+	}
+	after, err := decodeCursor(page, studentSortColumns)
+	if err != nil {
+		return dto.StudentPage{}, err
+	}
+
+	builder := newStudentListSQL(tenant.IdFrom(ctx), query.StudentFilter, sqliteDialect)
+
+	var total int64
+	countSQL, countArgs := builder.count("student_view")
+	if err := database.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.StudentPage{}, convertSQLiteError(err)
+	}
+
+	listSQL, listArgs := builder.page("student_view", studentColumns, page, after)
+	rows, err := database.QueryContext(ctx, listSQL, listArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return dto.StudentPage{}, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	var students []domain.Student
+	for rows.Next() {
+		student, err := scanStudent(rows)
+		if err != nil {
+			return dto.StudentPage{}, err
+		}
+		students = append(students, student)
+	}
+	if err := rows.Err(); err != nil {
+		return dto.StudentPage{}, convertSQLiteError(err)
+	}
+
+	students, nextCursor := trimPage(students, page, studentSortValue, studentId)
+
+	return dto.StudentPage{
+		Students:   students,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (repo *StudentRepoSQLite) Create(ctx context.Context, student domain.Student) (domain.Student, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	var id int64
+	err := database.QueryRowContext(ctx,
+		"insert into student(tenant_id, full_name, age, group_id, email) values(?, ?, ?, ?, ?) returning id",
+		tenant.IdFrom(ctx), student.FullName, student.Age, nullableId(student.GroupId), student.Email).Scan(&id)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return domain.Student{}, convertSQLiteWriteError(err)
+	}
+
+	return repo.GetById(ctx, id)
+}
+
+func (repo *StudentRepoSQLite) GetById(ctx context.Context, id int64) (domain.Student, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	student, err := scanStudent(database.QueryRowContext(ctx,
+		"select "+studentColumns+" from student_view where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Student{}, convertSQLiteError(err)
+	}
+
+	return student, nil
+}
+
+func (repo *StudentRepoSQLite) Update(ctx context.Context, student domain.Student) (domain.Student, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	var id int64
+	err := database.QueryRowContext(ctx,
+		"update student set full_name = ?, age = ?, group_id = ?, email = ? where id = ? and tenant_id = ? returning id",
+		student.FullName, student.Age, nullableId(student.GroupId), student.Email, student.Id, tenant.IdFrom(ctx)).Scan(&id)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or user doesn't exists", "err", err)
+		return domain.Student{}, convertSQLiteWriteError(err)
+	}
+
+	return repo.GetById(ctx, id)
+}
+
+func (repo *StudentRepoSQLite) Patch(ctx context.Context, id int64, changes dto.StudentChanges) (domain.Student, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	set, args := studentSetClause(changes, sqlitePlaceholder)
+	args = append(args, id, tenant.IdFrom(ctx))
+
+	err := database.QueryRowContext(ctx,
+		"update student set "+set+" where id = ? and tenant_id = ? returning id", args...).Scan(&id)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or user doesn't exists", "err", err)
+		return domain.Student{}, convertSQLiteWriteError(err)
+	}
+
+	return repo.GetById(ctx, id)
+}
+
+func (repo *StudentRepoSQLite) DeleteById(ctx context.Context, id int64) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx, "delete from student where id = ? and tenant_id = ?", id, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (repo *StudentRepoSQLite) GetByEmail(ctx context.Context, email string) (domain.Student, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	student, err := scanStudent(database.QueryRowContext(ctx,
+		"select "+studentColumns+" from student_view where email = ? and tenant_id = ?", email, tenant.IdFrom(ctx)))
+	if err != nil {
+		return domain.Student{}, convertSQLiteError(err)
+	}
+
+	return student, nil
+}
+
+func (repo *StudentRepoSQLite) CountByGroupId(ctx context.Context, groupId int64) (int64, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	var count int64
+	err := database.QueryRowContext(ctx,
+		"select count(*) from student where group_id = ? and tenant_id = ?", groupId, tenant.IdFrom(ctx)).Scan(&count)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return 0, convertSQLiteError(err)
+	}
+
+	return count, nil
+}
+
+func (repo *StudentRepoSQLite) MoveGroup(ctx context.Context, fromGroupId, toGroupId int64) (int64, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx, "update student set group_id = ? where group_id = ? and tenant_id = ?",
+		nullableId(toGroupId), fromGroupId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return 0, convertSQLiteError(err)
+	}
+
+	return result.RowsAffected()
+}
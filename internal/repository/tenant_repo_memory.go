@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"maps"
+	"sort"
+	"time"
+)
+
+type TenantRepoMemory struct {
+	lock *memoryLock
+	// the repositories below share the lock and are read directly to find
+	// out whether a tenant is still in use
+	students *StudentRepoMemory
+	groups   *GroupRepoMemory
+	access   *AccessRepoMemory
+	courses  *CourseRepoMemory
+	tenants  map[string]domain.Tenant
+}
+
+// newTenantRepoMemory starts with the default tenant, like the migrations do
+func newTenantRepoMemory(lock *memoryLock, students *StudentRepoMemory, groups *GroupRepoMemory,
+	access *AccessRepoMemory, courses *CourseRepoMemory) *TenantRepoMemory {
+	return &TenantRepoMemory{
+		lock:     lock,
+		students: students,
+		groups:   groups,
+		access:   access,
+		courses:  courses,
+		tenants: map[string]domain.Tenant{
+			tenant.DefaultId: {Id: tenant.DefaultId, Name: "Default", CreatedAt: time.Now().UTC()},
+		},
+	}
+}
+
+func (repo *TenantRepoMemory) Create(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer release()
+
+	if _, ok := repo.tenants[tenant.Id]; ok {
+		return domain.Tenant{}, ErrConflict
+	}
+	repo.tenants[tenant.Id] = tenant
+
+	return tenant, nil
+}
+
+func (repo *TenantRepoMemory) GetById(ctx context.Context, id string) (domain.Tenant, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer release()
+
+	tenant, ok := repo.tenants[id]
+	if !ok {
+		return domain.Tenant{}, ErrNotFound
+	}
+
+	return tenant, nil
+}
+
+func (repo *TenantRepoMemory) GetAll(ctx context.Context) ([]domain.Tenant, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tenants := make([]domain.Tenant, 0, len(repo.tenants))
+	for _, tenant := range repo.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].Id < tenants[j].Id
+	})
+
+	return tenants, nil
+}
+
+func (repo *TenantRepoMemory) Update(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer release()
+
+	current, ok := repo.tenants[tenant.Id]
+	if !ok {
+		return domain.Tenant{}, ErrNotFound
+	}
+	current.Name = tenant.Name
+	repo.tenants[tenant.Id] = current
+
+	return current, nil
+}
+
+func (repo *TenantRepoMemory) DeleteById(ctx context.Context, id string) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if repo.inUse(id) {
+		return ErrConflict
+	}
+	if _, ok := repo.tenants[id]; !ok {
+		return ErrNotFound
+	}
+	delete(repo.tenants, id)
+
+	return nil
+}
+
+func (repo *TenantRepoMemory) snapshot() func() {
+	tenants := maps.Clone(repo.tenants)
+
+	return func() {
+		repo.tenants = tenants
+	}
+}
+
+func (repo *TenantRepoMemory) inUse(id string) bool {
+	for _, tenantId := range repo.groups.tenants {
+		if tenantId == id {
+			return true
+		}
+	}
+	for _, tenantId := range repo.students.tenants {
+		if tenantId == id {
+			return true
+		}
+	}
+	for _, user := range repo.access.users {
+		if user.TenantId == id {
+			return true
+		}
+	}
+	for _, tenantId := range repo.courses.tenants {
+		if tenantId == id {
+			return true
+		}
+	}
+
+	return false
+}
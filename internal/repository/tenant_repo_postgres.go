@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+)
+
+const tenantColumns = "id, name, created_at"
+
+// tenantNotEmptySQL selects whether anything still belongs to a tenant, the
+// placeholder of the tenant id is used four times and has to be a numbered one
+const tenantNotEmptySQL = `select exists(select 1 from "group" where tenant_id = %[1]s)
+	or exists(select 1 from student where tenant_id = %[1]s)
+	or exists(select 1 from app_user where tenant_id = %[1]s)
+	or exists(select 1 from course where tenant_id = %[1]s)`
+
+type TenantRepoPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewTenantRepoPostgres(db *pgxpool.Pool) *TenantRepoPostgres {
+	return &TenantRepoPostgres{
+		db: db,
+	}
+}
+
+func (repo *TenantRepoPostgres) Create(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	created, err := scanTenant(database.QueryRow(ctx,
+		"insert into tenant(id, name, created_at) values($1, $2, $3) returning "+tenantColumns,
+		tenant.Id, tenant.Name, tenant.CreatedAt))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in tenant creation", "err", err)
+		return domain.Tenant{}, convertPostgresError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *TenantRepoPostgres) GetById(ctx context.Context, id string) (domain.Tenant, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tenant, err := scanTenant(database.QueryRow(ctx,
+		"select "+tenantColumns+" from tenant where id = $1", id))
+	if err != nil {
+		return domain.Tenant{}, convertPostgresError(err)
+	}
+
+	return tenant, nil
+}
+
+func (repo *TenantRepoPostgres) GetAll(ctx context.Context) ([]domain.Tenant, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	rows, err := database.Query(ctx, "select "+tenantColumns+" from tenant order by id")
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return nil, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	tenants := []domain.Tenant{}
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, convertPostgresError(rows.Err())
+}
+
+func (repo *TenantRepoPostgres) Update(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	updated, err := scanTenant(database.QueryRow(ctx,
+		"update tenant set name = $1 where id = $2 returning "+tenantColumns,
+		tenant.Name, tenant.Id))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or tenant doesn't exists", "err", err)
+		return domain.Tenant{}, convertPostgresError(err)
+	}
+
+	return updated, nil
+}
+
+func (repo *TenantRepoPostgres) DeleteById(ctx context.Context, id string) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	var notEmpty bool
+	if err := database.QueryRow(ctx, fmt.Sprintf(tenantNotEmptySQL, "$1"), id).Scan(&notEmpty); err != nil {
+		return convertPostgresError(err)
+	}
+	if notEmpty {
+		return ErrConflict
+	}
+
+	tag, err := database.Exec(ctx, "delete from tenant where id = $1", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in tenant deletion", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
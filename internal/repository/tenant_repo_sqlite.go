@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+type TenantRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewTenantRepoSQLite(db *sql.DB) *TenantRepoSQLite {
+	return &TenantRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *TenantRepoSQLite) Create(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	created, err := scanTenant(database.QueryRowContext(ctx,
+		"insert into tenant(id, name, created_at) values(?, ?, ?) returning "+tenantColumns,
+		tenant.Id, tenant.Name, tenant.CreatedAt.UTC()))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in tenant creation", "err", err)
+		return domain.Tenant{}, convertSQLiteError(err)
+	}
+
+	return created, nil
+}
+
+func (repo *TenantRepoSQLite) GetById(ctx context.Context, id string) (domain.Tenant, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	tenant, err := scanTenant(database.QueryRowContext(ctx,
+		"select "+tenantColumns+" from tenant where id = ?", id))
+	if err != nil {
+		return domain.Tenant{}, convertSQLiteError(err)
+	}
+
+	return tenant, nil
+}
+
+func (repo *TenantRepoSQLite) GetAll(ctx context.Context) ([]domain.Tenant, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	rows, err := database.QueryContext(ctx, "select "+tenantColumns+" from tenant order by id")
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return nil, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	tenants := []domain.Tenant{}
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, convertSQLiteError(rows.Err())
+}
+
+func (repo *TenantRepoSQLite) Update(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	updated, err := scanTenant(database.QueryRowContext(ctx,
+		"update tenant set name = ? where id = ? returning "+tenantColumns,
+		tenant.Name, tenant.Id))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement or tenant doesn't exists", "err", err)
+		return domain.Tenant{}, convertSQLiteError(err)
+	}
+
+	return updated, nil
+}
+
+func (repo *TenantRepoSQLite) DeleteById(ctx context.Context, id string) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	var notEmpty bool
+	err := database.QueryRowContext(ctx, fmt.Sprintf(tenantNotEmptySQL, "?1"), id).Scan(&notEmpty)
+	if err != nil {
+		return convertSQLiteError(err)
+	}
+	if notEmpty {
+		return ErrConflict
+	}
+
+	result, err := database.ExecContext(ctx, "delete from tenant where id = ?", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in tenant deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type IsolationLevel int
+
+const (
+	ReadCommitted IsolationLevel = iota
+	Serializable
+)
+
+type TxOptions struct {
+	Isolation IsolationLevel
+	// how many times the transaction is re-run after a serialization conflict
+	MaxRetries int
+}
+
+// SerializableTx is what services use for check-then-write operations
+var SerializableTx = TxOptions{
+	Isolation:  Serializable,
+	MaxRetries: 3,
+}
+
+// ErrTxConflict is returned when a transaction still conflicts with
+// concurrent ones after all retries are exhausted.
+var ErrTxConflict = errors.New("transaction conflicts with a concurrent one")
+
+// TxManager runs fn atomically. Repositories called with the context passed
+// to fn take part in the transaction; nested calls join the outer transaction.
+// fn may be called several times, so it must not have side effects other than
+// repository calls.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error
+}
+
+// retry re-runs attempt while it fails with an error the backend reports as a
+// serialization conflict
+func retry(ctx context.Context, opts TxOptions, isConflict func(error) bool, attempt func() error) error {
+	for i := 0; ; i++ {
+		err := attempt()
+		if err == nil || !isConflict(err) {
+			return err
+		}
+		if i >= opts.MaxRetries {
+			slog.ErrorContext(ctx, "transaction conflict, giving up after retries", "retries", i, "err", err)
+			return ErrTxConflict
+		}
+
+		slog.WarnContext(ctx, "transaction conflict, retrying", "err", err)
+
+		backoff := time.Duration(i+1) * 10 * time.Millisecond
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+type memoryTxKey struct{}
+
+// memoryLock is shared by all memory repositories of one Repositories set.
+// Every operation holds it, and a transaction holds it from begin to end,
+// which makes memory transactions serializable. Waiting for it stops when
+// the context is done, like waiting for a database would.
+type memoryLock struct {
+	once sync.Once
+	sem  chan struct{}
+}
+
+// acquire locks the store unless ctx belongs to a transaction that already holds the lock
+func (l *memoryLock) acquire(ctx context.Context) (release func(), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if owner, ok := ctx.Value(memoryTxKey{}).(*memoryLock); ok && owner == l {
+		return func() {}, nil
+	}
+
+	l.once.Do(func() {
+		l.sem = make(chan struct{}, 1)
+	})
+
+	select {
+	case l.sem <- struct{}{}:
+		return func() { <-l.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// memorySnapshotter is implemented by memory repositories, restore puts the
+// repository back into the state it had when snapshot was called
+type memorySnapshotter interface {
+	snapshot() (restore func())
+}
+
+type TxManagerMemory struct {
+	lock  *memoryLock
+	repos []memorySnapshotter
+}
+
+func (m *TxManagerMemory) WithinTransaction(ctx context.Context, _ TxOptions,
+	fn func(ctx context.Context) error) error {
+	if owner, ok := ctx.Value(memoryTxKey{}).(*memoryLock); ok && owner == m.lock {
+		return fn(ctx)
+	}
+
+	release, err := m.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	restores := make([]func(), 0, len(m.repos))
+	for _, repo := range m.repos {
+		restores = append(restores, repo.snapshot())
+	}
+
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, m.lock)); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+
+	return nil
+}
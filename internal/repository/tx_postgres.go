@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"StudentManager/internal/tracing"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+type pgTxKey struct{}
+
+// pgQuerier is the part of the API shared by *pgxpool.Pool and pgx.Tx
+type pgQuerier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// postgresQuerierFrom returns the transaction stored in ctx, or the pool when
+// the call is not part of a transaction
+func postgresQuerierFrom(ctx context.Context, db *pgxpool.Pool) pgQuerier {
+	if tx, ok := ctx.Value(pgTxKey{}).(pgx.Tx); ok {
+		return tracedPgQuerier{tx}
+	}
+
+	return tracedPgQuerier{db}
+}
+
+// tracedPgQuerier records a span for every statement. Rows are read after
+// the span ended, it covers sending the statement and the first response.
+type tracedPgQuerier struct {
+	querier pgQuerier
+}
+
+func (q tracedPgQuerier) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := tracing.StartStatement(ctx, tracing.DBPostgres, sql)
+	tag, err := q.querier.Exec(ctx, sql, arguments...)
+	tracing.End(span, err)
+
+	return tag, err
+}
+
+func (q tracedPgQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := tracing.StartStatement(ctx, tracing.DBPostgres, sql)
+	rows, err := q.querier.Query(ctx, sql, args...)
+	tracing.End(span, err)
+
+	return rows, err
+}
+
+func (q tracedPgQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := tracing.StartStatement(ctx, tracing.DBPostgres, sql)
+	row := q.querier.QueryRow(ctx, sql, args...)
+	span.End()
+
+	return row
+}
+
+type TxManagerPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewTxManagerPostgres(db *pgxpool.Pool) *TxManagerPostgres {
+	return &TxManagerPostgres{
+		db: db,
+	}
+}
+
+func (m *TxManagerPostgres) WithinTransaction(ctx context.Context, opts TxOptions,
+	fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(pgTxKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	isoLevel := pgx.ReadCommitted
+	if opts.Isolation == Serializable {
+		isoLevel = pgx.Serializable
+	}
+
+	return retry(ctx, opts, isPostgresTxConflict, func() error {
+		tx, err := m.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: isoLevel})
+		if err != nil {
+			return err
+		}
+
+		if err := fn(context.WithValue(ctx, pgTxKey{}, tx)); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+func isPostgresTxConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
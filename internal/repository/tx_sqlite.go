@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"StudentManager/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type sqliteTxKey struct{}
+
+// sqliteQuerier is the part of the API shared by *sql.DB and *sql.Tx
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func sqliteQuerierFrom(ctx context.Context, db *sql.DB) sqliteQuerier {
+	if tx, ok := ctx.Value(sqliteTxKey{}).(*sql.Tx); ok {
+		return tracedSQLiteQuerier{tx}
+	}
+
+	return tracedSQLiteQuerier{db}
+}
+
+// tracedSQLiteQuerier records a span for every statement. Rows are read
+// after the span ended, it covers running the statement up to the first row.
+type tracedSQLiteQuerier struct {
+	querier sqliteQuerier
+}
+
+func (q tracedSQLiteQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := tracing.StartStatement(ctx, tracing.DBSQLite, query)
+	result, err := q.querier.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (q tracedSQLiteQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := tracing.StartStatement(ctx, tracing.DBSQLite, query)
+	rows, err := q.querier.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return rows, err
+}
+
+func (q tracedSQLiteQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := tracing.StartStatement(ctx, tracing.DBSQLite, query)
+	row := q.querier.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+
+	return row
+}
+
+// TxManagerSQLite relies on SQLite transactions being serializable by design,
+// TxOptions.Isolation does not change anything here.
+type TxManagerSQLite struct {
+	db *sql.DB
+}
+
+func NewTxManagerSQLite(db *sql.DB) *TxManagerSQLite {
+	return &TxManagerSQLite{
+		db: db,
+	}
+}
+
+func (m *TxManagerSQLite) WithinTransaction(ctx context.Context, opts TxOptions,
+	fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(sqliteTxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	return retry(ctx, opts, isSQLiteTxConflict, func() error {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(context.WithValue(ctx, sqliteTxKey{}, tx)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+func isSQLiteTxConflict(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	// extended result codes keep the primary code in the lowest byte
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
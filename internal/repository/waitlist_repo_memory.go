@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+)
+
+type WaitlistRepoMemory struct {
+	lock *memoryLock
+	// group id -> entries in the order they joined
+	entries map[int64][]domain.WaitlistEntry
+	// group id -> tenant id of its entries
+	tenants map[int64]string
+	// checked for references, they share the lock
+	students *StudentRepoMemory
+	groups   *GroupRepoMemory
+}
+
+func newWaitlistRepoMemory(lock *memoryLock, students *StudentRepoMemory,
+	groups *GroupRepoMemory) *WaitlistRepoMemory {
+	return &WaitlistRepoMemory{
+		lock:     lock,
+		entries:  make(map[int64][]domain.WaitlistEntry),
+		tenants:  make(map[int64]string),
+		students: students,
+		groups:   groups,
+	}
+}
+
+func (repo *WaitlistRepoMemory) Put(ctx context.Context, entry domain.WaitlistEntry) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tenantId := tenant.IdFrom(ctx)
+	// the foreign keys of the entry
+	if _, ok := repo.groups.groups[entry.GroupId]; !ok || repo.groups.tenants[entry.GroupId] != tenantId {
+		return ErrConflict
+	}
+	if _, ok := repo.students.students[entry.StudentId]; !ok || repo.students.tenants[entry.StudentId] != tenantId {
+		return ErrConflict
+	}
+
+	entries := repo.queue(entry.GroupId)
+	for i := range entries {
+		if entries[i].StudentId == entry.StudentId {
+			entries[i].Priority = entry.Priority
+			return nil
+		}
+	}
+
+	entry.Position = 0
+	repo.entries[entry.GroupId] = append(entries, entry)
+	repo.tenants[entry.GroupId] = tenantId
+
+	return nil
+}
+
+func (repo *WaitlistRepoMemory) GetByGroupId(ctx context.Context, groupId int64,
+	mode string) ([]domain.WaitlistEntry, error) {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if repo.tenants[groupId] != tenant.IdFrom(ctx) {
+		return []domain.WaitlistEntry{}, nil
+	}
+
+	entries := slices.Clone(repo.queue(groupId))
+	if mode == domain.WaitlistPriority {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Priority > entries[j].Priority
+		})
+	}
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+
+	return entries, nil
+}
+
+func (repo *WaitlistRepoMemory) Remove(ctx context.Context, groupId, studentId int64) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if repo.tenants[groupId] != tenant.IdFrom(ctx) {
+		return ErrNotFound
+	}
+	entries := repo.queue(groupId)
+	i := slices.IndexFunc(entries, func(entry domain.WaitlistEntry) bool {
+		return entry.StudentId == studentId
+	})
+	if i < 0 {
+		return ErrNotFound
+	}
+	repo.entries[groupId] = slices.Delete(entries, i, i+1)
+
+	return nil
+}
+
+func (repo *WaitlistRepoMemory) RemoveByGroupId(ctx context.Context, groupId int64) error {
+	release, err := repo.lock.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if repo.tenants[groupId] == tenant.IdFrom(ctx) {
+		delete(repo.entries, groupId)
+		delete(repo.tenants, groupId)
+	}
+
+	return nil
+}
+
+// queue returns the entries of the group whose group and student still exist,
+// the way the database cascade drops the others. The caller holds the lock.
+func (repo *WaitlistRepoMemory) queue(groupId int64) []domain.WaitlistEntry {
+	if _, ok := repo.groups.groups[groupId]; !ok {
+		delete(repo.entries, groupId)
+		delete(repo.tenants, groupId)
+		return nil
+	}
+
+	entries := slices.DeleteFunc(repo.entries[groupId], func(entry domain.WaitlistEntry) bool {
+		_, ok := repo.students.students[entry.StudentId]
+		return !ok
+	})
+	repo.entries[groupId] = entries
+
+	return entries
+}
+
+func (repo *WaitlistRepoMemory) snapshot() func() {
+	entries := make(map[int64][]domain.WaitlistEntry, len(repo.entries))
+	for groupId, queue := range repo.entries {
+		entries[groupId] = slices.Clone(queue)
+	}
+	tenants := maps.Clone(repo.tenants)
+
+	return func() {
+		repo.entries = entries
+		repo.tenants = tenants
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+)
+
+const waitlistColumns = "group_id, student_id, priority, queued_at"
+
+// waitlistOrder is the order students leave a queue of the mode in, ids keep
+// the order they joined in
+func waitlistOrder(mode string) string {
+	if mode == domain.WaitlistPriority {
+		return "priority desc, id"
+	}
+
+	return "id"
+}
+
+type WaitlistRepoPostgres struct {
+	db *pgxpool.Pool
+}
+
+func NewWaitlistRepoPostgres(db *pgxpool.Pool) *WaitlistRepoPostgres {
+	return &WaitlistRepoPostgres{
+		db: db,
+	}
+}
+
+func (repo *WaitlistRepoPostgres) Put(ctx context.Context, entry domain.WaitlistEntry) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	_, err := database.Exec(ctx,
+		"insert into waitlist_entry(tenant_id, group_id, student_id, priority, queued_at) values($1, $2, $3, $4, $5) "+
+			"on conflict (group_id, student_id) do update set priority = excluded.priority",
+		tenant.IdFrom(ctx), entry.GroupId, entry.StudentId, entry.Priority, entry.QueuedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return convertPostgresError(err)
+	}
+
+	return nil
+}
+
+func (repo *WaitlistRepoPostgres) GetByGroupId(ctx context.Context, groupId int64,
+	mode string) ([]domain.WaitlistEntry, error) {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	rows, err := database.Query(ctx,
+		"select "+waitlistColumns+" from waitlist_entry where group_id = $1 and tenant_id = $2 order by "+
+			waitlistOrder(mode),
+		groupId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return nil, convertPostgresError(err)
+	}
+	defer rows.Close()
+
+	entries := []domain.WaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entry.Position = len(entries) + 1
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, convertPostgresError(err)
+	}
+
+	return entries, nil
+}
+
+func (repo *WaitlistRepoPostgres) Remove(ctx context.Context, groupId, studentId int64) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	tag, err := database.Exec(ctx,
+		"delete from waitlist_entry where group_id = $1 and student_id = $2 and tenant_id = $3",
+		groupId, studentId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertPostgresError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *WaitlistRepoPostgres) RemoveByGroupId(ctx context.Context, groupId int64) error {
+	database := postgresQuerierFrom(ctx, repo.db)
+
+	_, err := database.Exec(ctx, "delete from waitlist_entry where group_id = $1 and tenant_id = $2",
+		groupId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertPostgresError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"StudentManager/internal/domain"
+	"StudentManager/internal/tenant"
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+type WaitlistRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewWaitlistRepoSQLite(db *sql.DB) *WaitlistRepoSQLite {
+	return &WaitlistRepoSQLite{
+		db: db,
+	}
+}
+
+func (repo *WaitlistRepoSQLite) Put(ctx context.Context, entry domain.WaitlistEntry) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	_, err := database.ExecContext(ctx,
+		"insert into waitlist_entry(tenant_id, group_id, student_id, priority, queued_at) values(?, ?, ?, ?, ?) "+
+			"on conflict (group_id, student_id) do update set priority = excluded.priority",
+		tenant.IdFrom(ctx), entry.GroupId, entry.StudentId, entry.Priority, entry.QueuedAt.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return nil
+}
+
+func (repo *WaitlistRepoSQLite) GetByGroupId(ctx context.Context, groupId int64,
+	mode string) ([]domain.WaitlistEntry, error) {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	rows, err := database.QueryContext(ctx,
+		"select "+waitlistColumns+" from waitlist_entry where group_id = ? and tenant_id = ? order by "+
+			waitlistOrder(mode),
+		groupId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement", "err", err)
+		return nil, convertSQLiteError(err)
+	}
+	defer rows.Close()
+
+	entries := []domain.WaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entry.Position = len(entries) + 1
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, convertSQLiteError(err)
+	}
+
+	return entries, nil
+}
+
+func (repo *WaitlistRepoSQLite) Remove(ctx context.Context, groupId, studentId int64) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	result, err := database.ExecContext(ctx,
+		"delete from waitlist_entry where group_id = ? and student_id = ? and tenant_id = ?",
+		groupId, studentId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return checkRowsAffected(result)
+}
+
+func (repo *WaitlistRepoSQLite) RemoveByGroupId(ctx context.Context, groupId int64) error {
+	database := sqliteQuerierFrom(ctx, repo.db)
+
+	_, err := database.ExecContext(ctx, "delete from waitlist_entry where group_id = ? and tenant_id = ?",
+		groupId, tenant.IdFrom(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "query executement in deletion", "err", err)
+		return convertSQLiteError(err)
+	}
+
+	return nil
+}
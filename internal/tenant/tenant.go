@@ -0,0 +1,33 @@
+// Package tenant carries the tenant, the institution a request works on,
+// through a context down to the repositories.
+package tenant
+
+import (
+	"context"
+)
+
+// DefaultId is the tenant created by the migrations. Work done without a
+// tenant in the context, like single-tenant deployments, belongs to it.
+const DefaultId = "default"
+
+type tenantKey struct{}
+
+func WithId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant put into ctx, if there is one
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+
+	return id, ok
+}
+
+// IdFrom returns the tenant of ctx, DefaultId when there is none
+func IdFrom(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+
+	return DefaultId
+}
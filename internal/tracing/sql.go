@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+var (
+	DBPostgres = semconv.DBSystemPostgreSQL
+	DBSQLite   = semconv.DBSystemSqlite
+)
+
+// StartStatement begins a span for a SQL statement named after what it does
+// and to which table, like "INSERT student". Neither the statement nor its
+// arguments are recorded, they may hold personal data.
+func StartStatement(ctx context.Context, system attribute.KeyValue, query string) (context.Context, trace.Span) {
+	operation, table := statementName(query)
+	name := operation
+	attrs := []attribute.KeyValue{system, semconv.DBOperationName(operation)}
+	if table != "" {
+		name += " " + table
+		attrs = append(attrs, semconv.DBCollectionName(table))
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// statementName reads the operation and the main table of a statement
+func statementName(query string) (operation, table string) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return "", ""
+	}
+	operation = strings.ToUpper(words[0])
+
+	// the table follows the first of these words
+	var before string
+	switch operation {
+	case "SELECT", "DELETE":
+		before = "from"
+	case "INSERT":
+		before = "into"
+	case "UPDATE":
+		return operation, tableName(words, 1)
+	default:
+		return operation, ""
+	}
+	// subqueries in the select list come before the table, skip them
+	depth := 0
+	for i, word := range words {
+		if depth == 0 && strings.EqualFold(word, before) {
+			return operation, tableName(words, i+1)
+		}
+		depth += strings.Count(word, "(") - strings.Count(word, ")")
+	}
+
+	return operation, ""
+}
+
+func tableName(words []string, i int) string {
+	if i >= len(words) {
+		return ""
+	}
+	table, _, _ := strings.Cut(words[i], "(")
+
+	return strings.Trim(table, `"`)
+}
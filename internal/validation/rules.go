@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"StudentManager/internal/config"
+	"StudentManager/internal/domain"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// a group bigger than that is rather a typo
+const maxGroupCapacity = 10000
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._@-]{3,64}$`)
+
+// tenant ids have to fit into a subdomain label
+var tenantIdPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// course codes like "CS101" or "math-2.1" show up in urls and schedules
+var courseCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,31}$`)
+
+// New returns a validator with the domain rules configured for the institution:
+// full_name, age and group_number, plus the fixed username, password, tenant,
+// group capacity, course and enrollment rules
+func New(cfg config.Validation) (*Validator, error) {
+	groupNumber, err := regexp.Compile(cfg.GroupNumberPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group number pattern %q: %w", cfg.GroupNumberPattern, err)
+	}
+	if cfg.AgeMin > cfg.AgeMax {
+		return nil, fmt.Errorf("age_min %d is greater than age_max %d", cfg.AgeMin, cfg.AgeMax)
+	}
+	if cfg.NameMinLength > cfg.NameMaxLength {
+		return nil, fmt.Errorf("name_min_length %d is greater than name_max_length %d",
+			cfg.NameMinLength, cfg.NameMaxLength)
+	}
+
+	v := NewValidator()
+	v.Register("full_name", fullName(cfg.NameMinLength, cfg.NameMaxLength))
+	v.Register("age", intRange(cfg.AgeMin, cfg.AgeMax))
+	v.Register("group_number", pattern(groupNumber, "must match "+cfg.GroupNumberPattern))
+	v.Register("username", pattern(usernamePattern,
+		"must be 3 to 64 letters, digits, dots, underscores, hyphens or @"))
+	// bcrypt only looks at the first 72 bytes
+	v.Register("password", byteLength(8, 72))
+	v.Register("tenant_id", pattern(tenantIdPattern,
+		"must be up to 63 lowercase letters, digits or inner hyphens"))
+	v.Register("tenant_name", byteLength(1, 200))
+	v.Register("capacity", intRange(0, maxGroupCapacity))
+	v.Register("waitlist", oneOf(domain.WaitlistFIFO, domain.WaitlistPriority))
+	v.Register("waitlist_priority", intRange(0, 1000))
+	v.Register("course_code", pattern(courseCodePattern,
+		"must be up to 32 letters, digits, dots, underscores or hyphens, starting with a letter or digit"))
+	v.Register("course_title", byteLength(1, 200))
+	v.Register("enrollment_status", oneOf(domain.EnrollmentActive, domain.EnrollmentDropped,
+		domain.EnrollmentCompleted))
+
+	return v, nil
+}
+
+func email(value reflect.Value) string {
+	address, err := mail.ParseAddress(value.String())
+	// ParseAddress also accepts "Name <address>", we only want the address
+	if err != nil || address.Address != value.String() {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+// fullName allows letters in any script separated by spaces, hyphens, apostrophes and dots
+func fullName(minLength, maxLength int) Rule {
+	return func(value reflect.Value) string {
+		name := value.String()
+
+		if length := utf8.RuneCountInString(name); length < minLength || length > maxLength {
+			return fmt.Sprintf("must be between %d and %d characters long", minLength, maxLength)
+		}
+		if strings.TrimSpace(name) != name {
+			return "must not start or end with a space"
+		}
+		for _, r := range name {
+			if !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && !strings.ContainsRune(" -'.", r) {
+				return "may only contain letters, spaces, hyphens, apostrophes and dots"
+			}
+		}
+
+		return ""
+	}
+}
+
+func byteLength(min, max int) Rule {
+	return func(value reflect.Value) string {
+		if n := len(value.String()); n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d bytes long", min, max)
+		}
+
+		return ""
+	}
+}
+
+func intRange(min, max int) Rule {
+	return func(value reflect.Value) string {
+		if n := value.Int(); n < int64(min) || n > int64(max) {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+
+		return ""
+	}
+}
+
+func oneOf(values ...string) Rule {
+	return func(value reflect.Value) string {
+		if !slices.Contains(values, value.String()) {
+			return "must be one of " + strings.Join(values, ", ")
+		}
+
+		return ""
+	}
+}
+
+func pattern(re *regexp.Regexp, message string) Rule {
+	return func(value reflect.Value) string {
+		if !re.MatchString(value.String()) {
+			return message
+		}
+
+		return ""
+	}
+}
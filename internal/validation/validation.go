@@ -0,0 +1,145 @@
+// Package validation checks request payloads against rules declared in
+// `validate` struct tags, e.g. `validate:"required,email"`. Field paths in
+// the reported errors use the json names, so clients can map them back to
+// what they sent.
+package validation
+
+import (
+	"StudentManager/internal/apperror"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Rule checks a single non-zero value and returns a message describing what
+// is wrong with it, or "" when the value is fine
+type Rule func(value reflect.Value) string
+
+type Validator struct {
+	rules map[string]Rule
+}
+
+func NewValidator() *Validator {
+	return &Validator{
+		rules: map[string]Rule{
+			"email": email,
+		},
+	}
+}
+
+// Register adds a named rule that can be referenced from `validate` tags
+func (v *Validator) Register(name string, rule Rule) {
+	v.rules[name] = rule
+}
+
+// Struct validates s, which must be a struct or a pointer to one, and returns
+// an apperror validation error listing every violation, or nil
+func (v *Validator) Struct(s any) error {
+	var violations []apperror.FieldError
+	v.walk(reflect.ValueOf(s), "", &violations)
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return apperror.Validation("invalid_request", "request is invalid", violations...)
+}
+
+func (v *Validator) walk(value reflect.Value, path string, violations *[]apperror.FieldError) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fieldPath := joinPath(path, fieldName(field))
+			fieldValue := value.Field(i)
+
+			if tag := field.Tag.Get("validate"); tag != "" {
+				if message := v.check(fieldValue, tag); message != "" {
+					*violations = append(*violations, apperror.FieldError{Field: fieldPath, Message: message})
+					continue
+				}
+			}
+
+			v.walk(fieldValue, fieldPath, violations)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			v.walk(value.Index(i), path+"["+strconv.Itoa(i)+"]", violations)
+		}
+	}
+}
+
+// check runs the rules of one tag in order and stops at the first violation.
+// Zero values only have to pass "required", other rules skip them.
+func (v *Validator) check(value reflect.Value, tag string) string {
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+
+		if name == "required" {
+			if isZero(value) {
+				return "is required"
+			}
+			continue
+		}
+		if isZero(value) {
+			return ""
+		}
+
+		rule, ok := v.rules[name]
+		if !ok {
+			// tags are written by us, an unknown rule is a programming error
+			panic(fmt.Sprintf("validation: unknown rule %q", name))
+		}
+		if message := rule(indirect(value)); message != "" {
+			return message
+		}
+	}
+
+	return ""
+}
+
+func isZero(value reflect.Value) bool {
+	if value.Kind() == reflect.Pointer {
+		return value.IsNil()
+	}
+
+	return value.IsZero()
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+
+	return value
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
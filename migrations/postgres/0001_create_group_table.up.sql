@@ -0,0 +1,5 @@
+create table if not exists "group"
+(
+    id           bigserial primary key,
+    group_number text not null unique
+);
@@ -0,0 +1 @@
+drop index if exists student_group_number_idx;
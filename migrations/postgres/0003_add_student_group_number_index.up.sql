@@ -0,0 +1 @@
+create index if not exists student_group_number_idx on student (group_number);
@@ -0,0 +1,11 @@
+create table if not exists refresh_token
+(
+    token_hash text primary key,
+    subject    text        not null,
+    family_id  text        not null,
+    expires_at timestamptz not null,
+    revoked_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists refresh_token_family_id_idx on refresh_token (family_id);
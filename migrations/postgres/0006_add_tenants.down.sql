@@ -0,0 +1,29 @@
+-- only the default tenant fits into the old schema
+delete from student where tenant_id <> 'default';
+delete from "group" where tenant_id <> 'default';
+delete from app_user where tenant_id <> 'default';
+
+alter table refresh_token
+    drop column if exists tenant_id;
+
+alter table app_user
+    drop column if exists tenant_id;
+
+drop index if exists student_group_number_idx;
+create index if not exists student_group_number_idx on student (group_number);
+
+alter table student
+    drop constraint if exists student_tenant_id_email_key;
+alter table student
+    add constraint student_email_key unique (email);
+alter table student
+    drop column if exists tenant_id;
+
+alter table "group"
+    drop constraint if exists group_tenant_id_group_number_key;
+alter table "group"
+    add constraint group_group_number_key unique (group_number);
+alter table "group"
+    drop column if exists tenant_id;
+
+drop table if exists tenant;
@@ -0,0 +1,40 @@
+create table if not exists tenant
+(
+    id         text primary key,
+    name       text        not null,
+    created_at timestamptz not null default now()
+);
+
+insert into tenant(id, name)
+values ('default', 'Default')
+on conflict do nothing;
+
+-- existing rows belong to the default tenant
+alter table "group"
+    add column tenant_id text not null default 'default' references tenant (id);
+alter table "group"
+    alter column tenant_id drop default;
+alter table "group"
+    drop constraint group_group_number_key;
+alter table "group"
+    add constraint group_tenant_id_group_number_key unique (tenant_id, group_number);
+
+alter table student
+    add column tenant_id text not null default 'default' references tenant (id);
+alter table student
+    alter column tenant_id drop default;
+alter table student
+    drop constraint student_email_key;
+alter table student
+    add constraint student_tenant_id_email_key unique (tenant_id, email);
+
+drop index if exists student_group_number_idx;
+create index if not exists student_group_number_idx on student (tenant_id, group_number);
+
+alter table app_user
+    add column tenant_id text not null default 'default' references tenant (id);
+alter table app_user
+    alter column tenant_id drop default;
+
+alter table refresh_token
+    add column tenant_id text;
@@ -0,0 +1,19 @@
+drop view if exists student_view;
+
+alter table student
+    add column group_number text not null default '';
+alter table student
+    alter column group_number drop default;
+
+update student s
+set group_number = g.group_number
+from "group" g
+where g.id = s.group_id;
+
+drop index if exists student_group_id_idx;
+alter table student
+    drop column group_id;
+alter table "group"
+    drop constraint if exists group_tenant_id_id_key;
+
+create index if not exists student_group_number_idx on student (tenant_id, group_number);
@@ -0,0 +1,40 @@
+-- groups that students name but nobody created are created, so converting
+-- the references loses none of them
+insert into "group"(tenant_id, group_number)
+select distinct s.tenant_id, s.group_number
+from student s
+where s.group_number <> ''
+  and not exists (select 1
+                  from "group" g
+                  where g.tenant_id = s.tenant_id
+                    and g.group_number = s.group_number);
+
+-- the tenant is part of the reference, so it can't cross tenants
+alter table "group"
+    add constraint group_tenant_id_id_key unique (tenant_id, id);
+
+-- students refer to groups by id from now on, a null group_id means the
+-- student has no group. Deleting a referenced group fails, the app decides
+-- what happens to its students first.
+alter table student
+    add column group_id bigint;
+alter table student
+    add constraint student_group_id_fkey foreign key (tenant_id, group_id) references "group" (tenant_id, id);
+
+update student s
+set group_id = g.id
+from "group" g
+where g.tenant_id = s.tenant_id
+  and g.group_number = s.group_number;
+
+drop index if exists student_group_number_idx;
+alter table student
+    drop column group_number;
+
+create index if not exists student_group_id_idx on student (tenant_id, group_id);
+
+-- students are read with the number of their group, which follows renames
+create view student_view as
+select s.id, s.tenant_id, s.full_name, s.age, s.group_id, coalesce(g.group_number, '') as group_number, s.email
+from student s
+         left join "group" g on g.id = s.group_id;
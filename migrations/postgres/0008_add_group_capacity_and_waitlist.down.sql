@@ -0,0 +1,8 @@
+drop table if exists waitlist_entry;
+
+alter table student
+    drop constraint if exists student_tenant_id_id_key;
+
+alter table "group"
+    drop column if exists waitlist,
+    drop column if exists capacity;
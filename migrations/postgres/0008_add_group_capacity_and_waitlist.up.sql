@@ -0,0 +1,25 @@
+-- a capacity of 0 leaves the group unlimited, an empty waitlist mode turns
+-- the waitlist of the group off
+alter table "group"
+    add column capacity integer not null default 0 check (capacity >= 0),
+    add column waitlist text    not null default '' check (waitlist in ('', 'fifo', 'priority'));
+
+alter table student
+    add constraint student_tenant_id_id_key unique (tenant_id, id);
+
+-- students waiting for a seat, ids give the order they joined in. Entries go
+-- away with their group or student.
+create table if not exists waitlist_entry
+(
+    id         bigserial primary key,
+    tenant_id  text        not null,
+    group_id   bigint      not null,
+    student_id bigint      not null,
+    priority   integer     not null default 0,
+    queued_at  timestamptz not null default now(),
+    unique (group_id, student_id),
+    foreign key (tenant_id, group_id) references "group" (tenant_id, id) on delete cascade,
+    foreign key (tenant_id, student_id) references student (tenant_id, id) on delete cascade
+);
+
+create index if not exists waitlist_entry_student_id_idx on waitlist_entry (tenant_id, student_id);
@@ -0,0 +1,58 @@
+create table if not exists course
+(
+    id        bigserial primary key,
+    tenant_id text not null references tenant (id),
+    code      text not null,
+    title     text not null,
+    unique (tenant_id, code),
+    unique (tenant_id, id)
+);
+
+-- a course is taken by a whole group or by a single student as an elective.
+-- Enrollments go away with their group or student, but keep their course
+-- from being deleted.
+create table if not exists enrollment
+(
+    id          bigserial primary key,
+    tenant_id   text        not null,
+    course_id   bigint      not null,
+    group_id    bigint,
+    student_id  bigint,
+    status      text        not null default 'active' check (status in ('active', 'dropped', 'completed')),
+    enrolled_at timestamptz not null default now(),
+    updated_at  timestamptz not null default now(),
+    check ((group_id is null) <> (student_id is null)),
+    unique (course_id, group_id),
+    unique (course_id, student_id),
+    foreign key (tenant_id, course_id) references course (tenant_id, id),
+    foreign key (tenant_id, group_id) references "group" (tenant_id, id) on delete cascade,
+    foreign key (tenant_id, student_id) references student (tenant_id, id) on delete cascade
+);
+
+create index if not exists enrollment_group_id_idx on enrollment (tenant_id, group_id);
+create index if not exists enrollment_student_id_idx on enrollment (tenant_id, student_id);
+
+-- group_number is the number of the enrolled group, or of the group of the
+-- enrolled student, lists are limited by it
+create view enrollment_view as
+select e.id,
+       e.tenant_id,
+       e.course_id,
+       e.group_id,
+       e.student_id,
+       e.status,
+       e.enrolled_at,
+       e.updated_at,
+       coalesce(g.group_number, s.group_number, '') as group_number
+from enrollment e
+         left join "group" g on g.id = e.group_id
+         left join student_view s on s.id = e.student_id;
+
+insert into role_permission(role, permission, scope)
+values ('admin', 'courses:read', 'all'),
+       ('admin', 'courses:manage', 'all'),
+       ('admin', 'enrollments:manage', 'all'),
+       ('teacher', 'courses:read', 'all'),
+       ('teacher', 'enrollments:manage', 'curated'),
+       ('student', 'courses:read', 'all')
+on conflict do nothing;
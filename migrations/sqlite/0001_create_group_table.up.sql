@@ -0,0 +1,5 @@
+create table if not exists "group"
+(
+    id           integer primary key autoincrement,
+    group_number text not null unique
+);
@@ -0,0 +1,8 @@
+create table if not exists student
+(
+    id           integer primary key autoincrement,
+    full_name    text    not null,
+    age          integer not null,
+    group_number text    not null,
+    email        text    not null unique
+);
@@ -0,0 +1,11 @@
+create table if not exists refresh_token
+(
+    token_hash text primary key,
+    subject    text     not null,
+    family_id  text     not null,
+    expires_at datetime not null,
+    revoked_at datetime,
+    created_at datetime not null default current_timestamp
+);
+
+create index if not exists refresh_token_family_id_idx on refresh_token (family_id);
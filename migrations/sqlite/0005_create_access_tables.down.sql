@@ -0,0 +1,5 @@
+drop table if exists group_curator;
+drop table if exists user_role;
+drop table if exists role_permission;
+drop table if exists role;
+drop table if exists app_user;
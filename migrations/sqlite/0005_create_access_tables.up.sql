@@ -0,0 +1,52 @@
+create table if not exists app_user
+(
+    username      text primary key,
+    password_hash text not null,
+    student_id    integer references student (id) on delete set null
+);
+
+create table if not exists role
+(
+    name text primary key
+);
+
+create table if not exists role_permission
+(
+    role       text not null references role (name) on delete cascade,
+    permission text not null,
+    scope      text not null check (scope in ('all', 'curated', 'own')),
+    primary key (role, permission)
+);
+
+create table if not exists user_role
+(
+    username text not null references app_user (username) on delete cascade,
+    role     text not null references role (name) on delete cascade,
+    primary key (username, role)
+);
+
+create table if not exists group_curator
+(
+    group_id integer not null references "group" (id) on delete cascade,
+    username text    not null references app_user (username) on delete cascade,
+    primary key (group_id, username)
+);
+
+create index if not exists group_curator_username_idx on group_curator (username);
+
+insert or ignore into role(name)
+values ('admin'),
+       ('teacher'),
+       ('student');
+
+insert or ignore into role_permission(role, permission, scope)
+values ('admin', 'students:read', 'all'),
+       ('admin', 'students:write', 'all'),
+       ('admin', 'groups:read', 'all'),
+       ('admin', 'groups:manage', 'all'),
+       ('admin', 'users:manage', 'all'),
+       ('teacher', 'students:read', 'curated'),
+       ('teacher', 'students:write', 'curated'),
+       ('teacher', 'groups:read', 'curated'),
+       ('student', 'students:read', 'own'),
+       ('student', 'groups:read', 'own');
@@ -0,0 +1,54 @@
+-- only the default tenant fits into the old schema
+delete from student where tenant_id <> 'default';
+delete from "group" where tenant_id <> 'default';
+delete from app_user where tenant_id <> 'default';
+
+alter table refresh_token
+    drop column tenant_id;
+
+create table app_user_old
+(
+    username      text primary key,
+    password_hash text not null,
+    student_id    integer references student (id) on delete set null
+);
+
+insert into app_user_old(username, password_hash, student_id)
+select username, password_hash, student_id
+from app_user;
+
+drop table app_user;
+alter table app_user_old rename to app_user;
+
+create table student_old
+(
+    id           integer primary key autoincrement,
+    full_name    text    not null,
+    age          integer not null,
+    group_number text    not null,
+    email        text    not null unique
+);
+
+insert into student_old(id, full_name, age, group_number, email)
+select id, full_name, age, group_number, email
+from student;
+
+drop table student;
+alter table student_old rename to student;
+
+create index if not exists student_group_number_idx on student (group_number);
+
+create table group_old
+(
+    id           integer primary key autoincrement,
+    group_number text not null unique
+);
+
+insert into group_old(id, group_number)
+select id, group_number
+from "group";
+
+drop table "group";
+alter table group_old rename to "group";
+
+drop table if exists tenant;
@@ -0,0 +1,54 @@
+create table if not exists tenant
+(
+    id         text primary key,
+    name       text     not null,
+    created_at datetime not null default current_timestamp
+);
+
+insert or ignore into tenant(id, name)
+values ('default', 'Default');
+
+-- SQLite can't change unique constraints, so "group" and student are rebuilt
+-- with existing rows moved to the default tenant. The migrator turns foreign
+-- keys off meanwhile, otherwise dropping the tables would cascade.
+create table group_new
+(
+    id           integer primary key autoincrement,
+    tenant_id    text not null references tenant (id),
+    group_number text not null,
+    unique (tenant_id, group_number)
+);
+
+insert into group_new(id, tenant_id, group_number)
+select id, 'default', group_number
+from "group";
+
+drop table "group";
+alter table group_new rename to "group";
+
+create table student_new
+(
+    id           integer primary key autoincrement,
+    tenant_id    text    not null references tenant (id),
+    full_name    text    not null,
+    age          integer not null,
+    group_number text    not null,
+    email        text    not null,
+    unique (tenant_id, email)
+);
+
+insert into student_new(id, tenant_id, full_name, age, group_number, email)
+select id, 'default', full_name, age, group_number, email
+from student;
+
+drop table student;
+alter table student_new rename to student;
+
+create index if not exists student_group_number_idx on student (tenant_id, group_number);
+
+-- a column added with a reference can't drop its default in SQLite
+alter table app_user
+    add column tenant_id text not null default 'default' references tenant (id);
+
+alter table refresh_token
+    add column tenant_id text;
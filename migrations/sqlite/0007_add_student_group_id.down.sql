@@ -0,0 +1,24 @@
+drop view if exists student_view;
+
+create table student_old
+(
+    id           integer primary key autoincrement,
+    tenant_id    text    not null references tenant (id),
+    full_name    text    not null,
+    age          integer not null,
+    group_number text    not null,
+    email        text    not null,
+    unique (tenant_id, email)
+);
+
+insert into student_old(id, tenant_id, full_name, age, group_number, email)
+select s.id, s.tenant_id, s.full_name, s.age, coalesce(g.group_number, ''), s.email
+from student s
+         left join "group" g on g.id = s.group_id;
+
+drop table student;
+alter table student_old rename to student;
+
+create index if not exists student_group_number_idx on student (tenant_id, group_number);
+
+drop index if exists group_tenant_id_id_idx;
@@ -0,0 +1,44 @@
+-- groups that students name but nobody created are created, so converting
+-- the references loses none of them
+insert into "group"(tenant_id, group_number)
+select distinct s.tenant_id, s.group_number
+from student s
+where s.group_number <> ''
+  and not exists (select 1
+                  from "group" g
+                  where g.tenant_id = s.tenant_id
+                    and g.group_number = s.group_number);
+
+-- the tenant is part of the reference, so it can't cross tenants
+create unique index if not exists group_tenant_id_id_idx on "group" (tenant_id, id);
+
+-- students refer to groups by id from now on, a null group_id means the
+-- student has no group. Deleting a referenced group fails, the app decides
+-- what happens to its students first.
+create table student_new
+(
+    id        integer primary key autoincrement,
+    tenant_id text    not null references tenant (id),
+    full_name text    not null,
+    age       integer not null,
+    group_id  integer,
+    email     text    not null,
+    unique (tenant_id, email),
+    foreign key (tenant_id, group_id) references "group" (tenant_id, id)
+);
+
+insert into student_new(id, tenant_id, full_name, age, group_id, email)
+select s.id, s.tenant_id, s.full_name, s.age, g.id, s.email
+from student s
+         left join "group" g on g.tenant_id = s.tenant_id and g.group_number = s.group_number;
+
+drop table student;
+alter table student_new rename to student;
+
+create index if not exists student_group_id_idx on student (tenant_id, group_id);
+
+-- students are read with the number of their group, which follows renames
+create view student_view as
+select s.id, s.tenant_id, s.full_name, s.age, s.group_id, coalesce(g.group_number, '') as group_number, s.email
+from student s
+         left join "group" g on g.id = s.group_id;
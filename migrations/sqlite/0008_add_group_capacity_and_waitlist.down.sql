@@ -0,0 +1,8 @@
+drop index if exists waitlist_entry_student_id_idx;
+drop table if exists waitlist_entry;
+drop index if exists student_tenant_id_id_idx;
+
+alter table "group"
+    drop column waitlist;
+alter table "group"
+    drop column capacity;
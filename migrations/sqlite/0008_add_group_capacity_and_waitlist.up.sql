@@ -0,0 +1,25 @@
+-- a capacity of 0 leaves the group unlimited, an empty waitlist mode turns
+-- the waitlist of the group off
+alter table "group"
+    add column capacity integer not null default 0 check (capacity >= 0);
+alter table "group"
+    add column waitlist text not null default '' check (waitlist in ('', 'fifo', 'priority'));
+
+create unique index if not exists student_tenant_id_id_idx on student (tenant_id, id);
+
+-- students waiting for a seat, ids give the order they joined in. Entries go
+-- away with their group or student.
+create table if not exists waitlist_entry
+(
+    id         integer primary key autoincrement,
+    tenant_id  text     not null,
+    group_id   integer  not null,
+    student_id integer  not null,
+    priority   integer  not null default 0,
+    queued_at  datetime not null default current_timestamp,
+    unique (group_id, student_id),
+    foreign key (tenant_id, group_id) references "group" (tenant_id, id) on delete cascade,
+    foreign key (tenant_id, student_id) references student (tenant_id, id) on delete cascade
+);
+
+create index if not exists waitlist_entry_student_id_idx on waitlist_entry (tenant_id, student_id);
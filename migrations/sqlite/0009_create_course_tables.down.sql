@@ -0,0 +1,7 @@
+delete
+from role_permission
+where permission in ('courses:read', 'courses:manage', 'enrollments:manage');
+
+drop view if exists enrollment_view;
+drop table if exists enrollment;
+drop table if exists course;
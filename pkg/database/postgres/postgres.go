@@ -3,13 +3,17 @@ package postgres
 import (
 	"StudentManager/internal/config"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
+	"log/slog"
 )
 
 func New(database config.Database) (*pgxpool.Pool, error) {
+	if database.Host == "" || database.Port == "" || database.Dbname == "" || database.Username == "" {
+		return nil, errors.New("postgres connection settings are incomplete")
+	}
 
 	// Формирование строки подключения
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
@@ -20,23 +24,21 @@ func New(database config.Database) (*pgxpool.Pool, error) {
 		database.Dbname,
 		database.Sslmode)
 
-	_, err := pgx.ParseConfig(connStr)
-	if err != nil {
-		log.Fatal(err)
+	if _, err := pgx.ParseConfig(connStr); err != nil {
+		return nil, fmt.Errorf("invalid postgres connection settings: %w", err)
 	}
 
 	client, err := pgxpool.Connect(context.Background(), connStr)
 	if err != nil {
-		log.Fatalf("error: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
 	// Проверка соединения
 	if err := client.Ping(context.Background()); err != nil {
-		log.Fatalf("error: %v", err)
-		return nil, err
+		client.Close()
+		return nil, fmt.Errorf("failed to ping postgres: %w", err)
 	}
 
-	log.Println("Successfully connected to the database!")
+	slog.Info("Successfully connected to the database!")
 	return client, nil
 }
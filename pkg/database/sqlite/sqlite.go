@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"StudentManager/internal/config"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+func New(database config.Database) (*sql.DB, error) {
+	if database.Path == "" {
+		return nil, errors.New("sqlite database path is not set")
+	}
+
+	// foreign keys are off by default in SQLite, and without busy_timeout
+	// concurrent writers fail immediately instead of waiting for the lock
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	// take the write lock when a transaction starts instead of upgrading it later
+	params.Add("_txlock", "immediate")
+	dsn := fmt.Sprintf("file:%s?%s", database.Path, params.Encode())
+
+	client, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, one connection avoids SQLITE_BUSY on lock upgrades
+	client.SetMaxOpenConns(1)
+
+	if err := client.PingContext(context.Background()); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	slog.Info("Successfully opened the sqlite database", "path", database.Path)
+	return client, nil
+}